@@ -0,0 +1,125 @@
+package cfgm
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// configArchive 通过 [WithConfigArchive] 设置的归档文件及其中的配置条目。
+type configArchive struct {
+	path  string // 归档文件路径
+	entry string // 归档内的条目名称
+}
+
+// WithConfigArchive 从归档文件的指定条目读取配置，替代配置文件搜索。
+//
+// 支持 .zip、.tar、.tar.gz 和 .tgz 归档（按归档文件的扩展名判断）。
+// 条目内容与普通配置文件一样经过模板展开，并按条目的扩展名选择解析器（如 app.json → JSON）。
+// 相对的归档路径按 [WithBaseDir] 的规则解析；条目名称使用 / 分隔（如 "config/app.yaml"）。
+//
+// 归档文件或条目不存在时 [Load] 返回错误（包装 fs.ErrNotExist），不回退到默认值。
+// 归档条目不支持 [WithIncludes]。
+//
+// 示例：
+//
+//	cfgm.Load(defaultConfig, cfgm.WithConfigArchive("bundle.zip", "config/app.yaml"))
+func WithConfigArchive(archivePath, entryName string) Option {
+	return func(o *options) {
+		o.configArchive = &configArchive{path: archivePath, entry: entryName}
+	}
+}
+
+// findArchiveConfig 读取 [WithConfigArchive] 指定的归档条目，返回来源标识及模板展开后的内容。
+//
+// 来源标识为 "<归档路径>!<条目名称>"，用于错误信息和选择解析器。
+func findArchiveConfig(o *options) (string, []byte, error) {
+	archivePath := o.configArchive.path
+	if o.baseDir != "" && !filepath.IsAbs(archivePath) {
+		archivePath = filepath.Join(o.baseDir, archivePath)
+	}
+	entry := path.Clean(strings.TrimPrefix(o.configArchive.entry, "/"))
+	source := archivePath + "!" + entry
+
+	content, err := readArchiveEntry(o, archivePath, entry)
+	if err != nil {
+		return "", nil, err
+	}
+	content, err = o.decodeContent(source, content)
+	if err != nil {
+		return "", nil, err
+	}
+	content, err = expandConfigContent(o, archivePath, content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return source, content, nil
+}
+
+// readArchiveEntry 读取归档文件中指定条目的原始内容，受 [WithMaxConfigSize] 限制。
+func readArchiveEntry(o *options, archivePath, entry string) ([]byte, error) {
+	lower := strings.ToLower(archivePath)
+	if strings.HasSuffix(lower, ".zip") {
+		return readZipEntry(o, archivePath, entry)
+	}
+
+	f, err := os.Open(archivePath) //nolint:gosec // path is from trusted config
+	if err != nil {
+		return nil, fmt.Errorf("open config archive: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("decompress config archive %s: %w", archivePath, err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	} else if !strings.HasSuffix(lower, ".tar") {
+		return nil, fmt.Errorf("config archive %s: unsupported archive format", archivePath)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read config archive %s: %w", archivePath, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Clean(hdr.Name) == entry {
+			return o.readSource(archivePath+"!"+entry, tr)
+		}
+	}
+
+	return nil, fmt.Errorf("config archive %s: entry %s: %w", archivePath, entry, fs.ErrNotExist)
+}
+
+// readZipEntry 读取 zip 归档中指定条目的内容。
+func readZipEntry(o *options, archivePath, entry string) ([]byte, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open config archive: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	f, err := zr.Open(entry)
+	if err != nil {
+		return nil, fmt.Errorf("config archive %s: entry %s: %w", archivePath, entry, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return o.readSource(archivePath+"!"+entry, f)
+}
@@ -0,0 +1,128 @@
+package cfgm
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// 归档配置测试
+// =============================================================================
+
+// writeZip 在内存中构建 zip 归档并写入临时文件，返回归档路径。
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+
+	return path
+}
+
+// writeTarGz 在内存中构建 tar.gz 归档并写入临时文件，返回归档路径。
+func writeTarGz(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+
+	return path
+}
+
+func TestLoadWithConfigArchive(t *testing.T) {
+	type Config struct {
+		Name   string `koanf:"name"`
+		Server struct {
+			Port int `koanf:"port"`
+		} `koanf:"server"`
+	}
+	files := map[string]string{
+		"config/app.yaml": "name: '{{env `ARCHIVE_APP_NAME` | default `zipped`}}'\nserver:\n  port: 9090\n",
+		"config/app.json": `{"name": "json", "server": {"port": 7070}}`,
+	}
+
+	t.Run("yaml entry", func(t *testing.T) {
+		t.Setenv("ARCHIVE_APP_NAME", "from-env")
+
+		cfg, err := Load(Config{Name: "default"}, WithConfigArchive(writeZip(t, files), "config/app.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", cfg.Name, "entry is template expanded")
+		assert.Equal(t, 9090, cfg.Server.Port)
+	})
+
+	t.Run("parser by entry extension", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigArchive(writeZip(t, files), "config/app.json"))
+		require.NoError(t, err)
+		assert.Equal(t, "json", cfg.Name)
+		assert.Equal(t, 7070, cfg.Server.Port)
+	})
+
+	t.Run("tar.gz", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigArchive(writeTarGz(t, files), "config/app.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "zipped", cfg.Name)
+		assert.Equal(t, 9090, cfg.Server.Port)
+	})
+
+	t.Run("relative to base dir", func(t *testing.T) {
+		archive := writeZip(t, files)
+
+		cfg, err := Load(Config{}, WithBaseDir(filepath.Dir(archive)), WithConfigArchive("bundle.zip", "config/app.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+	})
+
+	t.Run("missing archive", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigArchive(filepath.Join(t.TempDir(), "missing.zip"), "app.yaml"))
+		require.Error(t, err)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		assert.Contains(t, err.Error(), "open config archive")
+	})
+
+	t.Run("missing entry", func(t *testing.T) {
+		for _, archive := range []string{writeZip(t, files), writeTarGz(t, files)} {
+			_, err := Load(Config{}, WithConfigArchive(archive, "config/missing.yaml"))
+			require.Error(t, err)
+			require.ErrorIs(t, err, fs.ErrNotExist)
+			assert.Contains(t, err.Error(), "entry config/missing.yaml")
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"bundle.rar": ""})
+
+		_, err := Load(Config{}, WithConfigArchive(filepath.Join(dir, "bundle.rar"), "app.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported archive format")
+	})
+}
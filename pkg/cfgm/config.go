@@ -1,21 +1,35 @@
 package cfgm
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"maps"
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/knadh/koanf/providers/structs"
 	"github.com/knadh/koanf/v2"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/text/encoding/htmlindex"
 
 	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/tmpl"
 )
@@ -25,14 +39,135 @@ type options struct {
 	appName             string // 应用名称，用于生成默认配置路径
 	cmd                 *cli.Command
 	configPaths         []string
-	baseDir             string // 路径基准目录，用于将相对路径转换为绝对路径
-	baseDirSet          bool   // 是否显式设置了 baseDir（区分空字符串和未设置）
-	envPrefix           string
-	envBindings         map[string]string
+	section             string   // 配置文件中作为根节点加载的子树，空表示整个文件
+	baseDir             string   // 路径基准目录，用于将相对路径转换为绝对路径
+	baseDirSet          bool     // 是否显式设置了 baseDir（区分空字符串和未设置）
+	envPrefixes         []string // 环境变量前缀，靠前的优先
+	envBindings         map[string]codeEnvBinding
+	envBindingRegexes   []envBindingRegex // 按正则匹配环境变量名生成的绑定
 	envBindKey          string
-	noTemplateExpansion bool // 是否禁用配置文件模板展开（默认启用）
+	noTemplateExpansion bool              // 是否禁用配置文件模板展开（默认启用）
+	templateData        map[string]string // 额外的模板数据
+	templateDelims      [2]string         // 模板分隔符，空字符串表示默认的 {{ 和 }}
+	templateMissingZero bool              // 缺失的模板变量展开为空字符串而非 <no value>
+	noEnv               bool              // 是否完全忽略环境变量
+	includes            bool              // 是否解析 YAML 中的 !include 指令
+	fileTags            bool              // 是否解析 YAML 中的 !file 标签
+	providers           []providerSource  // 额外的 koanf provider，按注册顺序在配置文件之后加载
+	hooks               []Hooks           // 生命周期回调
+	dockerSecrets       bool              // 是否从 Docker secrets 目录读取配置
+	dockerSecretsPrefix string            // Docker secret 文件名前缀
+	dockerSecretsDir    string            // Docker secrets 目录，空表示 /run/secrets
+	strictCLIFlags      bool              // CLI flag 冲突时返回错误而非警告
+	cliFlagAliases      map[string]string // 显式的 CLI flag 名称 → koanf key 映射
+	mergeCLIMaps        bool              // map flag 的条目合并到已有的 map 而非整体替换
+	strictBindings      bool              // 环境变量绑定的目标路径不存在时返回错误
+	maxConfigSize       int64             // 单个配置源的最大字节数，<= 0 表示不限制
+	configEncoding      string            // 配置文件的字符编码，空表示 UTF-8
+	mergeFunc           MergeFunc         // 自定义合并规则，nil 表示使用 koanf 默认规则
+	debugConfigEnv      string            // 为真值时记录生效配置的环境变量名
+	logger              *slog.Logger      // 日志记录器，默认 slog.Default()
+
+	// 自定义解码钩子，在内置钩子之前执行
+	decodeHooks []mapstructure.DecodeHookFunc
+
+	// 环境变量绑定实际提供值时的审计回调
+	envAuditFunc func(envKey, configPath string)
+
+	// 从归档文件读取配置，设置后替代配置文件搜索
+	configArchive *configArchive
+
+	// 支持 context 的 provider 单次读取的超时，<= 0 表示不限制
+	sourceTimeout time.Duration
+
+	// 加载和校验成功后执行的副作用回调，参数为 *T
+	afterLoad []func(cfg any) error
+
+	// 配置源 key 的转换函数，nil 表示不转换
+	keyMapper func(key string) string
+
+	// 代码绑定的注册次数，用于按注册顺序决定同一配置路径的优先级
+	envBindingCalls int
+
+	// 前缀绑定限定的 koanf key，nil 表示为全部 key 生成绑定，见 [WithEnvPrefixExplicit]
+	envPrefixKeys []string
+
+	// 加载指标回调，见 [WithMetrics]
+	metrics Metrics
+
+	// 解析前删除的 key，见 [WithIgnoreKeys]
+	ignoreKeys []string
+
+	// 加载过程中产生的警告，见 [LoadWithResult]
+	warnings []Warning
+
+	// 模板数据文件，按注册顺序合并，见 [WithTemplateDataFile]
+	templateDataFiles []string
+	templateFileData  map[string]string // 模板数据文件合并后的结果，每次加载只读取一次
+
+	// 指定配置文件路径的环境变量名及其生效的路径，见 [WithConfigPathsOverrideEnv]
+	configPathsOverrideEnv string
+	configPathOverride     string
+	configPathOverrideFrom string // 生效路径的来源，用于错误信息，如 "env CI_CONFIG"
+
+	// 指定配置文件路径的 CLI flag 名称，见 [WithConfigFlag]
+	configFlag string
+
+	// 尽力加载的覆盖文件，按注册顺序在配置文件之后加载，见 [WithLenientFile]
+	lenientFiles []string
+
+	// 按路径强制指定的配置格式（路径 → yaml/json），见 [WithConfigPathParser]
+	pathFormats map[string]string
+
+	// 解析前按 JSON Schema 校验合并后的配置，见 [WithSchemaValidation]
+	schemaValidation bool
+
+	// 按 koanf key 注册的值转换函数，按注册顺序执行，见 [WithValueTransformer]
+	valueTransformers []valueTransformer
+
+	// 前缀绑定仅填充配置文件未设置的 key，见 [WithEnvFillOnly]
+	envFillOnly bool
+	fileKeys    []string // 配置文件设置的 key，加载时记录
+
+	// 从 context 取得的配置文件路径（string）或内容（[]byte），见 [WithConfigFromContext]
+	contextConfig any
+
+	// 配置文件的最大年龄（按修改时间），<= 0 表示不检查，见 [WithMaxConfigAge]
+	maxConfigAge time.Duration
+
+	// 是否在加载开始时捕获环境变量快照，见 [WithEnvSnapshot]
+	envSnapshot bool
+
+	// 加载开始时捕获的环境变量快照，nil 表示直接读取进程环境变量
+	env map[string]string
+
+	// 校验 envrequired:"true" 字段由环境变量提供，见 [WithEnforceEnvRequired]
+	enforceEnvRequired bool
+	envRequiredPaths   map[string]bool // 带 envrequired:"true" 标签的配置路径，加载时记录
+	envSetPaths        map[string]bool // 由环境变量绑定写入的配置路径，加载时记录
+
+	// [WithEnvBindingRegex] 中无效 pattern 的编译错误，由 [Load] 返回
+	envBindingRegexErrs []error
+
+	// 选中配置的原始内容（模板展开和 !include 处理前），用于定位解码错误所在的行号
+	configRaw []byte
 }
 
+// defaultSourceTimeout [ContextProvider] 读取的默认超时，见 [WithSourceTimeout]。
+const defaultSourceTimeout = 10 * time.Second
+
+// ErrConfigTooLarge 配置源超过 [WithMaxConfigSize] 设置的大小限制。
+var ErrConfigTooLarge = errors.New("config source too large")
+
+// ErrConfigTooOld 配置文件的修改时间早于 [WithMaxConfigAge] 允许的范围。
+var ErrConfigTooOld = errors.New("config file too old")
+
+// ErrEnvRequired envrequired:"true" 字段未由环境变量提供，见 [WithEnforceEnvRequired]。
+var ErrEnvRequired = errors.New("required env not set")
+
+// ErrConfigPathIsDir 配置文件路径指向一个目录。
+var ErrConfigPathIsDir = errors.New("config path is a directory")
+
 // Option 配置加载选项函数。
 type Option func(*options)
 
@@ -45,6 +180,31 @@ func WithCommand(cmd *cli.Command) Option {
 	}
 }
 
+// WithSectionFromCommand 以命令名称作为配置文件的节选择器，仅加载该节的内容。
+//
+// 适用于多命令工具中每个子命令使用配置文件的独立节的场景：
+// 对于名为 client 的子命令，配置文件中 client: 节的内容作为 defaultConfig 的根加载。
+// 环境变量和 CLI flags 的 key 仍相对于 defaultConfig 的结构。
+//
+// 示例：
+//
+//	# config.yaml
+//	client:
+//	  url: http://localhost:8080
+//	  timeout: 30s
+//
+//	func clientAction(ctx context.Context, cmd *cli.Command) error {
+//	    cfg, err := cfgm.Load(ClientConfig{}, cfgm.WithSectionFromCommand(cmd))
+//	    ...
+//	}
+func WithSectionFromCommand(cmd *cli.Command) Option {
+	return func(o *options) {
+		if cmd != nil {
+			o.section = cmd.Name
+		}
+	}
+}
+
 // WithAppName 设置应用名称。
 //
 // 设置后会自动配置默认的配置文件搜索路径（如果未通过 [WithConfigPaths] 显式设置）。
@@ -65,12 +225,146 @@ func WithAppName(name string) Option {
 // WithConfigPaths 设置配置文件搜索路径。
 //
 // 按顺序搜索，找到第一个即停止。可使用 [DefaultPaths] 获取默认路径。
+// 不存在的路径会被跳过，但路径存在且是目录时 [Load] 返回 [ErrConfigPathIsDir]。
 func WithConfigPaths(paths ...string) Option {
 	return func(o *options) {
 		o.configPaths = paths
 	}
 }
 
+// WithConfigPathsOverrideEnv 在环境变量 envKey 非空时，使用其值作为唯一的配置文件路径。
+//
+// 环境变量的值完全替换搜索路径列表（包括 [WithConfigPaths] 和默认路径），而非追加到列表前面，
+// 适用于 CI 等注入临时配置文件的场景。相对路径基于当前工作目录解析，不受 [WithBaseDir] 影响。
+// 指定的文件不存在时 [Load] 返回 error，避免静默回退到默认配置。[WithNoEnv] 时不生效。
+//
+// 示例：
+//
+//	cfgm.Load(defaultConfig,
+//	    cfgm.WithConfigPaths("config.yaml"),
+//	    cfgm.WithConfigPathsOverrideEnv("CI_CONFIG"),
+//	)
+//
+//	// CI_CONFIG=/tmp/ci.yaml ./myapp  → 仅加载 /tmp/ci.yaml
+func WithConfigPathsOverrideEnv(envKey string) Option {
+	return func(o *options) {
+		o.configPathsOverrideEnv = envKey
+	}
+}
+
+// WithConfigFlag 在 CLI flag 被显式设置时，使用其值作为唯一的配置文件路径，需配合 [WithCommand] 使用。
+//
+// 与 [WithConfigPathsOverrideEnv] 相同，flag 的值完全替换搜索路径列表，指定的文件不存在时 [Load] 返回 error；
+// 两者同时生效时 flag 优先。flag 未设置时（即使有默认值）仍使用原有的搜索路径。
+// flag 可以定义在父命令上，在子命令中加载时沿命令链查找。
+//
+// 示例：
+//
+//	app := &cli.Command{
+//	    Flags:    []cli.Flag{&cli.StringFlag{Name: "config", Aliases: []string{"c"}}},
+//	    Commands: []*cli.Command{{
+//	        Name: "serve",
+//	        Action: func(ctx context.Context, cmd *cli.Command) error {
+//	            cfg, err := cfgm.Load(DefaultConfig(), cfgm.WithCommand(cmd), cfgm.WithConfigFlag("config"))
+//	            ...
+//	        },
+//	    }},
+//	}
+//
+//	// myapp --config /etc/myapp/prod.yaml serve
+func WithConfigFlag(name string) Option {
+	return func(o *options) {
+		o.configFlag = name
+	}
+}
+
+// WithLenientFile 在配置文件之后尽力加载一个覆盖文件，解析失败时记录警告而非返回错误。
+//
+// 文件不存在时跳过；读取、模板展开或解析失败时记录 [WarningLenientFile] 并忽略该文件，
+// 加载继续使用其余来源。适用于可能正在编辑中的本地覆盖文件。
+// 多次调用按注册顺序加载，后加载的优先；相对路径按 [WithBaseDir] 的规则解析。
+//
+// 示例：
+//
+//	res, err := cfgm.LoadWithResult(defaultConfig,
+//	    cfgm.WithConfigPaths("config.yaml"),
+//	    cfgm.WithLenientFile("config.local.yaml"),
+//	)
+func WithLenientFile(path string) Option {
+	return func(o *options) {
+		o.lenientFiles = append(o.lenientFiles, path)
+	}
+}
+
+// contextConfigSource 通过 [WithConfigFromContext] 提供配置内容时使用的来源名称。
+const contextConfigSource = "<context>"
+
+// WithConfigFromContext 从 ctx 中 key 对应的值获取配置，适用于由中间件决定配置的框架。
+//
+// 值的类型决定加载方式：
+//   - string: 配置文件路径，作为唯一的配置文件（同 [WithConfigPathsOverrideEnv]，相对路径基于当前工作目录），文件不存在时返回 error
+//   - []byte: 配置内容，按 YAML 解析（JSON 是 YAML 的子集，同样适用），来源名称为 <context>
+//
+// ctx 中没有该 key 或值为空时不生效，按常规规则搜索配置文件；其他类型的值使 [Load] 返回 error。
+// [WithConfigPathsOverrideEnv] 和 [WithConfigFlag] 指定的路径优先。
+//
+// 示例：
+//
+//	type configPathKey struct{}
+//
+//	ctx = context.WithValue(ctx, configPathKey{}, "/etc/tenant-a/config.yaml")
+//	cfg, err := cfgm.Load(DefaultConfig(), cfgm.WithConfigFromContext(ctx, configPathKey{}))
+func WithConfigFromContext(ctx context.Context, key any) Option {
+	return func(o *options) {
+		o.contextConfig = ctx.Value(key)
+	}
+}
+
+// WithConfigPathParser 为指定路径的配置文件强制使用 format 对应的解析器，不再按扩展名推断。
+//
+// format 支持 "yaml"（或 "yml"）和 "json"，不区分大小写，其他值使 [Load] 返回 error。
+// path 按 [WithBaseDir] 的规则解析后与实际加载的文件路径比较，适用于 [WithConfigPaths]、
+// [WithLenientFile] 等加载的文件。
+//
+// 示例：
+//
+//	// config.conf 的内容为 JSON
+//	cfgm.Load(defaultConfig,
+//	    cfgm.WithConfigPaths("config.conf"),
+//	    cfgm.WithConfigPathParser("config.conf", "json"),
+//	)
+func WithConfigPathParser(path, format string) Option {
+	return func(o *options) {
+		if o.pathFormats == nil {
+			o.pathFormats = make(map[string]string)
+		}
+		o.pathFormats[path] = strings.ToLower(format)
+	}
+}
+
+// configBasenameExts [WithConfigBasename] 依次尝试的扩展名。
+var configBasenameExts = []string{".yaml", ".yml", ".json"}
+
+// WithConfigBasename 按不带扩展名的基础名称搜索配置文件。
+//
+// 依次尝试 name.yaml、name.yml、name.json，加载第一个存在的文件，并按其扩展名选择解析器。
+// 相对路径按 [WithBaseDir] 的规则解析，等价于将这些路径传给 [WithConfigPaths]。
+//
+// 不尝试 name.toml：本包没有 TOML 解析器，配置文件仅支持 YAML 和 JSON 格式。
+//
+// 示例：
+//
+//	// config.yaml 不存在时加载 config.json
+//	cfgm.Load(defaultConfig, cfgm.WithConfigBasename("config"))
+func WithConfigBasename(name string) Option {
+	return func(o *options) {
+		o.configPaths = make([]string, 0, len(configBasenameExts))
+		for _, ext := range configBasenameExts {
+			o.configPaths = append(o.configPaths, name+ext)
+		}
+	}
+}
+
 // WithBaseDir 设置相对路径的基准目录。
 //
 // 默认情况下，[Load] 使用项目根目录（go.mod 所在目录）作为基准。
@@ -86,6 +380,28 @@ func WithBaseDir(path string) Option {
 	}
 }
 
+// executablePath 返回当前可执行文件的路径，测试中可替换。
+var executablePath = os.Executable
+
+// WithBaseDirExecutable 使用当前可执行文件所在目录作为相对路径的基准目录。
+//
+// 适用于配置文件与二进制文件一起部署的场景，如 /opt/myapp/myapp 与 /opt/myapp/config.yaml。
+// 目录取自 os.Executable，不解析符号链接；无法获取可执行文件路径时回退到当前工作目录。
+//
+// 示例：
+//
+//	// 加载可执行文件旁的 config.yaml
+//	cfgm.Load(defaultConfig, cfgm.WithBaseDirExecutable(), cfgm.WithConfigPaths("config.yaml"))
+func WithBaseDirExecutable() Option {
+	return func(o *options) {
+		o.baseDir = ""
+		if exe, err := executablePath(); err == nil {
+			o.baseDir = filepath.Dir(exe)
+		}
+		o.baseDirSet = true
+	}
+}
+
 // WithEnvPrefix 设置环境变量前缀。
 //
 // 启用后，会从环境变量加载配置。优先级：配置文件 < WithEnvPrefix < WithEnvBindKey < WithEnvBindings < CLI flags。
@@ -103,31 +419,151 @@ func WithBaseDir(path string) Option {
 // 若同一配置路径被 [WithEnvBindings] 或 [WithEnvBindKey] 显式绑定，则显式绑定优先。
 func WithEnvPrefix(prefix string) Option {
 	return func(o *options) {
-		o.envPrefix = prefix
+		o.envPrefixes = []string{prefix}
+		o.envPrefixKeys = nil
+	}
+}
+
+// WithEnvPrefixExplicit 设置环境变量前缀，但仅为列出的 koanf key 生成绑定。
+//
+// 命名规则与 [WithEnvPrefix] 相同，未列出的配置不受同前缀环境变量影响，
+// 避免环境中恰好存在的同名变量意外覆盖配置。key 使用完整的 koanf 路径（如 "server.addr"）。
+//
+// 示例：
+//
+//	// 仅 MYAPP_SERVER_ADDR 生效，MYAPP_SERVER_PORT 被忽略
+//	cfgm.WithEnvPrefixExplicit("MYAPP_", "server.addr", "debug")
+func WithEnvPrefixExplicit(prefix string, keys ...string) Option {
+	return func(o *options) {
+		o.envPrefixes = []string{prefix}
+		o.envPrefixKeys = append([]string{}, keys...)
+	}
+}
+
+// WithEnvPrefixMulti 设置多个环境变量前缀，适用于品牌迁移等需要同时兼容新旧前缀的场景。
+//
+// 每个前缀都按 [WithEnvPrefix] 的规则生成绑定，映射到相同的 koanf key。
+// 同一配置路径的多个前缀变量同时设置时，靠前的前缀优先。
+//
+// 示例：
+//
+//	// NEWAPP_PORT 和 OLDAPP_PORT 都映射到 port，同时设置时 NEWAPP_PORT 生效
+//	cfgm.WithEnvPrefixMulti("NEWAPP_", "OLDAPP_")
+func WithEnvPrefixMulti(prefixes ...string) Option {
+	return func(o *options) {
+		o.envPrefixes = slices.Clone(prefixes)
+		o.envPrefixKeys = nil
+	}
+}
+
+// WithEnvFillOnly 使 [WithEnvPrefix] 等前缀生成的环境变量绑定仅填充配置文件未设置的 key，
+// 对前缀层而言优先级变为 默认值 < 前缀环境变量 < 配置文件。
+//
+// 适用于环境变量作为兜底、配置文件中的显式值不应被覆盖的场景。
+// [WithEnvBindings]、[WithEnvBindKey] 等显式绑定保持原有优先级，仍覆盖配置文件。
+//
+// 示例：
+//
+//	// config.yaml 设置了 port: 9090 时 MYAPP_PORT 被忽略，未设置时 MYAPP_PORT 生效
+//	cfgm.Load(defaultConfig, cfgm.WithEnvPrefix("MYAPP_"), cfgm.WithEnvFillOnly())
+func WithEnvFillOnly() Option {
+	return func(o *options) {
+		o.envFillOnly = true
+	}
+}
+
+// WithEnvSnapshot 在加载开始时捕获一次进程环境变量，整个加载过程统一使用该快照。
+//
+// 前缀绑定、显式绑定、正则绑定、模板展开以及 [WithConfigPathsOverrideEnv] 等读取环境变量的位置
+// 都基于同一份快照，避免并发程序在加载期间修改环境变量导致各来源读到不一致的值。
+//
+// 示例：
+//
+//	cfgm.Load(defaultConfig, cfgm.WithEnvPrefix("MYAPP_"), cfgm.WithEnvSnapshot())
+func WithEnvSnapshot() Option {
+	return func(o *options) {
+		o.envSnapshot = true
+	}
+}
+
+// WithEnforceEnvRequired 要求带 envrequired:"true" 标签的字段必须由其绑定的环境变量提供。
+//
+// 环境变量层应用后检查，字段的值来自配置文件或默认值（包括 Docker secrets）均视为未提供，
+// 返回包装了 [ErrEnvRequired] 的错误，列出所有缺失的字段及其绑定的环境变量。
+// 与 [WithEnvFillOnly] 同时使用时，这些字段的前缀绑定不受"仅填充"限制，环境变量仍覆盖配置文件；
+// 与 [WithNoEnv] 冲突，同时使用时 [Load] 返回 error。
+// 适用于生产环境中必须通过环境变量注入的密钥，开发环境不启用此选项即可继续使用配置文件。
+//
+// 示例：
+//
+//	type Config struct {
+//	    Password string `koanf:"password" envrequired:"true"`
+//	}
+//
+//	opts := []cfgm.Option{cfgm.WithEnvPrefix("MYAPP_")}
+//	if prod {
+//	    opts = append(opts, cfgm.WithEnforceEnvRequired()) // 必须设置 MYAPP_PASSWORD
+//	}
+func WithEnforceEnvRequired() Option {
+	return func(o *options) {
+		o.enforceEnvRequired = true
 	}
 }
 
 // WithEnvBinding 绑定单个环境变量到配置路径。
 //
 // 用于复用第三方工具的标准环境变量，优先级高于 WithEnvPrefix。
+// 与 [WithEnvBindings] 相同，多个代码绑定指向同一配置路径时后注册的优先。
 //
 // 示例：
 //
 //	config.WithEnvBinding("REDIS_URL", "redis.url")
 //	config.WithEnvBinding("ETCDCTL_ENDPOINTS", "etcd.endpoints")
 func WithEnvBinding(envKey, configPath string) Option {
-	return func(o *options) {
-		if o.envBindings == nil {
-			o.envBindings = make(map[string]string)
-		}
-		o.envBindings[envKey] = configPath
+	return WithEnvBindings(map[string]string{envKey: configPath})
+}
+
+// WithEnvBindingStripped 将 prefix+envSuffix 环境变量绑定到配置路径，等价于 [WithEnvBinding](prefix+envSuffix, configPath)。
+//
+// 用于第三方变量带有命名空间前缀、而配置路径不含该前缀的场景，
+// 多个显式绑定共用同一前缀时可避免重复书写。
+//
+// 示例：
+//
+//	// MYAPP_REDIS_URL → redis.url
+//	config.WithEnvBindingStripped("MYAPP_", "REDIS_URL", "redis.url")
+func WithEnvBindingStripped(prefix, envSuffix, configPath string) Option {
+	return WithEnvBinding(prefix+envSuffix, configPath)
+}
+
+// WithEnvBindingsPrefix 批量绑定共用前缀的环境变量，mapping 为 环境变量后缀 → 配置路径。
+//
+// 每个条目等价于 [WithEnvBindingStripped](envPrefix, suffix, configPath)，绑定规则与 [WithEnvBindings] 相同。
+//
+// 示例：
+//
+//	// PG_HOST → db.host, PG_PORT → db.port
+//	config.WithEnvBindingsPrefix("PG_", map[string]string{
+//	    "HOST": "db.host",
+//	    "PORT": "db.port",
+//	})
+func WithEnvBindingsPrefix(envPrefix string, mapping map[string]string) Option {
+	bindings := make(map[string]string, len(mapping))
+	for suffix, configPath := range mapping {
+		bindings[envPrefix+suffix] = configPath
 	}
+
+	return WithEnvBindings(bindings)
 }
 
 // WithEnvBindings 批量绑定环境变量到配置路径。
 //
 // 用于复用第三方工具的标准环境变量，优先级高于 WithEnvPrefix。
 //
+// 多次调用会合并绑定。不同环境变量绑定到同一配置路径且同时设置时，后注册的生效（last-call-wins）；
+// 同一环境变量被多次绑定时，以最后一次绑定的路径为准。
+// 需要不受注册顺序影响的优先级时，使用 [WithEnvBindingsDefault] 或 [WithEnvBindingsOverride]。
+//
 // 示例：
 //
 //	config.WithEnvBindings(map[string]string{
@@ -137,10 +573,75 @@ func WithEnvBinding(envKey, configPath string) Option {
 //	})
 func WithEnvBindings(bindings map[string]string) Option {
 	return func(o *options) {
-		if o.envBindings == nil {
-			o.envBindings = make(map[string]string)
+		o.addEnvBindings(bindings, envBindingTierNormal)
+	}
+}
+
+// WithEnvBindingCoalesce 将多个候选环境变量绑定到同一配置路径，按 envKeys 的顺序取第一个已设置的变量。
+//
+// 与多次调用 [WithEnvBinding] 不同，优先级由参数顺序决定而非注册顺序，
+// 适用于同一配置项有多个惯用变量名的场景。
+//
+// 示例：
+//
+//	// 优先使用 REDIS_URL，未设置时使用 CACHE_URL
+//	config.WithEnvBindingCoalesce("redis.url", "REDIS_URL", "CACHE_URL")
+func WithEnvBindingCoalesce(configPath string, envKeys ...string) Option {
+	return func(o *options) {
+		// 后注册的绑定优先，因此逆序注册使靠前的变量优先
+		for _, envKey := range slices.Backward(envKeys) {
+			o.addEnvBindings(map[string]string{envKey: configPath}, envBindingTierNormal)
 		}
-		maps.Copy(o.envBindings, bindings)
+	}
+}
+
+// WithEnvBindingsDefault 添加低优先级的代码绑定，供库提供可被应用覆盖的默认绑定。
+//
+// 同一配置路径同时被 [WithEnvBindings] 或 [WithEnvBindingsOverride] 绑定时，
+// 无论注册顺序如何，其他绑定的环境变量设置时优先；仅设置了默认绑定的环境变量时使用其值。
+//
+// 示例：
+//
+//	// 库
+//	cfgm.WithEnvBindingsDefault(map[string]string{"REDIS_URL": "redis.url"})
+//	// 应用：MYAPP_REDIS_URL 设置时优先于 REDIS_URL
+//	cfgm.WithEnvBindings(map[string]string{"MYAPP_REDIS_URL": "redis.url"})
+func WithEnvBindingsDefault(bindings map[string]string) Option {
+	return func(o *options) {
+		o.addEnvBindings(bindings, envBindingTierDefault)
+	}
+}
+
+// WithEnvBindingsOverride 添加高优先级的代码绑定，无论注册顺序如何都优先于
+// [WithEnvBindings] 和 [WithEnvBindingsDefault] 对同一配置路径的绑定。
+//
+// 多次调用 WithEnvBindingsOverride 之间仍按注册顺序，后注册的优先。
+func WithEnvBindingsOverride(bindings map[string]string) Option {
+	return func(o *options) {
+		o.addEnvBindings(bindings, envBindingTierOverride)
+	}
+}
+
+// WithEnvBindingRegex 按正则批量绑定环境变量，适用于大量命名相似的环境变量。
+//
+// 每个名称匹配 pattern 的环境变量，通过 replacement 展开（支持 $1、${name} 等分组引用）
+// 得到配置路径，结果转为小写以匹配 koanf key。与 [WithEnvBinding] 同属代码绑定，
+// 同一配置路径同时被两者绑定时，[WithEnvBinding] 优先。pattern 无效时 [Load] 返回 error。
+//
+// 示例：
+//
+//	// FEATURE_BETA=on → features.beta
+//	cfgm.WithEnvBindingRegex(`^FEATURE_(.+)$`, "features.$1")
+func WithEnvBindingRegex(pattern, replacement string) Option {
+	re, err := regexp.Compile(pattern)
+
+	return func(o *options) {
+		if err != nil {
+			o.envBindingRegexErrs = append(o.envBindingRegexErrs, fmt.Errorf("invalid env binding regex %q: %w", pattern, err))
+
+			return
+		}
+		o.envBindingRegexes = append(o.envBindingRegexes, envBindingRegex{re: re, replacement: replacement})
 	}
 }
 
@@ -157,6 +658,9 @@ func WithEnvBindings(bindings map[string]string) Option {
 //
 //	redis:
 //	  url: "redis://localhost:6379"
+//
+// key 支持点号分隔的嵌套节点（如 meta.envbind）。读取后删除该节点，
+// 删除后为空的父节点（如只包含 envbind 的 meta）一并移除。
 func WithEnvBindKey(key string) Option {
 	return func(o *options) {
 		o.envBindKey = key
@@ -178,6 +682,409 @@ func WithoutTemplateExpansion() Option {
 	}
 }
 
+// WithTemplateData 设置模板展开的额外数据。
+//
+// 数据与环境变量合并到模板顶级命名空间，同名时覆盖环境变量。多次调用会合并数据。
+//
+// 示例：
+//
+//	cfgm.WithTemplateData(map[string]string{"region": "us-east-1"})
+//
+//	# config.yaml
+//	endpoint: "https://api.{{.region}}.example.com"
+func WithTemplateData(data map[string]string) Option {
+	return func(o *options) {
+		if o.templateData == nil {
+			o.templateData = make(map[string]string)
+		}
+		maps.Copy(o.templateData, data)
+	}
+}
+
+// WithTemplateDataFile 从 YAML/JSON 文件加载模板展开的额外数据。
+//
+// 文件内容为扁平的 key-value 映射，值为字符串、数字或布尔值，不支持嵌套结构。
+// 数据同名时覆盖环境变量，但会被 [WithTemplateData] 覆盖；多次调用时后注册的文件优先。
+// 相对路径基于 baseDir 解析（见 [WithBaseDir]），文件不存在或格式错误时加载返回 error。
+//
+// 示例：
+//
+//	cfgm.WithTemplateDataFile("vars." + env + ".yaml")
+//
+//	# vars.prod.yaml
+//	region: eu-west-1
+//
+//	# config.yaml
+//	endpoint: "https://api.{{.region}}.example.com"
+func WithTemplateDataFile(path string) Option {
+	return func(o *options) {
+		o.templateDataFiles = append(o.templateDataFiles, path)
+	}
+}
+
+// WithTemplateDelimiters 设置配置文件模板展开使用的分隔符，代替默认的 {{ 和 }}。
+//
+// 配置值本身包含供其他系统使用的 Go 模板语法时，更换分隔符可避免被误展开：
+//
+//	cfgm.WithTemplateDelimiters("[[", "]]")
+//
+//	# config.yaml
+//	api_key: '[[env "API_KEY"]]'
+//	message: "Hello {{.Name}}"   # 原样保留
+func WithTemplateDelimiters(left, right string) Option {
+	return func(o *options) {
+		o.templateDelims = [2]string{left, right}
+	}
+}
+
+// WithTemplateMissingZero 使配置文件中缺失的 {{.VAR}} 展开为空字符串，而非 "<no value>"。
+//
+// 介于默认行为（输出 "<no value>"）和报错之间：避免字面量 "<no value>" 写入配置，同时不中止加载。
+// 变量必须设置时请使用 required 函数。
+//
+//	# config.yaml
+//	proxy: "{{.HTTP_PROXY}}"   # 未设置 HTTP_PROXY 时为 ""
+func WithTemplateMissingZero() Option {
+	return func(o *options) {
+		o.templateMissingZero = true
+	}
+}
+
+// WithNoEnv 完全忽略环境变量，适用于需要可复现结果的测试。
+//
+// 启用后：
+//   - 跳过 [WithEnvPrefix]、[WithEnvBindKey]、[WithEnvBindings] 的环境变量加载
+//   - 模板展开不读取进程环境变量，{{.VAR}} 和 env 函数视所有变量为未设置
+//
+// [WithTemplateData] 提供的数据仍然可用。无需修改进程环境变量。
+func WithNoEnv() Option {
+	return func(o *options) {
+		o.noEnv = true
+	}
+}
+
+// WithIncludes 启用 YAML 配置文件的 !include 指令，用于将大型配置拆分为多个文件。
+//
+// 带 !include 标签的值会被替换为引用文件的内容，相对路径基于包含它的文件所在目录。
+// 被引用的文件同样进行模板展开，并可继续包含其他文件；循环包含会返回错误。
+//
+// 示例：
+//
+//	# config.yaml
+//	database: !include db.yaml
+//
+//	# db.yaml
+//	host: localhost
+//	port: 5432
+func WithIncludes() Option {
+	return func(o *options) {
+		o.includes = true
+	}
+}
+
+// WithFileTags 启用 YAML 配置文件的 !file 标签，将引用文件的内容作为字符串值。
+//
+// 适用于证书、密钥等存放在单独文件中的标量值，相对路径基于配置文件所在目录。
+// 与合并映射的 !include 不同，!file 读取的内容不解析、不进行模板展开，原样作为值（包括末尾换行）。
+//
+// 示例：
+//
+//	# config.yaml
+//	tls_cert: !file ./tls.pem
+func WithFileTags() Option {
+	return func(o *options) {
+		o.fileTags = true
+	}
+}
+
+// resolvesTags 判断是否需要解析 YAML 中的 !include 或 !file 标签。
+func (o *options) resolvesTags() bool {
+	return o.includes || o.fileTags
+}
+
+// providerSource 通过 [WithProvider] 注册的配置源。
+type providerSource struct {
+	provider koanf.Provider
+	parser   koanf.Parser
+}
+
+// WithProvider 添加任意 koanf provider 作为配置源（如 Consul、etcd），与 koanf 生态组合使用。
+//
+// provider 与配置文件同级，在配置文件之后加载，因此覆盖配置文件中的同名配置，
+// 并被环境变量和 CLI flags 覆盖。多次调用时按注册顺序加载，后注册的优先。
+// provider 直接返回 map 时（如 confmap.Provider）parser 传 nil。
+//
+// 实现了 [ContextProvider] 的 provider 读取时受 [WithSourceTimeout] 限制，
+// parser 非 nil 时读取的字节受 [WithMaxConfigSize] 限制。
+//
+// 示例：
+//
+//	cfgm.WithProvider(consul.Provider(consul.Config{...}), json.Parser())
+func WithProvider(provider koanf.Provider, parser koanf.Parser) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, providerSource{provider: provider, parser: parser})
+	}
+}
+
+// ContextProvider 支持 context 的 koanf provider，通常通过网络读取配置（如 HTTP、Consul）。
+//
+// 通过 [WithProvider] 注册且 parser 非 nil 时，[Load] 调用 ReadBytesContext 代替 ReadBytes，
+// 并按 [WithSourceTimeout] 设置超时，避免远程配置源无响应时加载一直阻塞。
+type ContextProvider interface {
+	koanf.Provider
+
+	// ReadBytesContext 读取原始配置字节，ctx 结束时应尽快返回 ctx.Err()。
+	ReadBytesContext(ctx context.Context) ([]byte, error)
+}
+
+// WithSourceTimeout 设置 [ContextProvider] 单次读取的超时，默认 10 秒，d <= 0 表示不限制。
+//
+// 超时后 [Load] 返回指明配置源的错误，错误包装 context.DeadlineExceeded。
+// 未实现 [ContextProvider] 的 provider 和本地配置文件不受影响。
+func WithSourceTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.sourceTimeout = d
+	}
+}
+
+// loadProvider 加载通过 [WithProvider] 注册的第 i 个配置源。
+func loadProvider(o *options, k *koanf.Koanf, i int, src providerSource) error {
+	if src.parser == nil {
+		if err := o.loadInto(k, src.provider, nil); err != nil {
+			return fmt.Errorf("load provider %d (%T): %w", i, src.provider, err)
+		}
+
+		return nil
+	}
+
+	data, err := readProviderBytes(o, src)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("load provider %d (%T): timed out after %s: %w", i, src.provider, o.sourceTimeout, err)
+	}
+	if err != nil {
+		return fmt.Errorf("load provider %d (%T): %w", i, src.provider, err)
+	}
+	if o.maxConfigSize > 0 && int64(len(data)) > o.maxConfigSize {
+		return fmt.Errorf("load provider %d (%T): %w: exceeds %d bytes", i, src.provider, ErrConfigTooLarge, o.maxConfigSize)
+	}
+	if err := o.loadInto(k, rawbytes.Provider(data), src.parser); err != nil {
+		return fmt.Errorf("load provider %d (%T): %w", i, src.provider, err)
+	}
+
+	return nil
+}
+
+// readProviderBytes 读取 provider 的原始配置字节，实现了 [ContextProvider] 时按 [WithSourceTimeout] 设置超时。
+func readProviderBytes(o *options, src providerSource) ([]byte, error) {
+	cp, ok := src.provider.(ContextProvider)
+	if !ok {
+		return src.provider.ReadBytes()
+	}
+
+	ctx := context.Background()
+	if o.sourceTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.sourceTimeout)
+		defer cancel()
+	}
+
+	return cp.ReadBytesContext(ctx)
+}
+
+// WithCLIFlagAlias 将指定名称的 CLI flag 显式映射到 koanf key。
+//
+// 用于名称无法按 kebab-case 规则推导的 flag（如简短或历史遗留的 --addr）。
+// 仅当推导出的 flag（--server-addr 或 --server.addr）未设置时才使用别名 flag 的值。
+// flag 自身的短别名（如 -a）由 urfave/cli 处理，无需单独映射。
+//
+// 示例：
+//
+//	cfgm.WithCLIFlagAlias("addr", "server.addr") // --addr → server.addr
+func WithCLIFlagAlias(flagName, koanfKey string) Option {
+	return func(o *options) {
+		if o.cliFlagAliases == nil {
+			o.cliFlagAliases = make(map[string]string)
+		}
+		o.cliFlagAliases[flagName] = koanfKey
+	}
+}
+
+// WithStrictCLIFlags 将 CLI flag 冲突视为错误。
+//
+// 同一配置路径的 kebab-case 和 dot notation 两种 flag（如 --server-addr 和 --server.addr）
+// 同时被设置时，默认使用 kebab-case 的值并记录 Warn 日志；启用此选项后 [Load] 返回错误。
+func WithStrictCLIFlags() Option {
+	return func(o *options) {
+		o.strictCLIFlags = true
+	}
+}
+
+// WithCLIMapMerge 将 map[string]string 类型 CLI flag 的条目合并到配置文件和环境变量提供的 map。
+//
+// 默认情况下 map flag 整体替换已有的 map；启用后仅覆盖同名条目，其他条目保留：
+//
+//	# config.yaml 中 labels: {env: dev, owner: ops}
+//	myapp --labels env=prod --labels team=infra
+//	# 结果：{env: prod, owner: ops, team: infra}
+func WithCLIMapMerge() Option {
+	return func(o *options) {
+		o.mergeCLIMaps = true
+	}
+}
+
+// WithStrictBindings 校验环境变量绑定的目标配置路径，路径不存在时 [Load] 返回错误。
+//
+// 默认情况下，绑定到不存在的路径（如 WithEnvBinding("X", "typo.path")）会被静默忽略。
+// 启用后，所有来源的绑定（代码、配置文件、正则）的目标路径必须是配置结构体的叶子 key，
+// 或 map 字段下的子 key（如 labels.env）。
+func WithStrictBindings() Option {
+	return func(o *options) {
+		o.strictBindings = true
+	}
+}
+
+// WithMaxConfigSize 限制单个配置源的最大字节数，防止误加载超大文件。
+//
+// 在解析前检查，超过限制时返回包装了 [ErrConfigTooLarge] 的错误。
+// 限制同时作用于 !include 引用的文件、.gz 文件解压后的内容，以及 [WithProvider] 注册的
+// 带 parser 的 provider 读取的字节；parser 为 nil 的 provider 直接返回 map，不受限制。默认不限制（<= 0）。
+func WithMaxConfigSize(maxBytes int64) Option {
+	return func(o *options) {
+		o.maxConfigSize = maxBytes
+	}
+}
+
+// WithMaxConfigAge 要求找到的配置文件在 d 之内修改过，防止使用过期的配置（如轮换的凭据）。
+//
+// 按搜索顺序选中配置文件后检查其修改时间，早于 d 时返回包装了 [ErrConfigTooOld] 的错误，
+// 不会继续尝试后续路径。未找到配置文件时不检查；[WithConfigFromContext] 提供的配置内容
+// 和 [WithConfigArchive] 的归档条目同样不检查。默认不限制（<= 0）。
+//
+// 示例：
+//
+//	// 凭据文件每小时轮换，超过 2 小时未更新视为异常
+//	cfgm.Load(defaultConfig, cfgm.WithConfigPaths("/run/secrets/app.yaml"), cfgm.WithMaxConfigAge(2*time.Hour))
+func WithMaxConfigAge(d time.Duration) Option {
+	return func(o *options) {
+		o.maxConfigAge = d
+	}
+}
+
+// WithConfigEncoding 设置配置文件的字符编码，读取后先转码为 UTF-8 再进行模板展开和解析。
+//
+// 用于读取遗留系统生成的非 UTF-8 文件（如 GBK 编码的 YAML）。编码名称按 WHATWG 编码标准解析，
+// 支持 gbk、gb18030、big5、shift_jis、euc-kr 等及其别名，不区分大小写。
+// 默认（空字符串）不转码。编码名称无法识别时 [Load] 返回错误。
+// 设置后同样作用于 !include 引用的文件，[WithMaxConfigSize] 限制转码前的字节数。
+//
+// 示例：
+//
+//	cfgm.Load(defaultConfig, cfgm.WithConfigPaths("legacy.yaml"), cfgm.WithConfigEncoding("gbk"))
+func WithConfigEncoding(enc string) Option {
+	return func(o *options) {
+		o.configEncoding = enc
+	}
+}
+
+// WithDecodeHook 注册自定义的 mapstructure 解码钩子，用于将配置值转换为自定义类型。
+//
+// 钩子在内置钩子（time.Duration、宽松 bool、内联 JSON、encoding.TextUnmarshaler）之前
+// 按注册顺序执行，因此可以覆盖内置的转换规则；钩子不处理的类型应原样返回 data。
+// 同样作用于 [LoadList] 的元素解码。
+//
+// 示例：
+//
+//	// "10MB" → ByteSize
+//	cfgm.WithDecodeHook(func(f, t reflect.Type, data any) (any, error) {
+//	    if f.Kind() != reflect.String || t != reflect.TypeFor[ByteSize]() {
+//	        return data, nil
+//	    }
+//	    return ParseByteSize(data.(string))
+//	})
+func WithDecodeHook(hook mapstructure.DecodeHookFunc) Option {
+	return func(o *options) {
+		o.decodeHooks = append(o.decodeHooks, hook)
+	}
+}
+
+// WithEnvAuditFunc 设置环境变量绑定的审计回调。
+//
+// Load 期间每个实际提供了值（环境变量已设置且非空）的绑定调用一次 fn，
+// 参数为环境变量名和配置路径，可用于合规审计中区分来自环境变量的配置值（如密钥）。
+// fn 不接收环境变量的值，避免密钥写入审计日志。
+//
+// 示例：
+//
+//	cfgm.WithEnvAuditFunc(func(envKey, configPath string) {
+//	    auditLog.Info("config value from env", "env", envKey, "path", configPath)
+//	})
+func WithEnvAuditFunc(fn func(envKey, configPath string)) Option {
+	return func(o *options) {
+		o.envAuditFunc = fn
+	}
+}
+
+// WithDebugConfigEnv 在指定环境变量为真值时，通过 logger 输出合并后的生效配置。
+//
+// 真值与 bool 字段的解析规则一致（true、yes、on、1 等）。配置经 [Redacted] 脱敏后
+// 以 YAML 格式记录为 Info 日志，便于排查线上配置而无需修改代码：
+//
+//	cfgm.Load(DefaultConfig(), cfgm.WithDebugConfigEnv("APP_DEBUG_CONFIG"))
+//
+//	// APP_DEBUG_CONFIG=1 ./myapp
+func WithDebugConfigEnv(envKey string) Option {
+	return func(o *options) {
+		o.debugConfigEnv = envKey
+	}
+}
+
+// WithLogger 设置加载过程使用的日志记录器，默认使用 slog.Default()。
+//
+// 加载过程以 Debug 级别记录配置文件、环境变量绑定的决议结果等信息，
+// 例如同一配置路径被多个来源绑定时，记录最终生效的来源（code、bindkey 或 prefix）。
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// templateOptions 返回配置文件模板展开使用的选项。
+func (o *options) templateOptions() []tmpl.Option {
+	var opts []tmpl.Option
+	switch {
+	case o.noEnv:
+		opts = append(opts, tmpl.WithoutEnv())
+	case o.env != nil:
+		opts = append(opts, tmpl.WithEnv(o.env))
+	}
+	if len(o.templateData) > 0 {
+		opts = append(opts, tmpl.WithData(o.templateData))
+	}
+	if o.templateDelims != [2]string{} {
+		opts = append(opts, tmpl.WithDelims(o.templateDelims[0], o.templateDelims[1]))
+	}
+	if o.templateMissingZero {
+		opts = append(opts, tmpl.WithMissingKeyZero())
+	}
+	opts = append(opts, tmpl.WithFileReader(o.readTemplateFile))
+
+	return opts
+}
+
+// readTemplateFile 读取模板中 readYaml/readJson 引用的文件。
+//
+// 相对路径基于 baseDir 解析（见 [WithBaseDir]），文件与配置文件相同经 readConfigFile 读取，
+// 遵循 [WithMaxConfigSize]、.gz 解压和 [WithConfigEncoding]。
+func (o *options) readTemplateFile(path string) ([]byte, error) {
+	if o.baseDir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(o.baseDir, path)
+	}
+
+	return readConfigFile(o, path)
+}
+
 // DefaultPaths 返回默认配置文件搜索路径。
 //
 // appName 可选，若提供则包含应用专属配置路径。
@@ -224,137 +1131,583 @@ func Load[T any](defaultConfig T, opts ...Option) (*T, error) {
 	return load(defaultConfig, 1, opts...)
 }
 
-// load 是内部加载函数，callerSkip 指定 FindProjectRoot 的调用栈跳过层数。
-// 不同的入口函数根据自身调用深度传递正确的 skip 值：
-//   - Load: skip=1 (Load → load → FindProjectRoot)
-//   - LoadCmd: skip=1 (LoadCmd → load → FindProjectRoot)
-//   - MustLoad: skip=2 (MustLoad → load → FindProjectRoot)
-//   - MustLoadCmd: skip=2 (MustLoadCmd → load → FindProjectRoot)
+// load 是内部加载函数，callerSkip 指定 FindProjectRoot 的调用栈跳过层数。
+// 不同的入口函数根据自身调用深度传递正确的 skip 值：
+//   - Load: skip=1 (Load → load → FindProjectRoot)
+//   - LoadCmd: skip=1 (LoadCmd → load → FindProjectRoot)
+//   - MustLoad: skip=2 (MustLoad → load → FindProjectRoot)
+//   - MustLoadCmd: skip=2 (MustLoadCmd → load → FindProjectRoot)
+//
+
+func load[T any](defaultConfig T, callerSkip int, opts ...Option) (*T, error) {
+	cfg, _, err := loadWithWarnings(defaultConfig, callerSkip+1, opts...)
+
+	return cfg, err
+}
+
+// loadWithWarnings 执行加载并返回加载过程中产生的警告，见 [LoadWithResult]。
+// callerSkip 的含义与 load 相同，由调用方计入自身的调用层数。
+func loadWithWarnings[T any](defaultConfig T, callerSkip int, opts ...Option) (_ *T, _ []Warning, err error) {
+	options := newOptions(callerSkip+1, opts)
+
+	var source string
+	if options.metrics != nil {
+		start := time.Now()
+		defer func() { options.metrics.ObserveLoad(time.Since(start), source, err) }()
+	}
+
+	if err := errors.Join(options.envBindingRegexErrs...); err != nil {
+		return nil, nil, err
+	}
+	if options.enforceEnvRequired {
+		if options.noEnv {
+			return nil, nil, errors.New("WithEnforceEnvRequired cannot be used with WithNoEnv")
+		}
+		options.envRequiredPaths = envRequiredKeys(reflect.TypeOf(defaultConfig))
+	}
+
+	k := koanf.New(".")
+
+	// 1️⃣ 加载默认配置 (最低优先级)
+	if err := k.Load(structs.Provider(defaultConfig, "koanf"), nil); err != nil {
+		return nil, nil, fmt.Errorf("failed to load default config: %w", err)
+	}
+
+	// 2️⃣ 加载配置文件 (按顺序搜索，找到第一个即停止)
+	path, content, err := findConfigFile(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	source = path
+	if path != "" {
+		if err := loadConfigContent(options, k, path, content); err != nil {
+			return nil, nil, err
+		}
+
+		options.logger.Debug("Loaded config from file", "path", path, "templateExpansion", !options.noTemplateExpansion)
+		if options.envFillOnly {
+			options.recordFileKeys(path, content)
+		}
+		options.runFileLoaded(path)
+	} else if len(options.configPaths) > 0 {
+		options.logger.Debug("No config file found, using defaults")
+	}
+
+	for _, path := range options.lenientFiles {
+		loadLenientFile(options, k, path)
+	}
+
+	for i, src := range options.providers {
+		if err := loadProvider(options, k, i, src); err != nil {
+			return nil, nil, err
+		}
+		options.logger.Debug("Loaded config from provider", "index", i, "provider", fmt.Sprintf("%T", src.provider))
+	}
+
+	// 3️⃣ 汇总环境变量绑定 (前缀自动生成 < 配置文件绑定 < 代码绑定)
+	// 前缀绑定基于配置结构体的 koanf key 生成，解决了 key 包含连字符（如 rev-auth-user）时无法前缀匹配的问题
+	bindings := resolveEnvBindings(options, k, collectKoanfKeys(defaultConfig))
+
+	// 4️⃣ 加载 Docker secrets 和环境变量绑定 (高于配置文件，低于 CLI flags)
+	fieldTypes := collectKoanfFieldTypes(defaultConfig)
+	if options.strictBindings {
+		if err := validateEnvBindingPaths(bindings, fieldTypes); err != nil {
+			return nil, nil, err
+		}
+	}
+	if options.dockerSecrets {
+		if err := applyDockerSecrets(options, k, fieldTypes); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !options.noEnv {
+		if err := applyEnvBindings(options, k, bindings, fieldTypes); err != nil {
+			return nil, nil, err
+		}
+	}
+	if options.enforceEnvRequired {
+		if err := checkEnvRequired(options, defaultConfig, bindings); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 5️⃣ 加载 CLI flags (最高优先级，仅当用户明确指定时)
+	if options.cmd != nil {
+		if err := applyCLIFlagsGeneric(options, k, defaultConfig); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	migrateDeprecatedKeys(options, k, defaultConfig)
+	options.deleteIgnoredKeys(k)
+
+	if err := options.applyValueTransformers(k); err != nil {
+		return nil, nil, err
+	}
+	if err := options.runBeforeUnmarshal(k); err != nil {
+		return nil, nil, err
+	}
+	warnUnknownKeys(options, k, defaultConfig)
+	if options.schemaValidation {
+		if err := validateSchema(buildJSONSchema(defaultConfig), k.Raw(), ""); err != nil {
+			return nil, nil, fmt.Errorf("schema validation failed: %w", err)
+		}
+	}
+
+	// 解析到结构体
+	var cfg T
+	if err := unmarshalConfig(k, "", &cfg, options.decodeHooks...); err != nil {
+		// 将出错的 key 关联到原始配置文件中的行号，便于定位；
+		// 行号取自模板展开和 !include 处理前的内容，找不到时不附带行号
+		if path != "" && options.configRaw != nil {
+			for _, key := range decodeErrorKeys(err) {
+				if options.section != "" {
+					key = options.section + "." + key
+				}
+				if line := locateKeyLine(options.configRaw, key); line > 0 {
+					return nil, nil, fmt.Errorf("failed to unmarshal config: %s:%d: %w", path, line, err)
+				}
+			}
+		}
+
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	// 填充 defaultFunc 标签标记的运行时默认值
+	if cfgVal := reflect.ValueOf(&cfg).Elem(); cfgVal.Kind() == reflect.Struct {
+		if err := applyDefaultFuncs(cfgVal, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	logEffectiveConfig(options, cfg)
+
+	if err := options.runLoaded(&cfg); err != nil {
+		return nil, nil, err
+	}
+	if err := options.runAfterLoad(&cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return &cfg, options.warnings, nil
+}
+
+// logEffectiveConfig 在 [WithDebugConfigEnv] 指定的环境变量为真值时记录脱敏后的生效配置。
+func logEffectiveConfig[T any](o *options, cfg T) {
+	if o.debugConfigEnv == "" {
+		return
+	}
+	if enabled, err := parseBool(o.getenv(o.debugConfigEnv)); err != nil || !enabled {
+		return
+	}
+
+	o.logger.Info("Effective config", "env", o.debugConfigEnv, "config", string(MarshalYAML(Redacted(cfg))))
+}
+
+// loadConfigContent 将处理后的配置文件内容加载到 k。
+//
+// 设置了 section 时，仅加载该节的内容作为根节点；节不存在时不加载任何内容。
+func loadConfigContent(o *options, k *koanf.Koanf, path string, content []byte) error {
+	if o.section == "" {
+		// 使用 rawbytes 加载处理后的内容
+		if err := o.loadInto(k, rawbytes.Provider(content), o.parserFor(path)); err != nil {
+			return fmt.Errorf("parse config file %s: %w", path, err)
+		}
+
+		return nil
+	}
+
+	fileK := koanf.New(".")
+	if err := fileK.Load(rawbytes.Provider(content), o.parserFor(path)); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if !fileK.Exists(o.section) {
+		o.logger.Debug("Config section not found in file", "path", path, "section", o.section)
+
+		return nil
+	}
+	if err := o.loadInto(k, confmap.Provider(fileK.Cut(o.section).Raw(), ""), nil); err != nil {
+		return fmt.Errorf("load section %q of config file %s: %w", o.section, path, err)
+	}
+
+	return nil
+}
+
+// loadLenientFile 尽力加载 [WithLenientFile] 注册的文件，失败时记录警告而不中断加载。
+func loadLenientFile(o *options, k *koanf.Koanf, path string) {
+	if o.baseDir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(o.baseDir, path)
+	}
+
+	content, err := readConfigFile(o, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		o.logger.Debug("Lenient config file not found", "path", path)
+
+		return
+	}
+	if err == nil {
+		content, err = expandConfigContent(o, path, content)
+	}
+	if err == nil && o.resolvesTags() && !o.isJSONFile(path) {
+		content, err = resolveIncludes(o, path, content)
+	}
+	if err == nil {
+		err = loadConfigContent(o, k, path, content)
+	}
+	if err != nil {
+		o.warn(WarningLenientFile, "", fmt.Sprintf("skipped lenient config file %s: %v", path, err))
+
+		return
+	}
+
+	o.logger.Debug("Loaded lenient config file", "path", path)
+}
+
+// newOptions 解析选项并填充默认值。
 //
-
-func load[T any](defaultConfig T, callerSkip int, opts ...Option) (*T, error) {
-	// 解析选项
-	options := &options{}
+// callerSkip 传递给 [FindProjectRoot]，用于在未设置 baseDir 时定位项目根目录。
+func newOptions(callerSkip int, opts []Option) *options {
+	o := &options{logger: slog.Default(), sourceTimeout: defaultSourceTimeout}
 	for _, opt := range opts {
-		opt(options)
+		opt(o)
+	}
+
+	if o.envSnapshot {
+		o.env = make(map[string]string)
+		for _, kv := range os.Environ() {
+			key, val, _ := strings.Cut(kv, "=")
+			o.env[key] = val
+		}
 	}
 
 	// 默认使用项目根目录作为相对路径基准
-	if !options.baseDirSet {
+	if !o.baseDirSet {
 		if root, err := FindProjectRoot(callerSkip); err == nil {
-			options.baseDir = root
+			o.baseDir = root
 		}
 	}
 
 	// 默认使用 DefaultPaths 作为配置文件搜索路径
 	// 如果设置了 appName，使用 DefaultPaths(appName) 生成应用专属路径
-	if len(options.configPaths) == 0 {
-		if options.appName != "" {
-			options.configPaths = DefaultPaths(options.appName)
+	if len(o.configPaths) == 0 {
+		if o.appName != "" {
+			o.configPaths = DefaultPaths(o.appName)
 		} else {
-			options.configPaths = DefaultPaths()
+			o.configPaths = DefaultPaths()
 		}
 	}
 
-	k := koanf.New(".")
+	// CLI flag 优先于环境变量，二者均优先于 context
+	if path, ok := o.contextConfig.(string); ok && path != "" {
+		o.overrideConfigPath(path, "context")
+	}
+	if o.configPathsOverrideEnv != "" && !o.noEnv {
+		if path := o.getenv(o.configPathsOverrideEnv); path != "" {
+			o.overrideConfigPath(path, "env "+o.configPathsOverrideEnv)
+		}
+	}
+	if o.configFlag != "" && o.cmd != nil {
+		if path, ok := lookupConfigFlag(o.cmd, o.configFlag); ok {
+			o.overrideConfigPath(path, "flag --"+o.configFlag)
+		}
+	}
 
-	// 1️⃣ 加载默认配置 (最低优先级)
-	if err := k.Load(structs.Provider(defaultConfig, "koanf"), nil); err != nil {
-		return nil, fmt.Errorf("failed to load default config: %w", err)
+	return o
+}
+
+// overrideConfigPath 使用 path 作为唯一的配置文件路径，相对路径基于当前工作目录。
+func (o *options) overrideConfigPath(path, from string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
 	}
+	o.configPathOverride = path
+	o.configPathOverrideFrom = from
+	o.configPaths = []string{path}
+}
 
-	// 2️⃣ 加载配置文件 (按顺序搜索，找到第一个即停止)
-	configLoaded := false
-	paths := options.configPaths
-	if options.baseDir != "" {
-		paths = make([]string, len(options.configPaths))
-		for i, p := range options.configPaths {
-			if !filepath.IsAbs(p) {
-				paths[i] = filepath.Join(options.baseDir, p)
-			} else {
-				paths[i] = p
+// lookupConfigFlag 沿命令链（当前命令到根命令）查找被显式设置的 flag，返回其非空值。
+//
+// 使 flag 定义在父命令上、在子命令的 Action 中加载配置时同样生效。
+func lookupConfigFlag(cmd *cli.Command, name string) (string, bool) {
+	for _, c := range cmd.Lineage() {
+		if c.IsSet(name) {
+			if path := c.String(name); path != "" {
+				return path, true
 			}
 		}
 	}
-	for _, path := range paths {
+
+	return "", false
+}
+
+// resolvedConfigPaths 返回基于 baseDir 转换后的配置文件搜索路径。
+func (o *options) resolvedConfigPaths() []string {
+	if o.baseDir == "" {
+		return o.configPaths
+	}
+
+	paths := make([]string, len(o.configPaths))
+	for i, p := range o.configPaths {
+		if !filepath.IsAbs(p) {
+			paths[i] = filepath.Join(o.baseDir, p)
+		} else {
+			paths[i] = p
+		}
+	}
+
+	return paths
+}
+
+// findConfigFile 按顺序搜索配置文件，返回第一个可读取文件的路径及处理后的内容。
+//
+// 内容已完成模板展开，启用 [WithIncludes] 时 YAML 文件中的 !include 也已解析。
+// 未找到任何配置文件时返回空路径和 nil error。
+// 设置了 [WithConfigArchive] 时改为读取归档中的条目。
+func findConfigFile(o *options) (string, []byte, error) {
+	for path, format := range o.pathFormats {
+		if format != "yaml" && format != "yml" && format != "json" {
+			return "", nil, fmt.Errorf("unsupported config format %q for %s", format, path)
+		}
+	}
+	if o.configArchive != nil {
+		return findArchiveConfig(o)
+	}
+	switch v := o.contextConfig.(type) {
+	case nil, string:
+	case []byte:
+		if len(v) > 0 && o.configPathOverride == "" {
+			return loadContextConfig(o, v)
+		}
+	default:
+		return "", nil, fmt.Errorf("config from context: unsupported value type %T (want string or []byte)", v)
+	}
+
+	for _, path := range o.resolvedConfigPaths() {
 		// 尝试读取配置文件
-		content, err := os.ReadFile(path) //nolint:gosec // path is from trusted config
-		if err != nil {
+		content, err := readConfigFile(o, path)
+		if pathErr := (*fs.PathError)(nil); errors.As(err, &pathErr) {
 			continue // 文件不存在或无法读取，尝试下一个路径
 		}
+		if err != nil {
+			return "", nil, err
+		}
+		if err := o.checkConfigAge(path); err != nil {
+			return "", nil, err
+		}
+		o.configRaw = content
 
-		// 默认启用模板展开，在解析前处理模板
-		if !options.noTemplateExpansion {
-			expanded, err := tmpl.ExpandTemplate(string(content))
+		content, err = expandConfigContent(o, path, content)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if o.resolvesTags() && !o.isJSONFile(path) {
+			content, err = resolveIncludes(o, path, content)
 			if err != nil {
-				return nil, fmt.Errorf("expand template in %s: %w", path, err)
+				return "", nil, err
 			}
-			content = []byte(expanded)
 		}
 
-		// 使用 rawbytes 加载处理后的内容
-		if err := k.Load(rawbytes.Provider(content), parserForPath(path)); err != nil {
-			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		return path, content, nil
+	}
+
+	if o.configPathOverride != "" {
+		return "", nil, fmt.Errorf("config file %s (from %s) not found", o.configPathOverride, o.configPathOverrideFrom)
+	}
+
+	return "", nil, nil
+}
+
+// loadContextConfig 处理 [WithConfigFromContext] 提供的配置内容，与配置文件一样进行模板展开和标签解析。
+func loadContextConfig(o *options, content []byte) (string, []byte, error) {
+	o.configRaw = content
+	content, err := expandConfigContent(o, contextConfigSource, content)
+	if err != nil {
+		return "", nil, err
+	}
+	if o.resolvesTags() {
+		content, err = resolveIncludes(o, contextConfigSource, content)
+		if err != nil {
+			return "", nil, err
 		}
+	}
 
-		slog.Debug("Loaded config from file", "path", path, "templateExpansion", !options.noTemplateExpansion)
-		configLoaded = true
+	return contextConfigSource, content, nil
+}
 
-		break
+// checkConfigAge 检查配置文件的修改时间是否在 [WithMaxConfigAge] 允许的范围内。
+func (o *options) checkConfigAge(path string) error {
+	if o.maxConfigAge <= 0 {
+		return nil
 	}
 
-	if len(options.configPaths) > 0 && !configLoaded {
-		slog.Debug("No config file found, using defaults")
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat config file %s: %w", path, err)
 	}
+	if age := time.Since(info.ModTime()); age > o.maxConfigAge {
+		return fmt.Errorf("%w: %s was modified %s ago, max age is %s",
+			ErrConfigTooOld, path, age.Truncate(time.Second), o.maxConfigAge)
+	}
+
+	return nil
+}
 
-	// 2.5️⃣ 从配置文件读取环境变量绑定 (在加载配置文件后)
-	if options.envBindKey != "" {
-		options.envBindings = mergeEnvBindingsFromConfig(k, options.envBindKey, options.envBindings)
+// readConfigFile 读取配置文件，.gz 后缀的文件自动解压，设置了 [WithConfigEncoding] 时转码为 UTF-8。
+//
+// 设置了 [WithMaxConfigSize] 时，文件内容和解压后的内容均受大小限制。
+// 文件无法打开或读取时返回 *fs.PathError；路径是目录时返回 [ErrConfigPathIsDir]，
+// 避免误配置的路径被当作文件不存在而静默跳过。
+func readConfigFile(o *options, path string) ([]byte, error) {
+	content, err := readRawConfigFile(o, path)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3️⃣ 自动生成环境变量绑定 (基于配置结构体的 koanf key)
-	// 这解决了 koanf key 包含连字符（如 rev-auth-user）时无法通过前缀匹配的问题
-	if options.envPrefix != "" {
-		// 构建已绑定配置路径的集合（用户显式绑定优先）
-		boundPaths := make(map[string]bool)
-		for _, configPath := range options.envBindings {
-			boundPaths[configPath] = true
-		}
+	return o.decodeContent(path, content)
+}
 
-		autoBindings := generateEnvBindings(options.envPrefix, collectKoanfKeys(defaultConfig))
-		// 合并自动绑定（仅当配置路径未被绑定时）
-		for envKey, configPath := range autoBindings {
-			if !boundPaths[configPath] {
-				if options.envBindings == nil {
-					options.envBindings = make(map[string]string)
-				}
-				options.envBindings[envKey] = configPath
-				boundPaths[configPath] = true
-			}
-		}
-		slog.Debug("Generated auto env bindings", "prefix", options.envPrefix, "count", len(autoBindings))
+// decodeContent 将 [WithConfigEncoding] 指定编码的配置内容转码为 UTF-8，未设置编码时原样返回。
+func (o *options) decodeContent(path string, content []byte) ([]byte, error) {
+	if o.configEncoding == "" {
+		return content, nil
+	}
+
+	enc, err := htmlindex.Get(o.configEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("config encoding %q: %w", o.configEncoding, err)
+	}
+	data, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("decode config file %s from %s: %w", path, o.configEncoding, err)
+	}
+
+	return data, nil
+}
+
+// readRawConfigFile 读取配置文件的原始字节，.gz 后缀的文件自动解压。
+func readRawConfigFile(o *options, path string) ([]byte, error) {
+	f, err := os.Open(path) //nolint:gosec // path is from trusted config
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrConfigPathIsDir, path)
+	}
+
+	content, err := o.readSource(path, f)
+	if err != nil || !isGzipPath(path) {
+		return content, err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decompress config file %s: %w", path, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	data, err := o.readSource(path, r)
+	if errors.Is(err, ErrConfigTooLarge) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompress config file %s: %w", path, err)
+	}
+
+	return data, nil
+}
+
+// readSource 读取单个配置源的全部内容，超过 [WithMaxConfigSize] 限制时返回 [ErrConfigTooLarge]。
+func (o *options) readSource(source string, r io.Reader) ([]byte, error) {
+	if o.maxConfigSize <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, o.maxConfigSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > o.maxConfigSize {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrConfigTooLarge, source, o.maxConfigSize)
+	}
+
+	return data, nil
+}
+
+// expandConfigContent 对配置文件内容进行模板展开（默认启用）。
+//
+// 模板中可通过 {{.ConfigDir}} 访问配置文件所在目录的绝对路径；
+// !include 引用的文件中为被引用文件所在的目录，非文件来源（[WithConfigFromContext] 提供的内容）为空。
+// [WithTemplateData] 可覆盖该变量。
+func expandConfigContent(o *options, path string, content []byte) ([]byte, error) {
+	if o.noTemplateExpansion {
+		return content, nil
 	}
 
-	// 4️⃣ 加载环境变量绑定 (高于配置文件，低于 CLI flags)
-	for envKey, configPath := range options.envBindings {
-		if val := os.Getenv(envKey); val != "" {
-			_ = k.Set(configPath, val)
-			slog.Debug("Loaded env binding", "env", envKey, "path", configPath)
+	// ConfigDir 为配置文件所在目录的绝对路径，便于引用与配置文件同目录的文件
+	var configDir string
+	if path != contextConfigSource {
+		dir, err := filepath.Abs(filepath.Dir(path))
+		if err != nil {
+			dir = filepath.Dir(path)
 		}
+		configDir = dir
+	}
+	fileData, err := o.loadTemplateDataFiles()
+	if err != nil {
+		return nil, err
 	}
+	opts := append([]tmpl.Option{
+		tmpl.WithData(map[string]string{"ConfigDir": configDir}),
+		tmpl.WithData(fileData),
+	}, o.templateOptions()...)
 
-	// 5️⃣ 加载 CLI flags (最高优先级，仅当用户明确指定时)
-	if options.cmd != nil {
-		applyCLIFlagsGeneric(options.cmd, k, defaultConfig)
+	expanded, err := tmpl.ExpandTemplate(string(content), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("expand template in %s: %w", path, err)
 	}
 
-	// 解析到结构体
-	var cfg T
-	if err := k.Unmarshal("", &cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	return []byte(expanded), nil
+}
+
+// loadTemplateDataFiles 读取 [WithTemplateDataFile] 注册的文件并合并为模板数据。
+//
+// 文件与配置文件相同经 readConfigFile 读取（遵循 [WithMaxConfigSize]、.gz 解压和 [WithConfigEncoding]），
+// 结果缓存在 options 中，主配置文件、!include 和 [WithLenientFile] 的模板展开共用同一份数据。
+func (o *options) loadTemplateDataFiles() (map[string]string, error) {
+	if len(o.templateDataFiles) == 0 || o.templateFileData != nil {
+		return o.templateFileData, nil
+	}
+
+	data := make(map[string]string)
+	for _, path := range o.templateDataFiles {
+		if o.baseDir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(o.baseDir, path)
+		}
+		content, err := readConfigFile(o, path)
+		if err != nil {
+			return nil, fmt.Errorf("read template data file: %w", err)
+		}
+		values, err := o.parserFor(path).Unmarshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("parse template data file %s: %w", path, err)
+		}
+		for key, value := range values {
+			switch value.(type) {
+			case map[string]any, []any:
+				return nil, fmt.Errorf("template data file %s: key %q must be a scalar value", path, key)
+			case nil:
+				data[key] = ""
+			default:
+				data[key] = fmt.Sprint(value)
+			}
+		}
 	}
+	o.templateFileData = data
 
-	return &cfg, nil
+	return data, nil
 }
 
 // LoadCmd 是 [Load] 的便捷版本，将 CLI 命令和应用名称作为参数。
@@ -431,19 +1784,72 @@ func MustLoadCmd[T any](cmd *cli.Command, defaultConfig T, appName string, opts
 	return cfg
 }
 
+// isNestedStruct 判断类型是否为需要递归处理的嵌套结构体（排除 time.Duration、time.Time 等特殊类型）。
+func isNestedStruct(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct &&
+		typ != reflect.TypeFor[time.Duration]() &&
+		typ != reflect.TypeFor[time.Time]()
+}
+
 // collectKoanfKeys 通过反射收集配置结构体的所有 koanf key。
 //
 // 递归遍历结构体字段，返回所有叶子节点的完整 koanf key。
 // 例如对于 client.rev-auth-user 这样的嵌套结构，会返回完整路径。
+// 结果按类型缓存，调用方不应修改返回的切片。
 func collectKoanfKeys[T any](defaultConfig T) []string {
-	var keys []string
-	collectKoanfKeysRecursive(reflect.TypeOf(defaultConfig), "", &keys)
+	return koanfTypeInfoFor(reflect.TypeOf(defaultConfig)).keys
+}
+
+// collectKoanfFieldTypes 通过反射收集配置结构体所有叶子 koanf key 对应的字段类型。
+//
+// 结果按类型缓存，调用方不应修改返回的 map。
+func collectKoanfFieldTypes[T any](defaultConfig T) map[string]reflect.Type {
+	return koanfTypeInfoFor(reflect.TypeOf(defaultConfig)).fieldTypes
+}
+
+// koanfLeaf 配置结构体的一个叶子字段。
+type koanfLeaf struct {
+	key   string
+	field reflect.StructField
+}
+
+// koanfTypeInfo 配置结构体的反射结果，按类型缓存，创建后只读。
+type koanfTypeInfo struct {
+	leaves     []koanfLeaf
+	keys       []string
+	fieldTypes map[string]reflect.Type
+}
+
+// koanfTypeInfoCache 缓存 reflect.Type → *koanfTypeInfo，避免重复加载时反复遍历结构体。
+var koanfTypeInfoCache sync.Map
+
+// koanfTypeInfoFor 返回类型的反射结果，首次调用时遍历结构体并缓存。
+func koanfTypeInfoFor(typ reflect.Type) *koanfTypeInfo {
+	if cached, ok := koanfTypeInfoCache.Load(typ); ok {
+		return cached.(*koanfTypeInfo)
+	}
+	info, _ := koanfTypeInfoCache.LoadOrStore(typ, buildKoanfTypeInfo(typ))
+
+	return info.(*koanfTypeInfo)
+}
+
+// buildKoanfTypeInfo 遍历结构体生成反射结果，不使用缓存。
+func buildKoanfTypeInfo(typ reflect.Type) *koanfTypeInfo {
+	info := &koanfTypeInfo{fieldTypes: make(map[string]reflect.Type)}
+	if typ == nil {
+		return info
+	}
+	walkKoanfLeaves(typ, "", func(fullKey string, field reflect.StructField) {
+		info.leaves = append(info.leaves, koanfLeaf{key: fullKey, field: field})
+		info.keys = append(info.keys, fullKey)
+		info.fieldTypes[fullKey] = field.Type
+	})
 
-	return keys
+	return info
 }
 
-// collectKoanfKeysRecursive 递归收集 koanf key。
-func collectKoanfKeysRecursive(typ reflect.Type, prefix string, keys *[]string) {
+// walkKoanfLeaves 递归遍历结构体的叶子字段，对每个字段调用 fn。
+func walkKoanfLeaves(typ reflect.Type, prefix string, fn func(fullKey string, field reflect.StructField)) {
 	// 处理指针类型
 	if typ.Kind() == reflect.Pointer {
 		typ = typ.Elem()
@@ -467,51 +1873,14 @@ func collectKoanfKeysRecursive(typ reflect.Type, prefix string, keys *[]string)
 		}
 
 		// 如果是嵌套结构体（非特殊类型），递归处理
-		if field.Type.Kind() == reflect.Struct &&
-			field.Type != reflect.TypeFor[time.Duration]() &&
-			field.Type != reflect.TypeFor[time.Time]() {
-			collectKoanfKeysRecursive(field.Type, fullKey, keys)
+		if isNestedStruct(field.Type) {
+			walkKoanfLeaves(field.Type, fullKey, fn)
 
 			continue
 		}
 
-		*keys = append(*keys, fullKey)
-	}
-}
-
-// mergeEnvBindingsFromConfig 从配置文件读取环境变量绑定并合并到现有绑定中。
-// 代码中的绑定优先，配置文件中的绑定仅用于填充未绑定的配置路径。
-func mergeEnvBindingsFromConfig(k *koanf.Koanf, bindKey string, existing map[string]string) map[string]string {
-	bindings := k.StringMap(bindKey)
-	if len(bindings) == 0 {
-		return existing
+		fn(fullKey, field)
 	}
-
-	// 构建已绑定配置路径的集合（代码中的绑定优先）
-	boundPaths := make(map[string]bool)
-	for _, configPath := range existing {
-		boundPaths[configPath] = true
-	}
-
-	// 确保结果 map 已初始化
-	result := existing
-	if result == nil {
-		result = make(map[string]string)
-	}
-
-	// 合并配置文件绑定（仅当配置路径未被绑定时）
-	for envKey, configPath := range bindings {
-		if !boundPaths[configPath] {
-			result[envKey] = configPath
-			boundPaths[configPath] = true
-		}
-	}
-
-	// 删除绑定节点，不污染用户配置
-	k.Delete(bindKey)
-	slog.Debug("Loaded env bindings from config", "key", bindKey, "count", len(bindings))
-
-	return result
 }
 
 // generateEnvBindings 根据 koanf key 生成环境变量绑定。
@@ -527,27 +1896,74 @@ func mergeEnvBindingsFromConfig(k *koanf.Koanf, bindKey string, existing map[str
 func generateEnvBindings(prefix string, koanfKeys []string) map[string]string {
 	bindings := make(map[string]string, len(koanfKeys))
 	for _, key := range koanfKeys {
-		// 将 "." 和 "-" 都转为 "_"，然后大写
-		envKey := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
-		bindings[prefix+envKey] = key
+		bindings[envKeyFor(prefix, key)] = key
 	}
 
 	return bindings
 }
 
+// envKeyFor 返回 koanf key 对应的环境变量名："." 和 "-" 都转为 "_"，然后大写并添加前缀。
+//
+// 不做其他转换：数字开头的 key（如 2fa → APP_2FA）和 Go 关键字（如 type → APP_TYPE）
+// 按原样映射，由前缀保证环境变量名不以数字开头。
+func envKeyFor(prefix, koanfKey string) string {
+	return prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(koanfKey))
+}
+
 // parserForPath 根据文件扩展名返回对应的解析器。
 //
 // 支持的格式：
 //   - .json → JSON 解析器
 //   - .yaml, .yml, 其他 → YAML 解析器 (默认)
+//
+// .gz 压缩文件按去掉 .gz 后的扩展名判断（如 config.yaml.gz → YAML）。
 func parserForPath(path string) koanf.Parser {
-	if strings.ToLower(filepath.Ext(path)) == ".json" {
+	if isJSONPath(path) {
+		return json.Parser()
+	}
+
+	return yaml.Parser()
+}
+
+// parserFor 返回加载 path 使用的解析器，优先使用 [WithConfigPathParser] 指定的格式。
+func (o *options) parserFor(path string) koanf.Parser {
+	if o.isJSONFile(path) {
 		return json.Parser()
 	}
 
 	return yaml.Parser()
 }
 
+// isJSONFile 判断 path 是否按 JSON 解析，优先使用 [WithConfigPathParser] 指定的格式，否则按扩展名判断。
+func (o *options) isJSONFile(path string) bool {
+	path = filepath.Clean(path)
+	for p, format := range o.pathFormats {
+		if o.baseDir != "" && !filepath.IsAbs(p) {
+			p = filepath.Join(o.baseDir, p)
+		}
+		if filepath.Clean(p) == path {
+			return format == "json"
+		}
+	}
+
+	return isJSONPath(path)
+}
+
+// isJSONPath 判断文件是否为 JSON 格式（按扩展名，忽略 .gz 后缀）。
+func isJSONPath(path string) bool {
+	path = strings.ToLower(path)
+	if isGzipPath(path) {
+		path = strings.TrimSuffix(path, ".gz")
+	}
+
+	return filepath.Ext(path) == ".json"
+}
+
+// isGzipPath 判断文件是否为 gzip 压缩文件（按 .gz 后缀）。
+func isGzipPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gz")
+}
+
 // applyCLIFlagsGeneric 通过反射将用户明确指定的 CLI flags 应用到 koanf 实例。
 //
 // 自动根据配置结构体的 koanf 标签映射 CLI flag 名称。
@@ -568,83 +1984,130 @@ func parserForPath(path string) koanf.Parser {
 //   - 时间类型: time.Duration, time.Time
 //   - 切片类型: []string, []int, []int64, []float64 等
 //   - Map 类型: map[string]string
-func applyCLIFlagsGeneric[T any](cmd *cli.Command, k *koanf.Koanf, defaultConfig T) {
-	applyCLIFlagsRecursive(cmd, k, reflect.TypeOf(defaultConfig), "")
-}
-
-// applyCLIFlagsRecursive 递归遍历结构体字段应用 CLI flags。
-func applyCLIFlagsRecursive(cmd *cli.Command, k *koanf.Koanf, typ reflect.Type, prefix string) {
-	for i := range typ.NumField() {
-		field := typ.Field(i)
+//
+// bool 字段还支持取反形式 --no-<flag>（如 --no-debug 将 debug 设为 false），
+// 仅在正向 flag 未设置时生效。
+func applyCLIFlagsGeneric[T any](o *options, k *koanf.Koanf, defaultConfig T) error {
+	for _, leaf := range koanfTypeInfoFor(reflect.TypeOf(defaultConfig)).leaves {
+		// 检测用户设置的 flag 格式 (kebab-case 或 dot notation)，均未设置时检查显式别名
+		cliFlag, isSet, conflict := detectCLIFlag(o.cmd, leaf.key)
+		if !isSet {
+			cliFlag, isSet = o.aliasCLIFlag(leaf.key)
+		}
+		if !isSet {
+			if noFlag, ok := detectNegatedBoolFlag(o.cmd, leaf.key, leaf.field.Type); ok {
+				_ = k.Set(leaf.key, !o.cmd.Bool(noFlag))
+			}
 
-		// 获取 koanf 标签作为配置 key
-		koanfKey := field.Tag.Get("koanf")
-		if koanfKey == "" {
 			continue
 		}
-
-		// 构建完整的 koanf key
-		fullKoanfKey := koanfKey
-		if prefix != "" {
-			fullKoanfKey = prefix + "." + koanfKey
+		if conflict != "" {
+			if o.strictCLIFlags {
+				return fmt.Errorf("conflicting CLI flags --%s and --%s both set for %s", cliFlag, conflict, leaf.key)
+			}
+			o.logger.Warn("Conflicting CLI flags, using kebab-case value",
+				"key", leaf.key, "used", cliFlag, "ignored", conflict)
 		}
 
-		// 如果是嵌套结构体，递归处理
-		if field.Type.Kind() == reflect.Struct &&
-			field.Type != reflect.TypeFor[time.Duration]() &&
-			field.Type != reflect.TypeFor[time.Time]() {
-			applyCLIFlagsRecursive(cmd, k, field.Type, fullKoanfKey)
-
-			continue
-		}
+		// 自定义类型的字符串 flag 原样写入，由解码钩子转换
+		if !o.prevalidates(leaf.field.Type) {
+			if val, ok := o.cmd.Value(cliFlag).(string); ok {
+				_ = k.Set(leaf.key, val)
 
-		// 检测用户设置的 flag 格式 (kebab-case 或 dot notation)
-		cliFlag, isSet := detectCLIFlag(cmd, fullKoanfKey)
-		if !isSet {
-			continue
+				continue
+			}
 		}
 
 		// 根据字段类型获取值并设置
-		setCLIFlagValue(cmd, k, fullKoanfKey, cliFlag, field.Type)
+		if err := setCLIFlagValue(o.cmd, k, leaf.key, cliFlag, leaf.field.Type, o.mergeCLIMaps); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 // detectCLIFlag 检测用户设置的 CLI flag 格式。
 //
 // 支持两种格式：kebab-case (server-skip_verify) 和 dot notation (server.skip_verify)。
-// 返回实际设置的 flag 名称和是否被设置。
-func detectCLIFlag(cmd *cli.Command, koanfKey string) (string, bool) {
+// 返回实际使用的 flag 名称和是否被设置；两种格式同时被设置时优先使用 kebab-case，
+// 并通过 conflict 返回被忽略的 dot notation flag 名称。
+func detectCLIFlag(cmd *cli.Command, koanfKey string) (cliFlag string, isSet bool, conflict string) {
 	// 生成 kebab-case 格式: server.skip_verify -> server-skip_verify
 	kebabFlag := strings.ReplaceAll(koanfKey, ".", "-")
 
 	// dot notation 格式即为原始 koanf key: server.skip_verify
 	dotFlag := koanfKey
 
-	// 优先检查 kebab-case 格式
-	if cmd.IsSet(kebabFlag) {
-		return kebabFlag, true
+	kebabSet := cmd.IsSet(kebabFlag)
+	dotSet := kebabFlag != dotFlag && cmd.IsSet(dotFlag)
+
+	switch {
+	case kebabSet && dotSet:
+		return kebabFlag, true, dotFlag
+	case kebabSet:
+		// 优先使用 kebab-case 格式
+		return kebabFlag, true, ""
+	case dotSet:
+		return dotFlag, true, ""
+	default:
+		return "", false, ""
+	}
+}
+
+// detectNegatedBoolFlag 检测 bool 字段的取反 flag（--no-server-tls 或 --no-server.tls）是否被设置。
+func detectNegatedBoolFlag(cmd *cli.Command, koanfKey string, fieldType reflect.Type) (string, bool) {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Bool {
+		return "", false
+	}
+
+	noFlag, isSet, _ := detectCLIFlag(cmd, "no-"+koanfKey)
+
+	return noFlag, isSet
+}
+
+// aliasCLIFlag 返回映射到 koanfKey 且已被设置的别名 flag，多个别名同时设置时按名称排序取第一个。
+func (o *options) aliasCLIFlag(koanfKey string) (string, bool) {
+	flags := make([]string, 0, len(o.cliFlagAliases))
+	for flag, key := range o.cliFlagAliases {
+		if key == koanfKey {
+			flags = append(flags, flag)
+		}
 	}
+	slices.Sort(flags)
 
-	// 再检查 dot notation 格式
-	if cmd.IsSet(dotFlag) {
-		return dotFlag, true
+	for _, flag := range flags {
+		if o.cmd.IsSet(flag) {
+			return flag, true
+		}
 	}
 
 	return "", false
 }
 
 // setCLIFlagValue 根据字段类型从 CLI 获取值并设置到 koanf。
-func setCLIFlagValue(cmd *cli.Command, k *koanf.Koanf, koanfKey, cliFlag string, fieldType reflect.Type) {
+//
+// 指针类型字段（如 *int）按其指向的类型读取 flag，解码时自动分配指针。
+// 整数 flag 的值超出字段类型的范围时返回错误。
+// mergeMaps 为 true 时 map[string]string flag 的条目合并到已有的 map，否则整体替换。
+func setCLIFlagValue(cmd *cli.Command, k *koanf.Koanf, koanfKey, cliFlag string, fieldType reflect.Type, mergeMaps bool) error {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+
 	// 先检查特殊类型 (time.Duration, time.Time)
 	switch fieldType {
 	case reflect.TypeFor[time.Duration]():
 		_ = k.Set(koanfKey, cmd.Duration(cliFlag))
 
-		return
+		return nil
 	case reflect.TypeFor[time.Time]():
 		_ = k.Set(koanfKey, cmd.Timestamp(cliFlag))
 
-		return
+		return nil
 	}
 
 	// 处理基本类型和切片
@@ -657,29 +2120,16 @@ func setCLIFlagValue(cmd *cli.Command, k *koanf.Koanf, koanfKey, cliFlag string,
 	case reflect.Bool:
 		_ = k.Set(koanfKey, cmd.Bool(cliFlag))
 
-	// 有符号整数
-	case reflect.Int:
-		_ = k.Set(koanfKey, cmd.Int(cliFlag))
-	case reflect.Int8:
-		_ = k.Set(koanfKey, cmd.Int8(cliFlag))
-	case reflect.Int16:
-		_ = k.Set(koanfKey, cmd.Int16(cliFlag))
-	case reflect.Int32:
-		_ = k.Set(koanfKey, cmd.Int32(cliFlag))
-	case reflect.Int64:
-		_ = k.Set(koanfKey, cmd.Int64(cliFlag))
-
-	// 无符号整数
-	case reflect.Uint:
-		_ = k.Set(koanfKey, cmd.Uint(cliFlag))
-	case reflect.Uint8:
-		_ = k.Set(koanfKey, uint8(cmd.Uint(cliFlag))) //nolint:gosec // CLI value expected to be in uint8 range
-	case reflect.Uint16:
-		_ = k.Set(koanfKey, cmd.Uint16(cliFlag))
-	case reflect.Uint32:
-		_ = k.Set(koanfKey, cmd.Uint32(cliFlag))
-	case reflect.Uint64:
-		_ = k.Set(koanfKey, cmd.Uint64(cliFlag))
+	// 整数：接受任意整数类型的 flag，并校验是否超出字段类型的范围
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, ok, err := cliIntegerValue(cmd.Value(cliFlag), fieldType)
+		if err != nil {
+			return fmt.Errorf("CLI flag --%s: %w for %s", cliFlag, err, koanfKey)
+		}
+		if ok {
+			_ = k.Set(koanfKey, val)
+		}
 
 	// 浮点数
 	case reflect.Float32:
@@ -694,12 +2144,84 @@ func setCLIFlagValue(cmd *cli.Command, k *koanf.Koanf, koanfKey, cliFlag string,
 	// Map 类型
 	case reflect.Map:
 		if fieldType.Key().Kind() == reflect.String && fieldType.Elem().Kind() == reflect.String {
-			_ = k.Set(koanfKey, cmd.StringMap(cliFlag))
+			val := cmd.StringMap(cliFlag)
+			if mergeMaps {
+				merged := k.StringMap(koanfKey)
+				maps.Copy(merged, val)
+				val = merged
+			}
+			_ = k.Set(koanfKey, val)
 		}
 
 	default:
 		// 不支持的类型，忽略
 	}
+
+	return nil
+}
+
+// cliIntegerValue 将整数 flag 的值转换为字段类型，值超出字段类型的范围时返回错误。
+//
+// flag 可以是任意整数类型（如 IntFlag 用于 int8 字段）或字符串（按字段位宽解析）。
+// 其他类型的 flag 返回 false，不修改配置。
+func cliIntegerValue(flagVal any, fieldType reflect.Type) (any, bool, error) {
+	v := reflect.ValueOf(flagVal)
+	out := reflect.New(fieldType).Elem()
+	isUint := out.CanUint()
+
+	overflow := func() (any, bool, error) {
+		return nil, false, fmt.Errorf("value %v overflows %s", flagVal, fieldType.Kind())
+	}
+
+	switch {
+	case v.CanInt():
+		n := v.Int()
+		if isUint {
+			if n < 0 || out.OverflowUint(uint64(n)) {
+				return overflow()
+			}
+			out.SetUint(uint64(n))
+		} else {
+			if out.OverflowInt(n) {
+				return overflow()
+			}
+			out.SetInt(n)
+		}
+	case v.CanUint():
+		n := v.Uint()
+		if isUint {
+			if out.OverflowUint(n) {
+				return overflow()
+			}
+			out.SetUint(n)
+		} else {
+			if n > math.MaxInt64 || out.OverflowInt(int64(n)) {
+				return overflow()
+			}
+			out.SetInt(int64(n))
+		}
+	case v.Kind() == reflect.String:
+		var err error
+		if isUint {
+			var n uint64
+			n, err = strconv.ParseUint(v.String(), 0, fieldType.Bits())
+			out.SetUint(n)
+		} else {
+			var n int64
+			n, err = strconv.ParseInt(v.String(), 0, fieldType.Bits())
+			out.SetInt(n)
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return overflow()
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("value %q is not a valid %s", v.String(), fieldType.Kind())
+		}
+	default:
+		return nil, false, nil
+	}
+
+	return out.Interface(), true, nil
 }
 
 // setSliceFlagValue 处理切片类型的 CLI flag。
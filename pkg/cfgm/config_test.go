@@ -1,17 +1,29 @@
 package cfgm
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/knadh/koanf/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 // =============================================================================
@@ -99,6 +111,84 @@ func TestLoadWithEnvBinding(t *testing.T) {
 	assert.Equal(t, "redis://test:6379", cfg.Redis.URL)
 }
 
+func TestLoadWithEnvBindingStripped(t *testing.T) {
+	type Config struct {
+		Redis struct {
+			URL string `koanf:"url"`
+		} `koanf:"redis"`
+	}
+
+	t.Setenv("MYAPP_REDIS_URL", "redis://myapp:6379")
+	t.Setenv("REDIS_URL", "redis://unprefixed:6379")
+
+	cfg, err := Load(Config{}, WithEnvBindingStripped("MYAPP_", "REDIS_URL", "redis.url"))
+	require.NoError(t, err)
+	assert.Equal(t, "redis://myapp:6379", cfg.Redis.URL)
+}
+
+func TestLoadWithEnvBindingsPrefix(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host string `koanf:"host"`
+			Port int    `koanf:"port"`
+			User string `koanf:"user"`
+		} `koanf:"db"`
+	}
+
+	t.Setenv("PG_HOST", "pg.local")
+	t.Setenv("PG_PORT", "5433")
+	t.Setenv("USER", "not-bound")
+
+	defaultCfg := Config{}
+	defaultCfg.DB.Host = "localhost"
+	defaultCfg.DB.Port = 5432
+	defaultCfg.DB.User = "postgres"
+
+	cfg, err := Load(defaultCfg, WithEnvBindingsPrefix("PG_", map[string]string{
+		"HOST": "db.host",
+		"PORT": "db.port",
+		"USER": "db.user",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "pg.local", cfg.DB.Host)
+	assert.Equal(t, 5433, cfg.DB.Port)
+	assert.Equal(t, "postgres", cfg.DB.User, "unset PG_USER keeps default")
+}
+
+func TestLoadWithEnvBindingCoalesce(t *testing.T) {
+	type Config struct {
+		Redis struct {
+			URL string `koanf:"url"`
+		} `koanf:"redis"`
+	}
+	defaultCfg := Config{}
+	defaultCfg.Redis.URL = "redis://localhost:6379"
+	opt := WithEnvBindingCoalesce("redis.url", "REDIS_URL", "CACHE_URL")
+
+	t.Run("second env supplies value when first is unset", func(t *testing.T) {
+		t.Setenv("CACHE_URL", "redis://cache:6379")
+
+		cfg, err := Load(defaultCfg, opt)
+		require.NoError(t, err)
+		assert.Equal(t, "redis://cache:6379", cfg.Redis.URL)
+	})
+
+	t.Run("first set env wins", func(t *testing.T) {
+		t.Setenv("REDIS_URL", "redis://redis:6379")
+		t.Setenv("CACHE_URL", "redis://cache:6379")
+
+		cfg, err := Load(defaultCfg, opt)
+		require.NoError(t, err)
+		assert.Equal(t, "redis://redis:6379", cfg.Redis.URL)
+	})
+
+	t.Run("none set keeps default", func(t *testing.T) {
+		cfg, err := Load(defaultCfg, opt)
+		require.NoError(t, err)
+		assert.Equal(t, "redis://localhost:6379", cfg.Redis.URL)
+	})
+}
+
 func TestLoadWithHyphenInKoanfKey(t *testing.T) {
 	type ClientConfig struct {
 		ServerPassword string `koanf:"server-password"`
@@ -152,6 +242,41 @@ client:
 	assert.Equal(t, "from-config", cfg.Name)
 }
 
+func TestLoadWithNestedEnvBindKey(t *testing.T) {
+	type Config struct {
+		Password string         `koanf:"password"`
+		Meta     map[string]any `koanf:"meta"`
+	}
+
+	t.Setenv("NESTED_PWD", "env-password")
+
+	t.Run("bind node is the only child", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `
+meta:
+  envbind:
+    NESTED_PWD: password
+password: "file-password"
+`)
+		cfg, err := Load(Config{Meta: map[string]any{}}, WithConfigPaths(tmpFile), WithEnvBindKey("meta.envbind"))
+		require.NoError(t, err)
+		assert.Equal(t, "env-password", cfg.Password)
+		assert.Empty(t, cfg.Meta, "empty meta node should be removed")
+	})
+
+	t.Run("bind node has siblings", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `
+meta:
+  owner: ops
+  envbind:
+    NESTED_PWD: password
+`)
+		cfg, err := Load(Config{Meta: map[string]any{}}, WithConfigPaths(tmpFile), WithEnvBindKey("meta.envbind"))
+		require.NoError(t, err)
+		assert.Equal(t, "env-password", cfg.Password)
+		assert.Equal(t, map[string]any{"owner": "ops"}, cfg.Meta)
+	})
+}
+
 func TestEnvBindingPriority(t *testing.T) {
 	type Config struct {
 		Password string `koanf:"password"`
@@ -366,6 +491,31 @@ func TestLoadWithBaseDir(t *testing.T) {
 		assert.Equal(t, "fallback", cfg.Server.Addr)
 	})
 
+	t.Run("WithBaseDirExecutable uses executable dir", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"config.yaml": `server: {addr: ":7070"}`})
+		stubExecutablePath(t, filepath.Join(dir, "myapp"), nil)
+
+		cfg, err := Load(
+			Config{Server: ServerConfig{Addr: "default"}},
+			WithBaseDirExecutable(),
+			WithConfigPaths("config.yaml"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, ":7070", cfg.Server.Addr)
+	})
+
+	t.Run("WithBaseDirExecutable falls back to cwd", func(t *testing.T) {
+		stubExecutablePath(t, "", errors.New("unsupported"))
+
+		cfg, err := Load(
+			Config{Server: ServerConfig{Addr: "fallback"}},
+			WithBaseDirExecutable(),
+			WithConfigPaths("config/config.example.yaml"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "fallback", cfg.Server.Addr, "cwd is pkg/cfgm, not the project root")
+	})
+
 	t.Run("absolute path unchanged", func(t *testing.T) {
 		tmpFile := writeTempConfig(t, `server: {addr: ":9090"}`)
 		cfg, err := Load(
@@ -377,6 +527,15 @@ func TestLoadWithBaseDir(t *testing.T) {
 	})
 }
 
+// stubExecutablePath 在测试期间替换 executablePath。
+func stubExecutablePath(t *testing.T, path string, err error) {
+	t.Helper()
+
+	orig := executablePath
+	executablePath = func() (string, error) { return path, err }
+	t.Cleanup(func() { executablePath = orig })
+}
+
 // =============================================================================
 // CLI Flags 测试 (github.com/urfave/cli/v3)
 // =============================================================================
@@ -471,6 +630,77 @@ func TestLoadWithCommand_SubCommands(t *testing.T) {
 	assert.Equal(t, 30, loadedCfg.Timeout, "unset keeps default")
 }
 
+func TestLoadWithConfigFlag(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+	defaultCfg := Config{Name: "default", Port: 8080}
+
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "name: searched\n",
+		"prod.yaml":   "name: prod\nport: 9090\n",
+	})
+	searchOpts := []Option{WithBaseDir(dir), WithConfigPaths("config.yaml")}
+
+	// run 在父命令上定义 --config，在子命令 serve 中加载配置
+	run := func(t *testing.T, args ...string) (*Config, error) {
+		t.Helper()
+		var loaded *Config
+		serve := &cli.Command{
+			Name: "serve",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				cfg, err := Load(defaultCfg, append(searchOpts, WithCommand(cmd), WithConfigFlag("config"))...)
+				loaded = cfg
+
+				return err
+			},
+		}
+		app := &cli.Command{
+			Name:     "app",
+			Flags:    []cli.Flag{&cli.StringFlag{Name: "config", Aliases: []string{"c"}, Value: "ignored.yaml"}},
+			Commands: []*cli.Command{serve},
+		}
+		err := app.Run(context.Background(), append([]string{"app"}, args...))
+
+		return loaded, err
+	}
+
+	t.Run("parent flag used in subcommand", func(t *testing.T) {
+		cfg, err := run(t, "--config", filepath.Join(dir, "prod.yaml"), "serve")
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "prod", Port: 9090}, *cfg)
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		cfg, err := run(t, "-c", filepath.Join(dir, "prod.yaml"), "serve")
+		require.NoError(t, err)
+		assert.Equal(t, "prod", cfg.Name)
+	})
+
+	t.Run("unset flag keeps search paths", func(t *testing.T) {
+		cfg, err := run(t, "serve")
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "searched", Port: 8080}, *cfg)
+	})
+
+	t.Run("flag wins over env", func(t *testing.T) {
+		t.Setenv("CI_CONFIG", filepath.Join(dir, "config.yaml"))
+		searchOpts := append(searchOpts, WithConfigPathsOverrideEnv("CI_CONFIG"))
+
+		cfg := runCLITest(t, defaultCfg, []cli.Flag{&cli.StringFlag{Name: "config"}},
+			[]string{"test", "--config", filepath.Join(dir, "prod.yaml")},
+			append(searchOpts, WithConfigFlag("config"))...)
+		assert.Equal(t, "prod", cfg.Name)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := run(t, "--config", filepath.Join(dir, "missing.yaml"), "serve")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "(from flag --config) not found")
+	})
+}
+
 func TestLoadWithCommand_Priority(t *testing.T) {
 	type Config struct {
 		Value string `koanf:"value"`
@@ -756,6 +986,54 @@ func TestCollectKoanfKeys(t *testing.T) {
 	}
 }
 
+func TestKoanfTypeInfoCache(t *testing.T) {
+	type Server struct {
+		Host    string        `koanf:"host"`
+		Timeout time.Duration `koanf:"timeout"`
+	}
+	type Config struct {
+		Name   string            `koanf:"name"`
+		Server Server            `koanf:"server"`
+		Labels map[string]string `koanf:"labels"`
+		Skip   string
+	}
+	typ := reflect.TypeFor[Config]()
+
+	uncached := buildKoanfTypeInfo(typ)
+	cached := koanfTypeInfoFor(typ)
+
+	a := assert.New(t)
+	a.Equal(uncached, cached)
+	a.Equal([]string{"name", "server.host", "server.timeout", "labels"}, cached.keys)
+	a.Equal(reflect.TypeFor[time.Duration](), cached.fieldTypes["server.timeout"])
+	a.Same(cached, koanfTypeInfoFor(typ), "second lookup should hit the cache")
+	a.Equal(collectKoanfKeys(Config{}), collectKoanfKeys(&Config{}))
+}
+
+func BenchmarkCollectKoanfFieldTypes(b *testing.B) {
+	cfg := struct {
+		Name   string `koanf:"name"`
+		Server struct {
+			Host    string        `koanf:"host"`
+			Port    int           `koanf:"port"`
+			Timeout time.Duration `koanf:"timeout"`
+		} `koanf:"server"`
+		Tags []string `koanf:"tags"`
+	}{}
+	typ := reflect.TypeOf(cfg)
+
+	b.Run("cached", func(b *testing.B) {
+		for b.Loop() {
+			collectKoanfFieldTypes(cfg)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		for b.Loop() {
+			buildKoanfTypeInfo(typ)
+		}
+	})
+}
+
 // =============================================================================
 // generateEnvBindings 测试 (内部函数)
 // =============================================================================
@@ -796,6 +1074,19 @@ func TestGenerateEnvBindings(t *testing.T) {
 				"APP_CLIENT_REV_AUTH_USER":   "client.rev-auth-user",
 			},
 		},
+		{
+			name:   "leading digits and keywords",
+			prefix: "APP_",
+			keys:   []string{"2fa", "two_factor", "type", "func", "auth.2fa-code", "range.0"},
+			expected: map[string]string{
+				"APP_2FA":           "2fa",
+				"APP_TWO_FACTOR":    "two_factor",
+				"APP_TYPE":          "type",
+				"APP_FUNC":          "func",
+				"APP_AUTH_2FA_CODE": "auth.2fa-code",
+				"APP_RANGE_0":       "range.0",
+			},
+		},
 		{
 			name:   "empty prefix",
 			prefix: "",
@@ -922,6 +1213,46 @@ model: "test"
 		assert.Equal(t, "{{env \"TEST_KEY\"}}", cfg.APIKey)
 	})
 
+	t.Run("ConfigDir refers to config file directory", func(t *testing.T) {
+		configPath := writeTempConfig(t, `api_key: '{{.ConfigDir}}/tls.pem'`)
+		cfg, err := Load(Config{}, WithConfigPaths(configPath))
+		require.NoError(t, err)
+
+		assert.Equal(t, filepath.Join(filepath.Dir(configPath), "tls.pem"), cfg.APIKey)
+		assert.True(t, filepath.IsAbs(cfg.APIKey))
+	})
+
+	t.Run("WithTemplateDelimiters keeps braces literal", func(t *testing.T) {
+		t.Setenv("TEST_API_KEY", "sk-delims")
+
+		configContent := `
+api_key: '[[env "TEST_API_KEY"]]'
+model: '{{keep}}'
+`
+		configPath := writeTempConfig(t, configContent)
+		cfg, err := Load(Config{}, WithConfigPaths(configPath), WithTemplateDelimiters("[[", "]]"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "sk-delims", cfg.APIKey)
+		assert.Equal(t, "{{keep}}", cfg.Model)
+	})
+
+	t.Run("WithTemplateMissingZero renders empty string", func(t *testing.T) {
+		configContent := `
+api_key: '{{.TEST_MISSING_KEY}}'
+model: 'm-{{.TEST_MISSING_MODEL}}'
+`
+		configPath := writeTempConfig(t, configContent)
+		cfg, err := Load(Config{APIKey: "default"}, WithConfigPaths(configPath), WithTemplateMissingZero())
+		require.NoError(t, err)
+		assert.Empty(t, cfg.APIKey)
+		assert.Equal(t, "m-", cfg.Model)
+
+		cfg, err = Load(Config{}, WithConfigPaths(configPath))
+		require.NoError(t, err)
+		assert.Equal(t, "<no value>", cfg.APIKey, "default mode keeps text/template behavior")
+	})
+
 	t.Run("template syntax error", func(t *testing.T) {
 		configContent := `
 api_key: '{{env "TEST_KEY"'
@@ -1007,6 +1338,205 @@ func TestLoadWithJSONConfig(t *testing.T) {
 	a.Equal(60*time.Second, cfg.Server.Timeout)
 }
 
+func TestLoadWithConfigBasename(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+	defaultCfg := Config{Name: "default", Port: 8080}
+
+	t.Run("only json exists", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"config.json": `{"name": "json-app", "port": 9090}`})
+
+		cfg, err := Load(defaultCfg, WithBaseDir(dir), WithConfigBasename("config"))
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "json-app", Port: 9090}, *cfg)
+	})
+
+	t.Run("yaml preferred over json", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yml":  "name: yml-app\n",
+			"config.json": `{"name": "json-app"}`,
+		})
+
+		cfg, err := Load(defaultCfg, WithBaseDir(dir), WithConfigBasename("config"))
+		require.NoError(t, err)
+		assert.Equal(t, "yml-app", cfg.Name)
+	})
+
+	t.Run("none exists", func(t *testing.T) {
+		cfg, err := Load(defaultCfg, WithBaseDir(t.TempDir()), WithConfigBasename("config"))
+		require.NoError(t, err)
+		assert.Equal(t, defaultCfg, *cfg)
+	})
+}
+
+func TestLoadWithConfigPathsOverrideEnv(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+	defaultCfg := Config{Name: "default", Port: 8080}
+
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "name: local\nport: 9090\n",
+		"ci.yaml":     "name: ci\n",
+	})
+	opts := []Option{
+		WithBaseDir(dir),
+		WithConfigPaths("config.yaml"),
+		WithConfigPathsOverrideEnv("CI_CONFIG"),
+	}
+
+	t.Run("env replaces path list", func(t *testing.T) {
+		t.Setenv("CI_CONFIG", filepath.Join(dir, "ci.yaml"))
+
+		cfg, err := Load(defaultCfg, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "ci", Port: 8080}, *cfg, "config.yaml should not be merged")
+	})
+
+	t.Run("env unset uses configured paths", func(t *testing.T) {
+		cfg, err := Load(defaultCfg, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "local", Port: 9090}, *cfg)
+	})
+
+	t.Run("missing override file", func(t *testing.T) {
+		t.Setenv("CI_CONFIG", filepath.Join(dir, "missing.yaml"))
+
+		_, err := Load(defaultCfg, opts...)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "(from env CI_CONFIG) not found")
+	})
+
+	t.Run("ignored with no env", func(t *testing.T) {
+		t.Setenv("CI_CONFIG", filepath.Join(dir, "ci.yaml"))
+
+		cfg, err := Load(defaultCfg, append(opts, WithNoEnv())...)
+		require.NoError(t, err)
+		assert.Equal(t, "local", cfg.Name)
+	})
+}
+
+func TestLoadWithConfigPathParser(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+	defaultCfg := Config{Name: "default", Port: 8080}
+
+	dir := writeFiles(t, map[string]string{
+		"app.conf":   "name: yaml-conf\nport: 9090\n",
+		"json.conf":  `{"name": "json-conf", "port": 7070}`,
+		"mixed.json": "name: yaml-in-json\n",
+	})
+
+	t.Run("yaml content in conf file", func(t *testing.T) {
+		cfg, err := Load(defaultCfg,
+			WithBaseDir(dir),
+			WithConfigPaths("app.conf"),
+			WithConfigPathParser("app.conf", "yaml"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "yaml-conf", Port: 9090}, *cfg)
+	})
+
+	t.Run("json content in conf file", func(t *testing.T) {
+		cfg, err := Load(defaultCfg,
+			WithBaseDir(dir),
+			WithConfigPaths("json.conf"),
+			WithConfigPathParser("json.conf", "JSON"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "json-conf", Port: 7070}, *cfg)
+	})
+
+	t.Run("overrides extension", func(t *testing.T) {
+		_, err := Load(defaultCfg, WithBaseDir(dir), WithConfigPaths("mixed.json"))
+		require.Error(t, err, "yaml content should not parse as json")
+
+		cfg, err := Load(defaultCfg,
+			WithBaseDir(dir),
+			WithConfigPaths("mixed.json"),
+			WithConfigPathParser(filepath.Join(dir, "mixed.json"), "yml"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "yaml-in-json", cfg.Name)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Load(defaultCfg,
+			WithBaseDir(dir),
+			WithConfigPaths("app.conf"),
+			WithConfigPathParser("app.conf", "toml"),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported config format "toml"`)
+	})
+}
+
+func TestLoadWithConfigFromContext(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+	type ctxKey struct{}
+	defaultCfg := Config{Name: "default", Port: 8080}
+
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "name: local\n",
+		"tenant.yaml": "name: tenant\nport: 9090\n",
+	})
+	opts := []Option{WithBaseDir(dir), WithConfigPaths("config.yaml")}
+
+	t.Run("path in context", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, filepath.Join(dir, "tenant.yaml"))
+
+		cfg, err := Load(defaultCfg, append(opts, WithConfigFromContext(ctx, ctxKey{}))...)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "tenant", Port: 9090}, *cfg)
+	})
+
+	t.Run("bytes in context", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, []byte("name: inline\n"))
+
+		cfg, err := Load(defaultCfg, append(opts, WithConfigFromContext(ctx, ctxKey{}))...)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "inline", Port: 8080}, *cfg)
+	})
+
+	t.Run("bytes in context have empty ConfigDir", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, []byte("name: 'dir={{.ConfigDir}}'\n"))
+
+		cfg, err := Load(defaultCfg, append(opts, WithConfigFromContext(ctx, ctxKey{}))...)
+		require.NoError(t, err)
+		assert.Equal(t, "dir=", cfg.Name)
+	})
+
+	t.Run("missing key uses configured paths", func(t *testing.T) {
+		cfg, err := Load(defaultCfg, append(opts, WithConfigFromContext(context.Background(), ctxKey{}))...)
+		require.NoError(t, err)
+		assert.Equal(t, "local", cfg.Name)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, filepath.Join(dir, "missing.yaml"))
+
+		_, err := Load(defaultCfg, append(opts, WithConfigFromContext(ctx, ctxKey{}))...)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "(from context) not found")
+	})
+
+	t.Run("unsupported value type", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, 42)
+
+		_, err := Load(defaultCfg, append(opts, WithConfigFromContext(ctx, ctxKey{}))...)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported value type int")
+	})
+}
+
 func TestParserForPath(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -1082,32 +1612,177 @@ func TestJSONTemplateExpansion(t *testing.T) {
 	})
 }
 
-func TestJSONPartialOverride(t *testing.T) {
+func TestLoadWithTemplateDataFile(t *testing.T) {
 	type Config struct {
-		Name    string `koanf:"name"`
-		Debug   bool   `koanf:"debug"`
-		Port    int    `koanf:"port"`
-		Timeout int    `koanf:"timeout"`
+		Endpoint string `koanf:"endpoint"`
+		Replicas int    `koanf:"replicas"`
 	}
 
-	jsonContent := `{
-  "name": "json-override",
-  "port": 9000
-}`
+	dir := writeFiles(t, map[string]string{
+		"config.yaml":    "endpoint: \"https://api.{{.region}}.example.com\"\nreplicas: {{.replicas}}\n",
+		"vars.prod.yaml": "region: eu-west-1\nreplicas: 3\n",
+		"vars.json":      `{"region": "us-east-1"}`,
+		"nested.yaml":    "region:\n  name: eu-west-1\n",
+	})
+	configPath := filepath.Join(dir, "config.yaml")
 
-	tmpFile := writeTempJSONConfig(t, jsonContent)
-	cfg, err := Load(
-		Config{Name: "default", Debug: true, Port: 8080, Timeout: 30},
-		WithConfigPaths(tmpFile),
-	)
-	require.NoError(t, err)
+	t.Run("vars file supplies region", func(t *testing.T) {
+		cfg, err := Load(Config{},
+			WithConfigPaths(configPath),
+			WithNoEnv(),
+			WithTemplateDataFile(filepath.Join(dir, "vars.prod.yaml")),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.eu-west-1.example.com", cfg.Endpoint)
+		assert.Equal(t, 3, cfg.Replicas)
+	})
 
-	a := assert.New(t)
-	a.Equal("json-override", cfg.Name, "specified field should be overridden")
-	a.True(cfg.Debug, "unspecified field should keep default")
-	a.Equal(9000, cfg.Port, "specified field should be overridden")
-	a.Equal(30, cfg.Timeout, "unspecified field should keep default")
-}
+	t.Run("relative to base dir", func(t *testing.T) {
+		cfg, err := Load(Config{},
+			WithBaseDir(dir),
+			WithConfigPaths("config.yaml"),
+			WithNoEnv(),
+			WithTemplateDataFile("vars.prod.yaml"),
+			WithTemplateDataFile("vars.json"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.us-east-1.example.com", cfg.Endpoint, "later file should win")
+		assert.Equal(t, 3, cfg.Replicas)
+	})
+
+	t.Run("overrides env and is overridden by WithTemplateData", func(t *testing.T) {
+		t.Setenv("region", "from-env")
+		cfg, err := Load(Config{},
+			WithConfigPaths(configPath),
+			WithTemplateDataFile(filepath.Join(dir, "vars.prod.yaml")),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.eu-west-1.example.com", cfg.Endpoint)
+
+		cfg, err = Load(Config{},
+			WithConfigPaths(configPath),
+			WithTemplateDataFile(filepath.Join(dir, "vars.prod.yaml")),
+			WithTemplateData(map[string]string{"region": "ap-south-1"}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.ap-south-1.example.com", cfg.Endpoint)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Load(Config{},
+			WithConfigPaths(configPath),
+			WithNoEnv(),
+			WithTemplateDataFile(filepath.Join(dir, "missing.yaml")),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "read template data file")
+	})
+
+	t.Run("nested value rejected", func(t *testing.T) {
+		_, err := Load(Config{},
+			WithConfigPaths(configPath),
+			WithNoEnv(),
+			WithTemplateDataFile(filepath.Join(dir, "nested.yaml")),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `key "region" must be a scalar value`)
+	})
+
+	t.Run("gzip file", func(t *testing.T) {
+		cfg, err := Load(Config{},
+			WithConfigPaths(configPath),
+			WithNoEnv(),
+			WithTemplateDataFile(writeTempGzipConfig(t, "vars.yaml.gz", "region: eu-west-1\nreplicas: 3\n")),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.eu-west-1.example.com", cfg.Endpoint)
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		_, err := Load(Config{},
+			WithConfigPaths(configPath),
+			WithNoEnv(),
+			WithMaxConfigSize(100),
+			WithTemplateDataFile(writeTempConfig(t, "region: "+strings.Repeat("x", 200)+"\n")),
+		)
+		require.ErrorIs(t, err, ErrConfigTooLarge)
+		assert.Contains(t, err.Error(), "read template data file")
+	})
+
+	t.Run("read once per load", func(t *testing.T) {
+		varsPath := writeTempConfig(t, "region: eu-west-1\nreplicas: 3\n")
+		localPath := writeTempConfig(t, "endpoint: \"https://local.{{.region}}.example.com\"\n")
+
+		// 主配置文件加载后删除数据文件，lenient 文件展开时仍使用已读取的数据
+		res, err := LoadWithResult(Config{},
+			WithConfigPaths(configPath),
+			WithNoEnv(),
+			WithTemplateDataFile(varsPath),
+			WithLenientFile(localPath),
+			WithHooks(Hooks{OnFileLoaded: func(string) { require.NoError(t, os.Remove(varsPath)) }}),
+		)
+		require.NoError(t, err)
+		assert.Empty(t, res.Warnings)
+		assert.Equal(t, "https://local.eu-west-1.example.com", res.Config.Endpoint)
+	})
+}
+
+func TestLoadTemplateReadFile(t *testing.T) {
+	type Config struct {
+		Region string `koanf:"region"`
+	}
+
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "region: '{{(readYaml \"shared.yaml\").region}}'\n",
+		"shared.yaml": "region: eu-west-1\n",
+		"big.yaml":    "region: " + strings.Repeat("x", 200) + "\n",
+		"large.yaml":  "region: '{{(readYaml \"big.yaml\").region}}'\n",
+	})
+
+	t.Run("relative to base dir", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithBaseDir(dir), WithConfigPaths("config.yaml"), WithNoEnv())
+		require.NoError(t, err)
+		assert.Equal(t, "eu-west-1", cfg.Region)
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		_, err := Load(Config{},
+			WithBaseDir(dir),
+			WithConfigPaths("large.yaml"),
+			WithNoEnv(),
+			WithMaxConfigSize(100),
+		)
+		require.ErrorIs(t, err, ErrConfigTooLarge)
+		assert.Contains(t, err.Error(), "readYaml")
+	})
+}
+
+func TestJSONPartialOverride(t *testing.T) {
+	type Config struct {
+		Name    string `koanf:"name"`
+		Debug   bool   `koanf:"debug"`
+		Port    int    `koanf:"port"`
+		Timeout int    `koanf:"timeout"`
+	}
+
+	jsonContent := `{
+  "name": "json-override",
+  "port": 9000
+}`
+
+	tmpFile := writeTempJSONConfig(t, jsonContent)
+	cfg, err := Load(
+		Config{Name: "default", Debug: true, Port: 8080, Timeout: 30},
+		WithConfigPaths(tmpFile),
+	)
+	require.NoError(t, err)
+
+	a := assert.New(t)
+	a.Equal("json-override", cfg.Name, "specified field should be overridden")
+	a.True(cfg.Debug, "unspecified field should keep default")
+	a.Equal(9000, cfg.Port, "specified field should be overridden")
+	a.Equal(30, cfg.Timeout, "unspecified field should keep default")
+}
 
 func TestJSONWithEnvPrefix(t *testing.T) {
 	type Config struct {
@@ -1316,7 +1991,7 @@ func TestSetCLIFlagValue_UnsupportedTypes(t *testing.T) {
 			_ = k.Load(confmap.Provider(map[string]any{"dummy": "initial"}, "."), nil)
 
 			// 测试不支持的基本类型 (complex128)
-			setCLIFlagValue(c, k, "dummy", "dummy", reflect.TypeFor[complex128]())
+			setCLIFlagValue(c, k, "dummy", "dummy", reflect.TypeFor[complex128](), false)
 			// 测试不支持的切片元素类型
 			setSliceFlagValue(c, k, "dummy", "dummy", reflect.TypeFor[[]complex128]())
 
@@ -1331,3 +2006,1545 @@ func TestSetCLIFlagValue_UnsupportedTypes(t *testing.T) {
 	// 不支持的类型不会修改值
 	assert.Equal(t, "initial", loadedCfg["dummy"])
 }
+
+// =============================================================================
+// WithNoEnv 测试
+// =============================================================================
+
+func TestLoadWithNoEnv(t *testing.T) {
+	type Config struct {
+		Name   string `koanf:"name"`
+		Port   int    `koanf:"port"`
+		APIKey string `koanf:"api_key"`
+		Region string `koanf:"region"`
+	}
+
+	t.Setenv("NOENV_NAME", "from-prefix")
+	t.Setenv("NOENV_BOUND_PORT", "9090")
+	t.Setenv("NOENV_API_KEY", "from-env")
+
+	configPath := writeTempConfig(t, `
+api_key: '{{env "NOENV_API_KEY" "file-default"}}'
+region: "{{.region | default "none"}}"
+`)
+
+	t.Run("env applies without option", func(t *testing.T) {
+		cfg, err := Load(Config{Name: "default", Port: 8080},
+			WithConfigPaths(configPath),
+			WithEnvPrefix("NOENV_"),
+			WithEnvBinding("NOENV_BOUND_PORT", "port"),
+		)
+		require.NoError(t, err)
+		a := assert.New(t)
+		a.Equal("from-prefix", cfg.Name)
+		a.Equal(9090, cfg.Port)
+		a.Equal("from-env", cfg.APIKey)
+	})
+
+	t.Run("env ignored with option", func(t *testing.T) {
+		cfg, err := Load(Config{Name: "default", Port: 8080},
+			WithConfigPaths(configPath),
+			WithEnvPrefix("NOENV_"),
+			WithEnvBinding("NOENV_BOUND_PORT", "port"),
+			WithNoEnv(),
+			WithTemplateData(map[string]string{"region": "eu-west-1"}),
+		)
+		require.NoError(t, err)
+		a := assert.New(t)
+		a.Equal("default", cfg.Name)
+		a.Equal(8080, cfg.Port)
+		a.Equal("file-default", cfg.APIKey)
+		a.Equal("eu-west-1", cfg.Region, "WithTemplateData should still apply")
+	})
+}
+
+// =============================================================================
+// ExampleYAMLSection 测试
+// =============================================================================
+
+func TestExampleYAMLSection(t *testing.T) {
+	type ServerConfig struct {
+		Addr string `koanf:"addr" desc:"监听地址"`
+		Port int    `koanf:"port" desc:"监听端口"`
+	}
+	type ClientConfig struct {
+		URL string `koanf:"url" desc:"服务器地址"`
+	}
+	type Config struct {
+		Server ServerConfig `koanf:"server" desc:"服务端配置"`
+		Client ClientConfig `koanf:"client" desc:"客户端配置"`
+	}
+
+	cfg := Config{
+		Server: ServerConfig{Addr: "0.0.0.0", Port: 8080},
+		Client: ClientConfig{URL: "http://localhost:8080"},
+	}
+
+	t.Run("existing section", func(t *testing.T) {
+		out, err := ExampleYAMLSection(cfg, "server")
+		require.NoError(t, err)
+
+		yaml := string(out)
+		a := assert.New(t)
+		a.True(strings.HasPrefix(yaml, "# 服务端配置\nserver:\n"), "section should start with its comment: %q", yaml)
+		a.Contains(yaml, `addr: "0.0.0.0" # 监听地址`)
+		a.Contains(yaml, "port: 8080 # 监听端口")
+		a.NotContains(yaml, "client")
+		a.NotContains(yaml, "配置示例文件")
+	})
+
+	t.Run("missing section", func(t *testing.T) {
+		_, err := ExampleYAMLSection(cfg, "database")
+		assert.ErrorContains(t, err, `"database"`)
+	})
+}
+
+// =============================================================================
+// 指针标量字段测试
+// =============================================================================
+
+type pointerTestConfig struct {
+	Workers *int  `koanf:"workers" desc:"工作协程数"`
+	Verbose *bool `koanf:"verbose" desc:"详细输出"`
+}
+
+func TestPointerScalarFields_Example(t *testing.T) {
+	workers, verbose := 4, true
+
+	t.Run("nil renders null", func(t *testing.T) {
+		yaml := string(ExampleYAML(pointerTestConfig{}))
+		a := assert.New(t)
+		a.Contains(yaml, "workers: null # 工作协程数")
+		a.Contains(yaml, "verbose: null # 详细输出")
+		a.NotContains(yaml, "0x")
+	})
+
+	t.Run("non-nil renders underlying value", func(t *testing.T) {
+		yaml := string(ExampleYAML(pointerTestConfig{Workers: &workers, Verbose: &verbose}))
+		a := assert.New(t)
+		a.Contains(yaml, "workers: 4 # 工作协程数")
+		a.Contains(yaml, "verbose: true # 详细输出")
+	})
+}
+
+func TestPointerScalarFields_Load(t *testing.T) {
+	workers := 4
+
+	t.Run("defaults preserved", func(t *testing.T) {
+		cfg, err := Load(pointerTestConfig{Workers: &workers}, WithConfigPaths("nonexistent.yaml"))
+		require.NoError(t, err)
+		require.NotNil(t, cfg.Workers)
+		assert.Equal(t, 4, *cfg.Workers)
+		assert.Nil(t, cfg.Verbose)
+	})
+
+	t.Run("file sets nil pointers", func(t *testing.T) {
+		configPath := writeTempConfig(t, "workers: 8\nverbose: false\n")
+		cfg, err := Load(pointerTestConfig{}, WithConfigPaths(configPath))
+		require.NoError(t, err)
+		require.NotNil(t, cfg.Workers)
+		require.NotNil(t, cfg.Verbose)
+		assert.Equal(t, 8, *cfg.Workers)
+		assert.False(t, *cfg.Verbose)
+	})
+
+	t.Run("cli sets pointed-to value", func(t *testing.T) {
+		flags := []cli.Flag{
+			&cli.IntFlag{Name: "workers"},
+			&cli.BoolFlag{Name: "verbose"},
+		}
+		cfg := runCLITest(t, pointerTestConfig{Workers: &workers}, flags,
+			[]string{"test", "--workers", "16", "--verbose"},
+			WithConfigPaths("nonexistent.yaml"),
+		)
+		require.NotNil(t, cfg.Workers)
+		require.NotNil(t, cfg.Verbose)
+		assert.Equal(t, 16, *cfg.Workers)
+		assert.True(t, *cfg.Verbose)
+	})
+}
+
+// =============================================================================
+// ConfigTestHelper 测试
+// =============================================================================
+
+func TestConfigTestHelper_AssertExampleUpToDate(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name" desc:"应用名称"`
+		Port int    `koanf:"port" desc:"端口"`
+	}
+	cfg := Config{Name: "app", Port: 8080}
+
+	examplePath := filepath.Join(t.TempDir(), "config.example.yaml")
+	require.NoError(t, os.WriteFile(examplePath, ExampleYAML(cfg), 0600))
+
+	t.Run("matching file", func(t *testing.T) {
+		helper := ConfigTestHelper[Config]{ExamplePath: examplePath}
+		helper.AssertExampleUpToDate(t, cfg)
+	})
+
+	t.Run("mismatching file", func(t *testing.T) {
+		diff, err := exampleDiff(examplePath, ExampleYAML(Config{Name: "changed", Port: 8080}))
+		require.NoError(t, err)
+
+		a := assert.New(t)
+		a.Contains(diff, `- name: "app" # 应用名称`)
+		a.Contains(diff, `+ name: "changed" # 应用名称`)
+		a.NotContains(diff, "port", "unchanged lines should not appear in diff")
+	})
+
+	t.Run("inserted line", func(t *testing.T) {
+		type Extended struct {
+			Name  string `koanf:"name" desc:"应用名称"`
+			Debug bool   `koanf:"debug" desc:"调试模式"`
+			Port  int    `koanf:"port" desc:"端口"`
+		}
+		diff, err := exampleDiff(examplePath, ExampleYAML(Extended{Name: "app", Port: 8080}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "  @@ -2,0 +3,1 @@\n    + debug: false # 调试模式\n", diff)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := exampleDiff(filepath.Join(t.TempDir(), "missing.yaml"), ExampleYAML(cfg))
+		assert.Error(t, err)
+	})
+}
+
+func TestConfigTestHelper_AssertAllDescribed(t *testing.T) {
+	type Config struct {
+		Name   string `koanf:"name" desc:"应用名称"`
+		Server struct {
+			Addr    string `koanf:"addr" desc:"监听地址"`
+			Timeout int    `koanf:"timeout"`
+		} `koanf:"server" desc:"服务配置"`
+		Tags []string `koanf:"tags" desc:"  "`
+	}
+
+	t.Run("lists undescribed keys", func(t *testing.T) {
+		assert.Equal(t, []string{"server.timeout", "tags"}, undescribedKeys(Config{}))
+	})
+
+	t.Run("all described", func(t *testing.T) {
+		type Described struct {
+			Name   string `koanf:"name" desc:"应用名称"`
+			Server struct {
+				Addr string `koanf:"addr" desc:"监听地址"`
+			} `koanf:"server"`
+		}
+		assert.Empty(t, undescribedKeys(Described{}), "sections without desc are allowed")
+
+		var helper ConfigTestHelper[Described]
+		helper.AssertAllDescribed(t, Described{})
+	})
+
+	t.Run("fails when desc is missing", func(t *testing.T) {
+		fake := &recordingTB{TB: t}
+		var helper ConfigTestHelper[Config]
+		helper.AssertAllDescribed(fake, Config{})
+
+		require.Len(t, fake.errors, 1)
+		assert.Contains(t, fake.errors[0], "server.timeout")
+		assert.Contains(t, fake.errors[0], "tags")
+		assert.NotContains(t, fake.errors[0], "server.addr")
+	})
+}
+
+// recordingTB 记录 Errorf/Fatalf 调用而不使外层测试失败，用于验证断言辅助函数确实会报告失败。
+type recordingTB struct {
+	testing.TB
+
+	errors []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestConfigTestHelper_GenerateExamples(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name" json:"name" desc:"应用名称"`
+		Port int    `koanf:"port" json:"port" desc:"端口"`
+	}
+	cfg := Config{Name: "app", Port: 8080}
+
+	dir := t.TempDir()
+	helper := ConfigTestHelper[Config]{
+		ExamplePath:     filepath.Join(dir, "config.example.yaml"),
+		ExampleJSONPath: filepath.Join(dir, "nested", "config.example.json"),
+	}
+	helper.GenerateExamples(t, cfg)
+
+	yamlBytes, err := os.ReadFile(helper.ExamplePath)
+	require.NoError(t, err)
+	assert.Equal(t, ExampleYAML(cfg), yamlBytes)
+
+	jsonBytes, err := os.ReadFile(helper.ExampleJSONPath)
+	require.NoError(t, err)
+	assert.Equal(t, MarshalJSON(cfg), jsonBytes)
+	assert.JSONEq(t, `{"name": "app", "port": 8080}`, string(jsonBytes))
+}
+
+// =============================================================================
+// 浮点数渲染测试 (ExampleYAML)
+// =============================================================================
+
+func TestExampleYAML_FloatRendering(t *testing.T) {
+	type Config struct {
+		Rate    float64            `koanf:"rate" desc:"速率"`
+		Ratio   float32            `koanf:"ratio" desc:"比例"`
+		Weights map[string]float64 `koanf:"weights"`
+		Steps   []float64          `koanf:"steps" desc:"步长"`
+		Extra   map[string]any     `koanf:"extra"`
+	}
+
+	cfg := Config{
+		Rate:    1.0,
+		Ratio:   0.1,
+		Weights: map[string]float64{"primary": 1.0, "backup": 0.25},
+		Steps:   []float64{2, 0.5},
+		Extra:   map[string]any{"factor": 3.0, "name": "x", "none": nil},
+	}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.Contains(yaml, "rate: 1.0 # 速率")
+	a.Contains(yaml, "ratio: 0.1 # 比例", "float32 uses shortest representation")
+	a.Contains(yaml, "weights:\n  backup: 0.25\n  primary: 1.0\n", "map floats match scalar rendering, keys sorted")
+	a.Contains(yaml, "steps:\n  - 2.0\n  - 0.5\n")
+	a.Contains(yaml, "factor: 3.0")
+	a.Contains(yaml, `name: "x"`)
+	a.Contains(yaml, "none: null")
+
+	t.Run("round trip", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, yaml)
+		loaded, err := Load(Config{Weights: map[string]float64{}, Extra: map[string]any{}}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		a.Equal(cfg.Weights, loaded.Weights)
+		a.InDelta(cfg.Ratio, loaded.Ratio, 1e-7)
+	})
+}
+
+// =============================================================================
+// gzip 压缩配置文件测试
+// =============================================================================
+
+// writeTempGzipConfig 写入 gzip 压缩的临时配置文件，name 决定文件名（如 config.yaml.gz）。
+func writeTempGzipConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+
+	return path
+}
+
+func TestLoadGzipConfig(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+
+	t.Run("yaml.gz with template", func(t *testing.T) {
+		t.Setenv("GZ_TEST_PORT", "9090")
+		path := writeTempGzipConfig(t, "config.yaml.gz", "name: gz-app\nport: {{env `GZ_TEST_PORT`}}\n")
+
+		cfg, err := Load(Config{}, WithConfigPaths(path))
+		require.NoError(t, err)
+		assert.Equal(t, "gz-app", cfg.Name)
+		assert.Equal(t, 9090, cfg.Port)
+	})
+
+	t.Run("json.gz", func(t *testing.T) {
+		path := writeTempGzipConfig(t, "config.JSON.GZ", `{"name": "gz-json", "port": 1234}`)
+
+		cfg, err := Load(Config{}, WithConfigPaths(path))
+		require.NoError(t, err)
+		assert.Equal(t, "gz-json", cfg.Name)
+		assert.Equal(t, 1234, cfg.Port)
+	})
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml.gz")
+		require.NoError(t, os.WriteFile(path, []byte("name: plain"), 0600))
+
+		_, err := Load(Config{}, WithConfigPaths(path))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "decompress config file")
+	})
+}
+
+// =============================================================================
+// CLI flag 冲突检测测试
+// =============================================================================
+
+func TestCLIFlagConflict(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Addr string `koanf:"addr"`
+		} `koanf:"server"`
+	}
+	// flag 实例会记录设置状态，每个子测试需重新创建
+	flags := func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{Name: "server-addr"},
+			&cli.StringFlag{Name: "server.addr"},
+		}
+	}
+	args := []string{"test", "--server-addr", ":8080", "--server.addr", ":9090"}
+
+	t.Run("warns and uses kebab-case", func(t *testing.T) {
+		logger, records := newRecordLogger()
+		cfg := runCLITest(t, Config{}, flags(), args, WithLogger(logger))
+		assert.Equal(t, ":8080", cfg.Server.Addr)
+
+		warnings := records.find("Conflicting CLI flags, using kebab-case value")
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "server.addr", warnings[0]["key"])
+		assert.Equal(t, "server-addr", warnings[0]["used"])
+		assert.Equal(t, "server.addr", warnings[0]["ignored"])
+	})
+
+	t.Run("error under strict option", func(t *testing.T) {
+		cmd := &cli.Command{
+			Name:  "test",
+			Flags: flags(),
+			Action: func(_ context.Context, cmd *cli.Command) error {
+				_, err := Load(Config{}, WithCommand(cmd), WithStrictCLIFlags())
+
+				return err
+			},
+		}
+		err := cmd.Run(context.Background(), args)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "conflicting CLI flags --server-addr and --server.addr both set for server.addr")
+	})
+
+	t.Run("single variant is not a conflict", func(t *testing.T) {
+		logger, records := newRecordLogger()
+		cfg := runCLITest(t, Config{}, flags(), []string{"test", "--server.addr", ":9090"},
+			WithLogger(logger), WithStrictCLIFlags())
+		assert.Equal(t, ":9090", cfg.Server.Addr)
+		assert.Empty(t, records.find("Conflicting CLI flags, using kebab-case value"))
+	})
+}
+
+// =============================================================================
+// CLI flag 别名测试
+// =============================================================================
+
+func TestLoadWithCLIFlagAlias(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Addr    string        `koanf:"addr"`
+			Timeout time.Duration `koanf:"timeout"`
+		} `koanf:"server"`
+	}
+	// flag 实例会记录设置状态，每个子测试需重新创建
+	flags := func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{Name: "addr", Aliases: []string{"s"}},
+			&cli.StringFlag{Name: "server-addr"},
+			&cli.DurationFlag{Name: "wait"},
+		}
+	}
+	aliases := []Option{WithCLIFlagAlias("addr", "server.addr"), WithCLIFlagAlias("wait", "server.timeout")}
+
+	t.Run("alias flag", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), []string{"test", "--addr", ":9090", "--wait", "5s"}, aliases...)
+		assert.Equal(t, ":9090", cfg.Server.Addr)
+		assert.Equal(t, 5*time.Second, cfg.Server.Timeout)
+	})
+
+	t.Run("short alias of alias flag", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), []string{"test", "-s", ":7070"}, aliases...)
+		assert.Equal(t, ":7070", cfg.Server.Addr)
+	})
+
+	t.Run("derived flag wins", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), []string{"test", "--addr", ":9090", "--server-addr", ":8080"}, aliases...)
+		assert.Equal(t, ":8080", cfg.Server.Addr)
+	})
+
+	t.Run("unset alias keeps default", func(t *testing.T) {
+		var defaultCfg Config
+		defaultCfg.Server.Addr = ":80"
+		cfg := runCLITest(t, defaultCfg, flags(), []string{"test"}, aliases...)
+		assert.Equal(t, ":80", cfg.Server.Addr)
+	})
+}
+
+// =============================================================================
+// bool flag 取反测试
+// =============================================================================
+
+func TestLoadWithNegatedBoolFlag(t *testing.T) {
+	type Config struct {
+		Debug  bool `koanf:"debug"`
+		Server struct {
+			TLS bool `koanf:"tls"`
+		} `koanf:"server"`
+	}
+	// flag 实例会记录设置状态，每个子测试需重新创建
+	flags := func() []cli.Flag {
+		return []cli.Flag{
+			&cli.BoolFlag{Name: "debug"},
+			&cli.BoolFlag{Name: "no-debug"},
+			&cli.BoolFlag{Name: "no-server-tls"},
+		}
+	}
+	tmpFile := writeTempConfig(t, "debug: true\nserver:\n  tls: true\n")
+
+	t.Run("overrides file value", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), []string{"test", "--no-debug", "--no-server-tls"},
+			WithConfigPaths(tmpFile))
+		assert.False(t, cfg.Debug)
+		assert.False(t, cfg.Server.TLS)
+	})
+
+	t.Run("unset keeps file value", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), []string{"test"}, WithConfigPaths(tmpFile))
+		assert.True(t, cfg.Debug)
+		assert.True(t, cfg.Server.TLS)
+	})
+
+	t.Run("positive flag wins", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), []string{"test", "--debug", "--no-debug"}, WithConfigPaths(tmpFile))
+		assert.True(t, cfg.Debug)
+	})
+}
+
+// =============================================================================
+// CLI map flag 合并测试
+// =============================================================================
+
+func TestLoadWithCLIMapMerge(t *testing.T) {
+	type Config struct {
+		Labels map[string]string `koanf:"labels"`
+	}
+	flags := func() []cli.Flag {
+		return []cli.Flag{&cli.StringMapFlag{Name: "labels"}}
+	}
+	tmpFile := writeTempConfig(t, "labels:\n  a: from-file\n  env: dev\n")
+	args := []string{"test", "--labels", "b=from-cli", "--labels", "env=prod"}
+
+	t.Run("merge with file entries", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), args, WithConfigPaths(tmpFile), WithCLIMapMerge())
+		assert.Equal(t, map[string]string{"a": "from-file", "b": "from-cli", "env": "prod"}, cfg.Labels)
+	})
+
+	t.Run("merge with env entries", func(t *testing.T) {
+		t.Setenv("TEAM_LABEL", "infra")
+
+		cfg := runCLITest(t, Config{}, flags(), args,
+			WithConfigPaths(tmpFile), WithEnvBinding("TEAM_LABEL", "labels.team"), WithCLIMapMerge())
+		assert.Equal(t, map[string]string{"a": "from-file", "team": "infra", "b": "from-cli", "env": "prod"}, cfg.Labels)
+	})
+
+	t.Run("replace by default", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), args, WithConfigPaths(tmpFile))
+		assert.Equal(t, map[string]string{"b": "from-cli", "env": "prod"}, cfg.Labels)
+	})
+
+	t.Run("unset flag keeps file entries", func(t *testing.T) {
+		cfg := runCLITest(t, Config{}, flags(), []string{"test"}, WithConfigPaths(tmpFile), WithCLIMapMerge())
+		assert.Equal(t, map[string]string{"a": "from-file", "env": "dev"}, cfg.Labels)
+	})
+}
+
+// =============================================================================
+// WithProvider 测试
+// =============================================================================
+
+// failingProvider 读取时总是返回错误的 koanf provider。
+type failingProvider struct{}
+
+func (failingProvider) ReadBytes() ([]byte, error)    { return nil, errors.New("backend unavailable") }
+func (failingProvider) Read() (map[string]any, error) { return nil, errors.New("backend unavailable") }
+
+func TestLoadWithProvider(t *testing.T) {
+	type Database struct {
+		Host string `koanf:"host"`
+		Port int    `koanf:"port"`
+	}
+	type Config struct {
+		Name     string   `koanf:"name"`
+		Database Database `koanf:"database"`
+	}
+	defaultCfg := Config{Name: "default", Database: Database{Host: "localhost", Port: 5432}}
+
+	t.Run("nested values in registration order", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: from-file\ndatabase:\n  host: file-host\n")
+
+		cfg, err := Load(defaultCfg,
+			WithConfigPaths(tmpFile),
+			WithProvider(confmap.Provider(map[string]any{
+				"database": map[string]any{"host": "first-host", "port": 6432},
+			}, ""), nil),
+			WithProvider(confmap.Provider(map[string]any{"database.port": 7432}, "."), nil),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "from-file", Database: Database{Host: "first-host", Port: 7432}}, *cfg)
+	})
+
+	t.Run("env overrides provider", func(t *testing.T) {
+		t.Setenv("APP_DATABASE_HOST", "env-host")
+
+		cfg, err := Load(defaultCfg,
+			WithProvider(confmap.Provider(map[string]any{"database.host": "provider-host"}, "."), nil),
+			WithEnvPrefix("APP_"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "env-host", cfg.Database.Host)
+	})
+
+	t.Run("provider error", func(t *testing.T) {
+		_, err := Load(defaultCfg, WithProvider(failingProvider{}, nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "backend unavailable")
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		data := []byte(`{"name": "` + strings.Repeat("x", 200) + `"}`)
+
+		_, err := Load(defaultCfg, WithProvider(rawbytes.Provider(data), json.Parser()), WithMaxConfigSize(100))
+		require.ErrorIs(t, err, ErrConfigTooLarge)
+		assert.Contains(t, err.Error(), "load provider 0")
+
+		cfg, err := Load(defaultCfg, WithProvider(rawbytes.Provider(data), json.Parser()), WithMaxConfigSize(1024))
+		require.NoError(t, err)
+		assert.Len(t, cfg.Name, 200)
+	})
+}
+
+// httpProvider 通过 HTTP GET 读取配置的 [ContextProvider]。
+type httpProvider struct{ url string }
+
+func (p httpProvider) ReadBytes() ([]byte, error)  { return p.ReadBytesContext(context.Background()) }
+func (httpProvider) Read() (map[string]any, error) { return nil, errors.New("not supported") }
+
+func (p httpProvider) ReadBytesContext(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	return io.ReadAll(resp.Body)
+}
+
+func TestLoadWithSourceTimeout(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = w.Write([]byte(`{"port": 1}`))
+	}))
+	t.Cleanup(slow.Close)
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"port": 9090}`))
+	}))
+	t.Cleanup(fast.Close)
+
+	t.Run("within timeout", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: from-file\nport: 8080\n")
+
+		cfg, err := Load(Config{},
+			WithConfigPaths(tmpFile),
+			WithProvider(httpProvider{url: fast.URL}, json.Parser()),
+			WithSourceTimeout(time.Second),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "from-file", Port: 9090}, *cfg)
+	})
+
+	t.Run("slow source times out", func(t *testing.T) {
+		start := time.Now()
+		_, err := Load(Config{},
+			WithProvider(httpProvider{url: fast.URL}, json.Parser()),
+			WithProvider(httpProvider{url: slow.URL}, json.Parser()),
+			WithSourceTimeout(50*time.Millisecond),
+		)
+		require.Error(t, err)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "load provider 1 (cfgm.httpProvider): timed out after 50ms")
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("default timeout", func(t *testing.T) {
+		assert.Equal(t, 10*time.Second, newOptions(1, nil).sourceTimeout)
+	})
+}
+
+// =============================================================================
+// WithSectionFromCommand 测试
+// =============================================================================
+
+func TestLoadWithSectionFromCommand(t *testing.T) {
+	type ClientConfig struct {
+		URL     string        `koanf:"url"`
+		Timeout time.Duration `koanf:"timeout"`
+		Retries int           `koanf:"retries"`
+	}
+	defaultCfg := ClientConfig{URL: "http://default", Timeout: time.Second, Retries: 1}
+
+	// runSection 以子命令 name 运行并加载对应节的配置。
+	runSection := func(t *testing.T, name, configPath string, opts ...Option) (*ClientConfig, error) {
+		t.Helper()
+
+		var (
+			cfg     *ClientConfig
+			loadErr error
+		)
+		sub := &cli.Command{
+			Name: name,
+			Action: func(_ context.Context, cmd *cli.Command) error {
+				cfg, loadErr = Load(defaultCfg, append([]Option{WithConfigPaths(configPath), WithSectionFromCommand(cmd)}, opts...)...)
+
+				return nil
+			},
+		}
+		root := &cli.Command{Name: "app", Commands: []*cli.Command{sub}}
+		require.NoError(t, root.Run(context.Background(), []string{"app", name}))
+
+		return cfg, loadErr
+	}
+
+	configPath := writeTempConfig(t, `
+client:
+  url: http://localhost:8080
+  timeout: 30s
+server:
+  url: should-not-load
+`)
+
+	t.Run("loads client section", func(t *testing.T) {
+		cfg, err := runSection(t, "client", configPath)
+		require.NoError(t, err)
+		assert.Equal(t, ClientConfig{URL: "http://localhost:8080", Timeout: 30 * time.Second, Retries: 1}, *cfg)
+	})
+
+	t.Run("env keys are relative to section", func(t *testing.T) {
+		t.Setenv("APP_RETRIES", "5")
+
+		cfg, err := runSection(t, "client", configPath, WithEnvPrefix("APP_"))
+		require.NoError(t, err)
+		assert.Equal(t, 5, cfg.Retries)
+		assert.Equal(t, "http://localhost:8080", cfg.URL)
+	})
+
+	t.Run("missing section uses defaults", func(t *testing.T) {
+		cfg, err := runSection(t, "worker", configPath)
+		require.NoError(t, err)
+		assert.Equal(t, defaultCfg, *cfg)
+	})
+
+	t.Run("error line points into section", func(t *testing.T) {
+		badPath := writeTempConfig(t, "client:\n  url: x\n  retries: many\n")
+
+		_, err := runSection(t, "client", badPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), badPath+":3:")
+	})
+}
+
+// =============================================================================
+// 数字开头和关键字 key 测试
+// =============================================================================
+
+func TestUnusualKoanfKeys(t *testing.T) {
+	type Auth struct {
+		TwoFA bool   `koanf:"2fa"`
+		Code  string `koanf:"2fa-code"`
+	}
+	type Config struct {
+		Type      string `koanf:"type"`
+		Func      string `koanf:"func"`
+		TwoFactor bool   `koanf:"two_factor"`
+		Auth      Auth   `koanf:"auth"`
+	}
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("APP_TYPE", "t")
+		t.Setenv("APP_FUNC", "f")
+		t.Setenv("APP_TWO_FACTOR", "true")
+		t.Setenv("APP_AUTH_2FA", "yes")
+		t.Setenv("APP_AUTH_2FA_CODE", "123456")
+
+		cfg, err := Load(Config{}, WithEnvPrefix("APP_"))
+		require.NoError(t, err)
+		assert.Equal(t, Config{Type: "t", Func: "f", TwoFactor: true, Auth: Auth{TwoFA: true, Code: "123456"}}, *cfg)
+	})
+
+	t.Run("detectCLIFlag names", func(t *testing.T) {
+		tests := []struct {
+			key, args, want string
+		}{
+			{"type", "--type", "type"},
+			{"two_factor", "--two_factor", "two_factor"},
+			{"auth.2fa", "--auth-2fa", "auth-2fa"},
+			{"auth.2fa", "--auth.2fa", "auth.2fa"},
+			{"auth.2fa-code", "--auth-2fa-code", "auth-2fa-code"},
+		}
+
+		for _, tt := range tests {
+			cmd := &cli.Command{
+				Name: "test",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "type"},
+					&cli.BoolFlag{Name: "two_factor"},
+					&cli.BoolFlag{Name: "auth-2fa"},
+					&cli.BoolFlag{Name: "auth.2fa"},
+					&cli.BoolFlag{Name: "auth-2fa-code"},
+				},
+			}
+			require.NoError(t, cmd.Run(context.Background(), []string{"test", tt.args}))
+
+			cliFlag, isSet, conflict := detectCLIFlag(cmd, tt.key)
+			assert.True(t, isSet, tt.args)
+			assert.Equal(t, tt.want, cliFlag, tt.args)
+			assert.Empty(t, conflict, tt.args)
+		}
+	})
+
+	t.Run("cli flags", func(t *testing.T) {
+		flags := []cli.Flag{
+			&cli.StringFlag{Name: "type"},
+			&cli.StringFlag{Name: "func"},
+			&cli.BoolFlag{Name: "auth-2fa"},
+			&cli.StringFlag{Name: "auth.2fa-code"},
+		}
+		cfg := runCLITest(t, Config{}, flags,
+			[]string{"test", "--type", "t", "--func", "f", "--auth-2fa", "--auth.2fa-code", "654321"})
+		assert.Equal(t, Config{Type: "t", Func: "f", Auth: Auth{TwoFA: true, Code: "654321"}}, *cfg)
+	})
+}
+
+// =============================================================================
+// 结构体切片渲染测试 (ExampleYAML)
+// =============================================================================
+
+func TestExampleYAML_SliceOfStructs(t *testing.T) {
+	type RetryPolicy struct {
+		Name    string        `koanf:"name"`
+		Backoff time.Duration `koanf:"backoff"`
+	}
+	type Config struct {
+		Retries []RetryPolicy          `koanf:"retries" desc:"重试策略"`
+		ByName  map[string]RetryPolicy `koanf:"by_name"`
+	}
+
+	cfg := Config{
+		Retries: []RetryPolicy{{Name: "fast", Backoff: time.Second}, {Name: "slow", Backoff: 90 * time.Second}},
+		ByName:  map[string]RetryPolicy{"default": {Name: "default", Backoff: 500 * time.Millisecond}},
+	}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.Contains(yaml, "retries:\n  - name: \"fast\"\n    backoff: 1s\n  - name: \"slow\"\n    backoff: 1m30s\n")
+	a.Contains(yaml, "by_name:\n  default:\n    name: \"default\"\n    backoff: 500ms\n")
+
+	t.Run("round trip", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, yaml)
+		loaded, err := Load(Config{ByName: map[string]RetryPolicy{}}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		a.Equal(cfg, *loaded)
+	})
+}
+
+// =============================================================================
+// 配置大小限制测试
+// =============================================================================
+
+func TestLoadWithMaxConfigSize(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+	}
+	content := "name: sized\n" // 12 字节
+	tmpFile := writeTempConfig(t, content)
+	size := int64(len(content))
+
+	t.Run("just under limit", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithMaxConfigSize(size))
+		require.NoError(t, err)
+		assert.Equal(t, "sized", cfg.Name)
+	})
+
+	t.Run("just over limit", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigPaths(tmpFile), WithMaxConfigSize(size-1))
+		require.ErrorIs(t, err, ErrConfigTooLarge)
+		assert.Contains(t, err.Error(), tmpFile)
+	})
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.Equal(t, "sized", cfg.Name)
+	})
+
+	t.Run("decompressed gzip content", func(t *testing.T) {
+		path := writeTempGzipConfig(t, "config.yaml.gz", "name: "+strings.Repeat("x", 1024)+"\n")
+
+		_, err := Load(Config{}, WithConfigPaths(path), WithMaxConfigSize(512))
+		require.ErrorIs(t, err, ErrConfigTooLarge)
+	})
+
+	t.Run("included file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "name: !include name.yaml\n",
+			"name.yaml":   strings.Repeat("x", 100) + "\n",
+		})
+
+		_, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes(), WithMaxConfigSize(64))
+		require.ErrorIs(t, err, ErrConfigTooLarge)
+	})
+}
+
+func TestLoadWithMaxConfigAge(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+	}
+	tmpFile := writeTempConfig(t, "name: fresh\n")
+
+	t.Run("recent file", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithMaxConfigAge(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, "fresh", cfg.Name)
+	})
+
+	t.Run("old file", func(t *testing.T) {
+		old := time.Now().Add(-3 * time.Hour)
+		require.NoError(t, os.Chtimes(tmpFile, old, old))
+
+		_, err := Load(Config{}, WithConfigPaths(tmpFile), WithMaxConfigAge(time.Hour))
+		require.ErrorIs(t, err, ErrConfigTooOld)
+		assert.Contains(t, err.Error(), tmpFile)
+		assert.Contains(t, err.Error(), "max age is 1h0m0s")
+		assert.Contains(t, err.Error(), "was modified 3h0m")
+	})
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.Equal(t, "fresh", cfg.Name)
+	})
+}
+
+func TestLoadConfigPathIsDir(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+	}
+	dir := t.TempDir()
+
+	t.Run("directory path", func(t *testing.T) {
+		_, err := Load(Config{Name: "default"}, WithConfigPaths(dir))
+		require.ErrorIs(t, err, ErrConfigPathIsDir)
+		assert.Contains(t, err.Error(), dir)
+	})
+
+	t.Run("directory before existing file", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: from-file\n")
+
+		_, err := Load(Config{}, WithConfigPaths(dir, tmpFile))
+		require.ErrorIs(t, err, ErrConfigPathIsDir)
+	})
+
+	t.Run("missing path still skipped", func(t *testing.T) {
+		cfg, err := Load(Config{Name: "default"}, WithConfigPaths(filepath.Join(dir, "missing.yaml")))
+		require.NoError(t, err)
+		assert.Equal(t, "default", cfg.Name)
+	})
+
+	t.Run("included directory", func(t *testing.T) {
+		root := writeFiles(t, map[string]string{"config.yaml": "name: !include sub\n"})
+		require.NoError(t, os.Mkdir(filepath.Join(root, "sub"), 0750))
+
+		_, err := Load(Config{}, WithConfigPaths(filepath.Join(root, "config.yaml")), WithIncludes())
+		require.ErrorIs(t, err, ErrConfigPathIsDir)
+	})
+}
+
+// =============================================================================
+// 配置文件编码测试
+// =============================================================================
+
+func TestLoadWithConfigEncoding(t *testing.T) {
+	type Config struct {
+		Name  string `koanf:"name"`
+		Owner string `koanf:"owner"`
+	}
+	content := "name: 配置中心\nowner: {{env \"CFG_OWNER\" \"运维组\"}}\n"
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String(content)
+	require.NoError(t, err)
+	tmpFile := writeTempConfig(t, gbk)
+
+	t.Run("gbk file", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithConfigEncoding("GBK"))
+		require.NoError(t, err)
+		assert.Equal(t, "配置中心", cfg.Name)
+		assert.Equal(t, "运维组", cfg.Owner)
+	})
+
+	t.Run("gbk included file", func(t *testing.T) {
+		included, err := simplifiedchinese.GBK.NewEncoder().String("配置中心\n")
+		require.NoError(t, err)
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "name: !include name.yaml\n",
+			"name.yaml":   included,
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes(), WithConfigEncoding("gbk"))
+		require.NoError(t, err)
+		assert.Equal(t, "配置中心", cfg.Name)
+	})
+
+	t.Run("without encoding", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.Error(t, err, "GBK bytes are not valid UTF-8 YAML")
+	})
+
+	t.Run("unknown encoding", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigPaths(tmpFile), WithConfigEncoding("no-such-encoding"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `config encoding "no-such-encoding"`)
+	})
+}
+
+// =============================================================================
+// format 标签渲染测试 (ExampleYAML)
+// =============================================================================
+
+func TestExampleYAML_FormatYesNo(t *testing.T) {
+	type Config struct {
+		Enabled bool  `koanf:"enabled" format:"yesno" desc:"是否启用"`
+		Verbose bool  `koanf:"verbose" format:"yesno"`
+		Cache   *bool `koanf:"cache" format:"yesno"`
+		Debug   bool  `koanf:"debug"`
+	}
+	on := true
+	cfg := Config{Enabled: true, Verbose: false, Cache: &on, Debug: true}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.Contains(yaml, "enabled: yes # 是否启用")
+	a.Contains(yaml, "verbose: no\n")
+	a.Contains(yaml, "cache: yes\n")
+	a.Contains(yaml, "debug: true\n", "fields without format tag are unchanged")
+
+	t.Run("loads back", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, yaml)
+		loaded, err := Load(Config{Verbose: true}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		a.Equal(cfg, *loaded)
+	})
+}
+
+func TestExampleYAML_FormatDuration(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration  `koanf:"timeout"`
+		Interval time.Duration  `koanf:"interval" format:"seconds" desc:"检查间隔"`
+		TTL      time.Duration  `koanf:"ttl" format:"minutes"`
+		Backoff  *time.Duration `koanf:"backoff" format:"seconds"`
+		Grace    time.Duration  `koanf:"grace" format:"seconds"`
+	}
+	backoff := 1500 * time.Millisecond
+	cfg := Config{
+		Timeout:  90 * time.Second,
+		Interval: 90 * time.Second,
+		TTL:      90 * time.Second,
+		Backoff:  &backoff,
+		Grace:    2 * time.Hour,
+	}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.Contains(yaml, "timeout: 1m30s\n", "default rendering uses Duration.String")
+	a.Contains(yaml, "interval: 90s # 检查间隔")
+	a.Contains(yaml, "ttl: 1.5m\n")
+	a.Contains(yaml, "backoff: 1.5s\n")
+	a.Contains(yaml, "grace: 7200s\n")
+
+	t.Run("loads back", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, yaml)
+		loaded, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		a.Equal(cfg, *loaded)
+	})
+}
+
+func TestExampleYAML_EnvPrefix(t *testing.T) {
+	type Server struct {
+		Addr    string `koanf:"addr" desc:"监听地址"`
+		Timeout int    `koanf:"timeout"`
+	}
+	type Config struct {
+		Name   string            `koanf:"name" desc:"应用名称"`
+		Server Server            `koanf:"server" desc:"服务配置"`
+		Tags   []string          `koanf:"tags" desc:"标签"`
+		Labels map[string]string `koanf:"labels"`
+	}
+	cfg := Config{Name: "app", Server: Server{Addr: ":8080"}, Tags: []string{"a"}}
+
+	yaml := string(ExampleYAML(cfg, WithExampleEnvPrefix("APP_")))
+	lines := strings.Split(yaml, "\n")
+
+	// lineWith 返回以 prefix 开头（忽略缩进）的行
+	lineWith := func(prefix string) string {
+		for _, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), prefix) {
+				return line
+			}
+		}
+
+		return ""
+	}
+
+	a := assert.New(t)
+	a.Equal(`name: "app" # 应用名称 (env: APP_NAME)`, lineWith("name:"))
+	a.Equal(`  addr: ":8080" # 监听地址 (env: APP_SERVER_ADDR)`, lineWith("addr:"))
+	a.Equal("  timeout: 0 # (env: APP_SERVER_TIMEOUT)", lineWith("timeout:"))
+	a.Equal("# 标签 (env: APP_TAGS)", lineWith("# 标签"))
+	a.Equal("# (env: APP_LABELS)", lineWith("# (env: APP_LABELS)"))
+	a.Equal("# 服务配置", lineWith("# 服务配置"), "nested struct has no env var of its own")
+
+	t.Run("without prefix", func(t *testing.T) {
+		a.NotContains(string(ExampleYAML(cfg)), "(env:")
+	})
+
+	t.Run("section", func(t *testing.T) {
+		section, err := ExampleYAMLSection(cfg, "server", WithExampleEnvPrefix("APP_"))
+		require.NoError(t, err)
+		a.Contains(string(section), `addr: ":8080" # 监听地址 (env: APP_SERVER_ADDR)`)
+	})
+
+	t.Run("loads back", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, yaml)
+		loaded, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		a.Equal(cfg.Server, loaded.Server)
+	})
+}
+
+func TestExampleYAML_EmptyStyle(t *testing.T) {
+	type Inner struct {
+		Ports []int `koanf:"ports"`
+	}
+	type Config struct {
+		Name   string            `koanf:"name"`
+		Tags   []string          `koanf:"tags" desc:"标签"`
+		Labels map[string]string `koanf:"labels" desc:"附加标签"`
+		Inner  Inner             `koanf:"inner"`
+	}
+	cfg := Config{Name: "app", Tags: []string{}, Labels: map[string]string{}, Inner: Inner{Ports: []int{}}}
+
+	t.Run("flow by default", func(t *testing.T) {
+		yaml := string(ExampleYAML(cfg))
+		a := assert.New(t)
+		a.Contains(yaml, "tags: []\n")
+		a.Contains(yaml, "labels: {} # 附加标签\n")
+		a.Contains(yaml, "ports: []\n")
+	})
+
+	t.Run("block", func(t *testing.T) {
+		yaml := string(ExampleYAML(cfg, WithExampleEmptyStyle(EmptyBlock)))
+		a := assert.New(t)
+		a.Contains(yaml, "# 标签\ntags:\n")
+		a.Contains(yaml, "labels: # 附加标签\n")
+		a.Contains(yaml, "  ports:\n")
+		a.NotContains(yaml, "[]")
+		a.NotContains(yaml, "{}")
+	})
+
+	t.Run("omit", func(t *testing.T) {
+		yaml := string(ExampleYAML(cfg, WithExampleEmptyStyle(EmptyOmit)))
+		a := assert.New(t)
+		a.Contains(yaml, `name: "app"`)
+		a.NotContains(yaml, "tags")
+		a.NotContains(yaml, "labels")
+		a.NotContains(yaml, "ports")
+		a.Contains(yaml, "inner:", "struct emptied by omission keeps its key")
+
+		tmpFile := writeTempConfig(t, yaml)
+		loaded, err := Load(cfg, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		a.Equal(cfg, *loaded)
+	})
+
+	t.Run("non-empty collections unchanged", func(t *testing.T) {
+		full := Config{Tags: []string{"a"}, Labels: map[string]string{"k": "v"}, Inner: Inner{Ports: []int{80}}}
+		assert.Equal(t, ExampleYAML(full), ExampleYAML(full, WithExampleEmptyStyle(EmptyOmit)))
+	})
+}
+
+func TestExampleYAML_TemplateBraces(t *testing.T) {
+	type Config struct {
+		Host   string   `koanf:"host" desc:"支持模板，如 {{env \"HOST\"}}"`
+		Banner string   `koanf:"banner"`
+		Hosts  []string `koanf:"hosts"`
+	}
+	t.Setenv("X", "expanded")
+	cfg := Config{
+		Host:   `{{env "X" | default "y"}}`,
+		Banner: "{{ .Name }}",
+		Hosts:  []string{`{{env "X"}}`},
+	}
+
+	// 生成示例时不进行模板展开，默认值中的 {{ }} 原样输出
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.NotContains(yaml, "expanded")
+	a.Contains(yaml, `{{env \"X\" | default \"y\"}}`)
+	a.Contains(yaml, `{{ .Name }}`)
+	a.Contains(yaml, `# 支持模板，如 {{env "HOST"}}`)
+	a.Contains(yaml, `- '{{env "X"}}'`)
+}
+
+func TestExampleYAML_RequiredMarker(t *testing.T) {
+	type Database struct {
+		DSN  string `koanf:"dsn" desc:"数据库连接串" validate:"required"`
+		Pool int    `koanf:"pool" desc:"连接池大小" validate:"min=1"`
+	}
+	type Config struct {
+		Name     string   `koanf:"name" desc:"应用名称" validate:"min=1,required"`
+		Token    string   `koanf:"token" required:"true"`
+		Debug    bool     `koanf:"debug" desc:"调试模式" required:"false"`
+		Hosts    []string `koanf:"hosts" desc:"主机列表\n至少一个" validate:"required"`
+		Database Database `koanf:"database" desc:"数据库"`
+	}
+
+	yaml := string(ExampleYAML(Config{Hosts: []string{"a"}}))
+	a := assert.New(t)
+	a.Contains(yaml, `name: "" # 应用名称 (required)`)
+	a.Contains(yaml, `token: "" # (required)`)
+	a.Contains(yaml, `dsn: "" # 数据库连接串 (required)`)
+	a.Contains(yaml, "# 主机列表 (required)\n# 至少一个\nhosts:")
+	a.Contains(yaml, "debug: false # 调试模式\n")
+	a.Contains(yaml, "pool: 0 # 连接池大小\n")
+	a.Contains(yaml, "# 数据库\ndatabase:")
+	a.Equal(4, strings.Count(yaml, "(required)"))
+}
+
+func TestExampleYAML_OneofAllowedValues(t *testing.T) {
+	type Config struct {
+		Env   string `koanf:"env" desc:"运行环境" validate:"oneof=dev staging prod"`
+		Level string `koanf:"level" validate:"required,oneof=debug info"`
+		Mode  string `koanf:"mode" desc:"模式\n影响日志格式" validate:"oneof=text json"`
+		Name  string `koanf:"name" desc:"应用名称" validate:"min=1"`
+	}
+
+	yaml := string(ExampleYAML(Config{Env: "dev", Level: "info", Mode: "text"}))
+	a := assert.New(t)
+	a.Contains(yaml, `env: "dev" # 运行环境 (allowed: dev, staging, prod)`+"\n")
+	a.Contains(yaml, `level: "info" # (required) (allowed: debug, info)`+"\n")
+	a.Contains(yaml, "# 模式 (allowed: text, json)\n# 影响日志格式\nmode: \"text\"")
+	a.Contains(yaml, `name: "" # 应用名称`+"\n")
+	a.Equal(3, strings.Count(yaml, "(allowed:"))
+}
+
+func TestExampleYAML_SensitivePlaceholder(t *testing.T) {
+	type Database struct {
+		Host     string  `koanf:"host" desc:"主机"`
+		Password string  `koanf:"password" desc:"数据库密码" sensitive:"true"`
+		Token    *string `koanf:"token" sensitive:"true"`
+		PIN      int     `koanf:"pin" sensitive:"true"`
+	}
+	type Config struct {
+		Name     string   `koanf:"name" desc:"应用名称"`
+		Database Database `koanf:"database"`
+	}
+
+	token := "real-token"
+	cfg := Config{Name: "app", Database: Database{Host: "db", Password: "s3cret", Token: &token, PIN: 1234}}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.Contains(yaml, `name: "app" # 应用名称`)
+	a.Contains(yaml, `host: "db" # 主机`)
+	a.Contains(yaml, `password: "<your-secret-here>" # 数据库密码`)
+	a.Contains(yaml, `token: "<your-secret-here>"`)
+	a.Contains(yaml, "pin: 0\n")
+	a.NotContains(yaml, "s3cret")
+	a.NotContains(yaml, "real-token")
+	a.NotContains(yaml, "1234")
+}
+
+func TestExampleYAML_InterfaceField(t *testing.T) {
+	type Config struct {
+		Name   string       `koanf:"name"`
+		Extra  any          `koanf:"extra" desc:"附加配置"`
+		Plugin fmt.Stringer `koanf:"plugin" desc:"插件"`
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"nil", Config{}, "extra: null # 附加配置\nplugin: null # 插件\n"},
+		{"scalar", Config{Extra: "s", Plugin: time.Second}, "extra: \"s\" # 附加配置\nplugin: 1s # 插件\n"},
+		{"map", Config{Extra: map[string]any{"k": 1}}, "\n# 附加配置\nextra:\n  k: 1\nplugin: null # 插件\n"},
+		{"slice", Config{Extra: []any{1, "x"}}, "\n# 附加配置\nextra:\n  - 1\n  - x\nplugin: null # 插件\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var yaml string
+			require.NotPanics(t, func() {
+				yaml = string(ExampleYAML(tt.cfg))
+				_ = FlattenConfig(tt.cfg)
+				_ = FieldMetadata(tt.cfg)
+				_ = MarkdownDocs(tt.cfg)
+				_ = Redacted(tt.cfg)
+				_ = DiffConfig(tt.cfg, Config{})
+				_, _ = JSONSchema(tt.cfg)
+			})
+			assert.Contains(t, yaml, tt.want)
+		})
+	}
+}
+
+func TestExampleYAML_ZeroTime(t *testing.T) {
+	type Config struct {
+		Name      string     `koanf:"name"`
+		StartedAt time.Time  `koanf:"started_at" desc:"启动时间"`
+		ExpiresAt *time.Time `koanf:"expires_at"`
+		CreatedAt time.Time  `koanf:"created_at"`
+	}
+	zero := time.Time{}
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := Config{Name: "app", ExpiresAt: &zero, CreatedAt: created}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.NotContains(yaml, "0001-01-01")
+	a.Contains(yaml, `started_at: "" # 启动时间`)
+	a.Contains(yaml, `expires_at: ""`)
+	a.Contains(yaml, "created_at: 2025-01-02T03:04:05Z")
+
+	t.Run("loads back as zero", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, yaml)
+		loaded, err := Load(Config{StartedAt: created}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		a.True(loaded.StartedAt.IsZero())
+		require.NotNil(t, loaded.ExpiresAt)
+		a.True(loaded.ExpiresAt.IsZero())
+		a.True(created.Equal(loaded.CreatedAt))
+	})
+}
+
+func TestExampleYAML_MapOfStructs(t *testing.T) {
+	type ServerConfig struct {
+		Addr    string        `koanf:"addr" desc:"监听地址"`
+		Timeout time.Duration `koanf:"timeout" desc:"超时时间"`
+	}
+	type Config struct {
+		Servers map[string]ServerConfig  `koanf:"servers" desc:"服务列表"`
+		Backups map[string]*ServerConfig `koanf:"backups" desc:"备用服务"`
+		Empty   map[string]ServerConfig  `koanf:"empty" desc:"空映射"`
+		Name    string                   `koanf:"name" desc:"名称"`
+	}
+	cfg := Config{
+		Servers: map[string]ServerConfig{
+			"api":   {Addr: ":8080", Timeout: 30 * time.Second},
+			"admin": {Addr: ":9090"},
+		},
+		Backups: map[string]*ServerConfig{"dr": {Addr: ":7070"}},
+		Empty:   map[string]ServerConfig{},
+	}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.Contains(yaml, "# 服务列表\nservers:\n  admin:\n    addr: \":9090\" # 监听地址\n")
+	a.Contains(yaml, "  api:\n    addr: \":8080\" # 监听地址\n")
+	a.Contains(yaml, "    timeout: 30s # 超时时间\n")
+	a.Contains(yaml, "    timeout: 0s # 超时时间\n")
+	a.Contains(yaml, "# 备用服务\nbackups:\n  dr:\n    addr: \":7070\" # 监听地址\n")
+	a.Contains(yaml, "empty: {} # 空映射\n")
+	a.Equal(3, strings.Count(yaml, "# 监听地址"), "each map entry carries the inner field comments")
+
+	t.Run("loads back", func(t *testing.T) {
+		loaded, err := Load(Config{}, WithConfigPaths(writeTempConfig(t, yaml)))
+		require.NoError(t, err)
+		a.Equal(cfg.Servers, loaded.Servers)
+		a.Equal(":7070", loaded.Backups["dr"].Addr)
+	})
+}
+
+func TestExampleYAML_MultilineString(t *testing.T) {
+	type Config struct {
+		Cert    string            `koanf:"cert" desc:"TLS 证书"`
+		Query   string            `koanf:"query" desc:"查询语句"`
+		Name    string            `koanf:"name"`
+		Scripts []string          `koanf:"scripts"`
+		Extra   map[string]string `koanf:"extra"`
+	}
+	cfg := Config{
+		Cert:    "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		Query:   "SELECT id\nFROM users",
+		Name:    "app",
+		Scripts: []string{"echo a\necho b", "echo c"},
+		Extra:   map[string]string{"banner": "  indented\nline"},
+	}
+
+	yaml := string(ExampleYAML(cfg))
+	a := assert.New(t)
+	a.Contains(yaml, "cert: | # TLS 证书\n  -----BEGIN CERTIFICATE-----\n  MIIB\n  -----END CERTIFICATE-----\n")
+	a.Contains(yaml, "query: |- # 查询语句\n  SELECT id\n  FROM users\n")
+	a.Contains(yaml, `name: "app"`)
+	a.Contains(yaml, "  - |-\n    echo a\n    echo b\n  - echo c\n")
+	a.NotContains(yaml, `\n`, "no escaped newlines")
+
+	t.Run("loads back identically", func(t *testing.T) {
+		loaded, err := Load(Config{}, WithConfigPaths(writeTempConfig(t, yaml)))
+		require.NoError(t, err)
+		a.Equal(cfg, *loaded)
+	})
+}
+
+func TestExampleOutput_TrailingNewline(t *testing.T) {
+	type Empty struct{}
+	type Config struct {
+		Name   string            `koanf:"name" desc:"名称"`
+		Labels map[string]string `koanf:"labels" desc:"标签"`
+		Footer string            `koanf:"footer" desc:"以空行结尾的文本"`
+	}
+	cfg := Config{
+		Name:   "app",
+		Labels: map[string]string{"env": "prod"},
+		Footer: "line1\nline2\n\n",
+	}
+
+	outputs := map[string][]byte{
+		"ExampleYAML":       ExampleYAML(cfg),
+		"ExampleYAML empty": ExampleYAML(Empty{}),
+		"MarshalJSON":       MarshalJSON(cfg),
+		"MarshalJSON empty": MarshalJSON(Empty{}),
+	}
+	section, err := ExampleYAMLSection(cfg, "labels")
+	require.NoError(t, err)
+	outputs["ExampleYAMLSection"] = section
+
+	for name, out := range outputs {
+		assert.True(t, strings.HasSuffix(string(out), "\n"), "%s should end with a newline", name)
+		assert.False(t, strings.HasSuffix(string(out), "\n\n"), "%s should not end with two newlines", name)
+	}
+
+	t.Run("trailing blank lines survive round trip", func(t *testing.T) {
+		loaded, err := Load(Config{}, WithConfigPaths(writeTempConfig(t, string(ExampleYAML(cfg)))))
+		require.NoError(t, err)
+		assert.Equal(t, cfg.Footer, loaded.Footer)
+	})
+}
+
+func TestExampleYAML_SortKeys(t *testing.T) {
+	type Server struct {
+		Port int    `koanf:"port" desc:"端口"`
+		Addr string `koanf:"addr" desc:"地址"`
+		TLS  struct {
+			Key  string `koanf:"key"`
+			Cert string `koanf:"cert"`
+		} `koanf:"tls" desc:"TLS 配置"`
+	}
+	type Backend struct {
+		Weight int    `koanf:"weight"`
+		Host   string `koanf:"host"`
+	}
+	type Config struct {
+		Zone     string    `koanf:"zone" desc:"区域"`
+		Server   Server    `koanf:"server" desc:"服务配置"`
+		Backends []Backend `koanf:"backends"`
+		App      string    `koanf:"app"`
+	}
+	cfg := Config{Zone: "cn", App: "demo", Backends: []Backend{{Weight: 1, Host: "db"}}}
+	cfg.Server.Port = 8080
+
+	yaml := string(ExampleYAML(cfg, WithExampleSortKeys()))
+
+	a := assert.New(t)
+	order := func(keys ...string) {
+		t.Helper()
+		last := -1
+		for _, key := range keys {
+			idx := strings.Index(yaml, key)
+			a.Greater(idx, last, "%q out of order", key)
+			last = idx
+		}
+	}
+	order("app:", "backends:", "server:", "zone:")
+	order("server:", "  addr:", "  port:", "  tls:", "    cert:", "    key:")
+	order("  - host:", "    weight:")
+	a.Contains(yaml, "  port: 8080 # 端口\n", "comments move with their fields")
+	a.Contains(yaml, "zone: \"cn\" # 区域\n")
+
+	t.Run("declaration order by default", func(t *testing.T) {
+		plain := string(ExampleYAML(cfg))
+		a.Less(strings.Index(plain, "zone:"), strings.Index(plain, "app:"))
+	})
+
+	t.Run("loads back", func(t *testing.T) {
+		loaded, err := Load(Config{}, WithConfigPaths(writeTempConfig(t, yaml)))
+		require.NoError(t, err)
+		a.Equal(cfg, *loaded)
+	})
+}
+
+func TestExampleYAML_DeeplyNestedComments(t *testing.T) {
+	type Pool struct {
+		Size int `koanf:"size" desc:"连接池大小"`
+	}
+	type Database struct {
+		Host string `koanf:"host" desc:"数据库地址"`
+		Pool Pool   `koanf:"pool" desc:"连接池配置"`
+	}
+	type Storage struct {
+		Database Database `koanf:"database" desc:"数据库配置"`
+		Path     string   `koanf:"path" desc:"数据目录"`
+	}
+	type Config struct {
+		Storage Storage `koanf:"storage" desc:"存储配置"`
+		Name    string  `koanf:"name" desc:"应用名称"`
+	}
+	cfg := Config{Name: "app"}
+	cfg.Storage.Database.Pool.Size = 10
+
+	yaml := string(ExampleYAML(cfg))
+
+	assert.Equal(t, `# 配置示例文件, 复制此文件为 config.yaml 并根据需要修改
+
+# 存储配置
+storage:
+  # 数据库配置
+  database:
+    host: "" # 数据库地址
+
+    # 连接池配置
+    pool:
+      size: 10 # 连接池大小
+  path: "" # 数据目录
+name: "app" # 应用名称
+`, yaml)
+
+	for line := range strings.SplitSeq(yaml, "\n") {
+		assert.Equal(t, strings.TrimRight(line, " "), line, "no trailing whitespace")
+	}
+}
@@ -0,0 +1,253 @@
+package cfgm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/knadh/koanf/v2"
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// unmarshalConfig 将 koanf 中 path 下的配置解码到 out。
+//
+// 在 koanf 默认解码行为（弱类型转换、time.Duration、encoding.TextUnmarshaler）
+// 的基础上增加了宽松的 bool 解析，见 [stringToBoolHookFunc]；
+// 带千位分隔符的时长（如 1,000ms）的明确错误，见 [stringToDurationHookFunc]；
+// map[string]any 和 json.RawMessage 字段的内联 JSON 解析，见 [inlineJSONHookFunc]；
+// 以及 time.Time 字段的空字符串解析为零值，见 [emptyStringToZeroTimeHookFunc]。
+//
+// 弱类型转换使字符串形式的标量（如 JSON 中的 "8080"、"true"、"0.5"）可解码到数值和 bool 字段，
+// 常见于模板展开后所有值均为字符串的 JSON 配置。
+//
+// hooks 为调用方通过 [WithDecodeHook] 注册的钩子，在内置钩子之前按注册顺序执行。
+func unmarshalConfig(k *koanf.Koanf, path string, out any, hooks ...mapstructure.DecodeHookFunc) error {
+	hooks = append(slices.Clip(hooks),
+		stringToDurationHookFunc(),
+		stringToBoolHookFunc(),
+		emptyStringToZeroTimeHookFunc(),
+		inlineJSONHookFunc(),
+		mapstructure.TextUnmarshallerHookFunc(),
+	)
+
+	return k.UnmarshalWithConf(path, out, koanf.UnmarshalConf{
+		DecoderConfig: &mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.ComposeDecodeHookFunc(hooks...),
+			WeaklyTypedInput: true,
+		},
+	})
+}
+
+// stringToDurationHookFunc 将字符串解析为 time.Duration，规则见 [parseDuration]。
+func stringToDurationHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeFor[time.Duration]() {
+			return data, nil
+		}
+
+		s, _ := data.(string)
+
+		return parseDuration(s)
+	}
+}
+
+// errDurationCommaGrouping 表示时长字符串包含千位分隔符，见 [parseDuration]。
+var errDurationCommaGrouping = errors.New("remove the comma grouping")
+
+// parseDuration 按 time.ParseDuration 解析时长。
+//
+// 包含逗号（如 1,000ms）时返回提示去掉千位分隔符的错误，而非 time.ParseDuration 难以理解的 unknown unit 错误。
+func parseDuration(s string) (time.Duration, error) {
+	if strings.Contains(s, ",") {
+		return 0, fmt.Errorf("invalid duration %q: %w (use %q)", s, errDurationCommaGrouping, strings.ReplaceAll(s, ",", ""))
+	}
+
+	return time.ParseDuration(s)
+}
+
+// stringToBoolHookFunc 将字符串解析为 bool，兼容非开发人员常用的写法。
+//
+// 支持（不区分大小写）：true/false、yes/no、on/off、1/0，空字符串视为 false，其他值返回错误。
+func stringToBoolHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String || t.Kind() != reflect.Bool {
+			return data, nil
+		}
+
+		s, _ := data.(string)
+
+		return parseBool(s)
+	}
+}
+
+// emptyStringToZeroTimeHookFunc 将空字符串（含仅空白）解码为 time.Time 零值。
+//
+// 与 [ExampleYAML] 将零值 time.Time 渲染为 "" 对应，其他字符串交由 encoding.TextUnmarshaler 按 RFC3339 解析。
+func emptyStringToZeroTimeHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeFor[time.Time]() {
+			return data, nil
+		}
+		if s, _ := data.(string); strings.TrimSpace(s) != "" {
+			return data, nil
+		}
+
+		return time.Time{}, nil
+	}
+}
+
+// inlineJSONHookFunc 将字符串值按 JSON 解析，用于在 YAML 中以字符串存放 JSON 的场景：
+//
+//	metadata: '{"a": 1}'
+//
+// 目标为 map[string]any 时解析为 map，空字符串视为 nil；
+// 目标为 json.RawMessage 时校验后保留原始 JSON，结构化的值（如 YAML 映射）编码为 JSON。
+func inlineJSONHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		switch t {
+		case reflect.TypeFor[map[string]any]():
+			s, ok := data.(string)
+			if !ok {
+				return data, nil
+			}
+			if strings.TrimSpace(s) == "" {
+				return map[string]any(nil), nil
+			}
+
+			var m map[string]any
+			if err := json.Unmarshal([]byte(s), &m); err != nil {
+				return nil, fmt.Errorf("cannot parse %q as JSON object: %w", s, err)
+			}
+
+			return m, nil
+		case reflect.TypeFor[json.RawMessage]():
+			// 默认值中的 json.RawMessage/[]byte 原样保留
+			if f.Kind() == reflect.Slice && f.Elem().Kind() == reflect.Uint8 {
+				return data, nil
+			}
+			if f.Kind() != reflect.String {
+				raw, err := json.Marshal(data)
+				if err != nil {
+					return nil, fmt.Errorf("cannot encode %T as JSON: %w", data, err)
+				}
+
+				return json.RawMessage(raw), nil
+			}
+
+			s, _ := data.(string)
+			if !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("cannot parse %q as JSON", s)
+			}
+
+			return json.RawMessage(s), nil
+		default:
+			return data, nil
+		}
+	}
+}
+
+// parseBool 按 [stringToBoolHookFunc] 的规则解析 bool 字符串。
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	// 兼容 strconv.ParseBool 的其他写法（如 t/f），与 koanf 默认行为保持一致
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b, nil
+	}
+
+	return false, fmt.Errorf("cannot parse %q as bool (expected true/false, yes/no, on/off, 1/0)", s)
+}
+
+// decodeErrorKeys 返回解码错误涉及的配置 key（如 server.port、servers[0].host）。
+func decodeErrorKeys(err error) []string {
+	var keys []string
+	var walk func(err error)
+	walk = func(err error) {
+		var decodeErr *mapstructure.DecodeError
+		if errors.As(err, &decodeErr) {
+			keys = append(keys, decodeErr.Name())
+
+			return
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				walk(e)
+			}
+		}
+	}
+	walk(err)
+
+	return keys
+}
+
+// locateKeyLine 在 YAML/JSON 内容中查找配置 key 所在的行号，未找到时返回 0。
+//
+// key 使用点号分隔，切片元素使用 [i] 索引（如 servers[0].host）。
+func locateKeyLine(content []byte, key string) int {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+
+	node := doc.Content[0]
+	line := 0
+	for segment := range strings.SplitSeq(key, ".") {
+		name, indexes := splitKeyIndexes(segment)
+
+		if node.Kind != yamlv3.MappingNode {
+			return 0
+		}
+		var found *yamlv3.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				line = node.Content[i].Line
+				found = node.Content[i+1]
+
+				break
+			}
+		}
+		if found == nil {
+			return 0
+		}
+		node = found
+
+		for _, idx := range indexes {
+			if node.Kind != yamlv3.SequenceNode || idx >= len(node.Content) {
+				return 0
+			}
+			node = node.Content[idx]
+			line = node.Line
+		}
+	}
+
+	return line
+}
+
+// splitKeyIndexes 拆分 "servers[0][1]" 形式的 key 片段为名称和索引列表。
+func splitKeyIndexes(segment string) (string, []int) {
+	name, rest, ok := strings.Cut(segment, "[")
+	if !ok {
+		return segment, nil
+	}
+
+	var indexes []int
+	for part := range strings.SplitSeq(strings.TrimSuffix(rest, "]"), "][") {
+		idx, err := strconv.Atoi(part)
+		if err != nil {
+			return name, nil
+		}
+		indexes = append(indexes, idx)
+	}
+
+	return name, indexes
+}
@@ -0,0 +1,481 @@
+package cfgm
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+// =============================================================================
+// 时长解析测试
+// =============================================================================
+
+func TestLoadDurationCommaGrouping(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration  `koanf:"timeout"`
+		Retry   *time.Duration `koanf:"retry"`
+	}
+
+	t.Run("plain value", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(writeTempConfig(t, "timeout: 1000ms\n")))
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, cfg.Timeout)
+	})
+
+	t.Run("comma grouping from file", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigPaths(writeTempConfig(t, "timeout: 1,000ms\n")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid duration "1,000ms": remove the comma grouping (use "1000ms")`)
+		assert.NotContains(t, err.Error(), "unknown unit")
+	})
+
+	t.Run("comma grouping in pointer field", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigPaths(writeTempConfig(t, "retry: 2,500ms\n")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `use "2500ms"`)
+	})
+
+	t.Run("comma grouping from env", func(t *testing.T) {
+		t.Setenv("APP_TIMEOUT", "1,000ms")
+
+		_, err := Load(Config{}, WithEnvPrefix("APP_"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "remove the comma grouping")
+	})
+}
+
+// =============================================================================
+// bool 解析测试
+// =============================================================================
+
+func TestLoadBoolWords(t *testing.T) {
+	type Config struct {
+		Debug   bool  `koanf:"debug"`
+		Verbose bool  `koanf:"verbose"`
+		Cache   *bool `koanf:"cache"`
+	}
+	defaultCfg := Config{Debug: false, Verbose: true}
+
+	t.Run("yes and off from file", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `
+debug: "yes"
+verbose: "off"
+cache: "ON"
+`)
+		cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+
+		a := assert.New(t)
+		a.True(cfg.Debug)
+		a.False(cfg.Verbose)
+		require.NotNil(t, cfg.Cache)
+		a.True(*cfg.Cache)
+	})
+
+	t.Run("unquoted yaml words", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `
+debug: yes
+verbose: off
+`)
+		cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.True(t, cfg.Debug)
+		assert.False(t, cfg.Verbose)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("APP_DEBUG", "Yes")
+		t.Setenv("APP_VERBOSE", "no")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefix("APP_"))
+		require.NoError(t, err)
+		assert.True(t, cfg.Debug)
+		assert.False(t, cfg.Verbose)
+	})
+
+	t.Run("unparseable value", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `debug: maybe`)
+
+		_, err := Load(defaultCfg, WithConfigPaths(tmpFile))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"maybe"`)
+	})
+}
+
+func TestStringToBoolHookFunc(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"true", true}, {"FALSE", false},
+		{"yes", true}, {"No", false},
+		{"on", true}, {"OFF", false},
+		{"1", true}, {"0", false},
+		{" yes ", true}, {"", false},
+	}
+
+	for _, tt := range tests {
+		var out struct {
+			V bool `koanf:"v"`
+		}
+		k := newKoanfWith(t, map[string]any{"v": tt.input})
+		require.NoError(t, unmarshalConfig(k, "", &out), "input %q", tt.input)
+		assert.Equal(t, tt.want, out.V, "input %q", tt.input)
+	}
+}
+
+// =============================================================================
+// 解码错误行号测试
+// =============================================================================
+
+func TestLoadUnmarshalErrorLine(t *testing.T) {
+	type Server struct {
+		Host string `koanf:"host"`
+		Port int    `koanf:"port"`
+	}
+	type Config struct {
+		Name    string   `koanf:"name"`
+		Server  Server   `koanf:"server"`
+		Servers []Server `koanf:"servers"`
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `name: app
+server:
+  host: localhost
+  port: not-a-number
+`)
+		_, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tmpFile+":4:")
+		assert.Contains(t, err.Error(), "server.port")
+	})
+
+	t.Run("yaml slice element", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `servers:
+  - host: a
+    port: 1
+  - host: b
+    port: oops
+`)
+		_, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tmpFile+":5:")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		tmpFile := writeTempJSONConfig(t, `{
+  "name": "app",
+  "server": {
+    "port": "abc"
+  }
+}`)
+		_, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tmpFile+":4:")
+	})
+
+	t.Run("line from raw file before template expansion", func(t *testing.T) {
+		// 模板展开为多行，展开后 server.port 位于第 6 行，原始文件中位于第 3 行
+		tmpFile := writeTempConfig(t, `name: '{{ "a\n\n\nb" }}'
+server:
+  port: oops
+`)
+		_, err := Load(Config{}, WithConfigPaths(tmpFile))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tmpFile+":3:")
+	})
+}
+
+func TestLocateKeyLine(t *testing.T) {
+	content := []byte("a:\n  b: 1\n  list:\n    - x: 1\n    - x: 2\n")
+
+	assert.Equal(t, 2, locateKeyLine(content, "a.b"))
+	assert.Equal(t, 5, locateKeyLine(content, "a.list[1].x"))
+	assert.Equal(t, 0, locateKeyLine(content, "a.missing"))
+	assert.Equal(t, 0, locateKeyLine(content, "a.list[5].x"))
+}
+
+// =============================================================================
+// 内联 JSON 解析测试
+// =============================================================================
+
+func TestLoadInlineJSON(t *testing.T) {
+	type Config struct {
+		Metadata map[string]any  `koanf:"metadata"`
+		Raw      json.RawMessage `koanf:"raw"`
+	}
+
+	t.Run("json strings in yaml", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `
+metadata: '{"a": 1, "tags": ["x", "y"], "nested": {"b": true}}'
+raw: '{"k": "v"}'
+`)
+		cfg, err := Load(Config{Metadata: map[string]any{}}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]any{
+			"a":      float64(1),
+			"tags":   []any{"x", "y"},
+			"nested": map[string]any{"b": true},
+		}, cfg.Metadata)
+		assert.JSONEq(t, `{"k": "v"}`, string(cfg.Raw))
+	})
+
+	t.Run("structured yaml values", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `
+metadata:
+  a: 1
+raw:
+  k: v
+`)
+		cfg, err := Load(Config{Metadata: map[string]any{}}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": 1}, cfg.Metadata)
+		assert.JSONEq(t, `{"k": "v"}`, string(cfg.Raw))
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("APP_METADATA", `{"env": "prod"}`)
+
+		cfg, err := Load(Config{Raw: json.RawMessage(`{"d": 1}`)}, WithEnvPrefix("APP_"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"env": "prod"}, cfg.Metadata)
+		assert.JSONEq(t, `{"d": 1}`, string(cfg.Raw))
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, `metadata: '{"a": '`)
+
+		_, err := Load(Config{Metadata: map[string]any{}}, WithConfigPaths(tmpFile))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "as JSON object")
+	})
+}
+
+// =============================================================================
+// 空切片/map 默认值测试
+// =============================================================================
+
+func TestLoadPreservesEmptyDefaults(t *testing.T) {
+	type Inner struct {
+		Name  string         `koanf:"name"`
+		Ports []int          `koanf:"ports"`
+		Extra map[string]int `koanf:"extra"`
+	}
+	type Config struct {
+		Name    string            `koanf:"name"`
+		Tags    []string          `koanf:"tags"`
+		Labels  map[string]string `koanf:"labels"`
+		NilTags []string          `koanf:"nil_tags"`
+		Inner   Inner             `koanf:"inner"`
+	}
+	defaultCfg := Config{
+		Tags:   []string{},
+		Labels: map[string]string{},
+		Inner:  Inner{Ports: []int{}, Extra: map[string]int{}},
+	}
+
+	// assertEmptyDefaults 断言未被覆盖的字段保留了默认值的 nil/空 状态。
+	assertEmptyDefaults := func(t *testing.T, cfg *Config) {
+		t.Helper()
+		a := assert.New(t)
+		a.NotNil(cfg.Tags)
+		a.Empty(cfg.Tags)
+		a.NotNil(cfg.Labels)
+		a.Empty(cfg.Labels)
+		a.Nil(cfg.NilTags)
+		a.NotNil(cfg.Inner.Ports)
+		a.Empty(cfg.Inner.Ports)
+		a.NotNil(cfg.Inner.Extra)
+		a.Empty(cfg.Inner.Extra)
+	}
+
+	t.Run("partial yaml override", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: app\ninner:\n  name: inner\n")
+
+		cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.Equal(t, "inner", cfg.Inner.Name)
+		assertEmptyDefaults(t, cfg)
+	})
+
+	t.Run("partial json override", func(t *testing.T) {
+		tmpFile := writeTempJSONConfig(t, `{"inner": {"name": "inner"}}`)
+
+		cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assertEmptyDefaults(t, cfg)
+	})
+
+	t.Run("env override of sibling", func(t *testing.T) {
+		t.Setenv("APP_INNER_NAME", "env")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefix("APP_"))
+		require.NoError(t, err)
+		assert.Equal(t, "env", cfg.Inner.Name)
+		assertEmptyDefaults(t, cfg)
+	})
+
+	t.Run("explicit empty list in file", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "nil_tags: []\n")
+
+		cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.NotNil(t, cfg.NilTags)
+		assert.Empty(t, cfg.NilTags)
+	})
+}
+
+// =============================================================================
+// 自定义解码钩子测试
+// =============================================================================
+
+// level 测试用的自定义类型，从 "low"/"high" 字符串解码。
+type level int
+
+const (
+	levelLow level = iota + 1
+	levelHigh
+)
+
+// levelHook 将字符串解码为 level。
+func levelHook(f, t reflect.Type, data any) (any, error) {
+	if f.Kind() != reflect.String || t != reflect.TypeFor[level]() {
+		return data, nil
+	}
+
+	switch data.(string) {
+	case "low":
+		return levelLow, nil
+	case "high":
+		return levelHigh, nil
+	default:
+		return nil, fmt.Errorf("unknown level %q", data)
+	}
+}
+
+func TestLoadWithDecodeHook(t *testing.T) {
+	type Config struct {
+		Level   level         `koanf:"level"`
+		Timeout time.Duration `koanf:"timeout"`
+		Debug   bool          `koanf:"debug"`
+	}
+
+	t.Run("custom type from file and env", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "level: low\ntimeout: 5s\ndebug: yes\n")
+		t.Setenv("APP_LEVEL", "high")
+
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithEnvPrefix("APP_"), WithDecodeHook(levelHook))
+		require.NoError(t, err)
+		assert.Equal(t, Config{Level: levelHigh, Timeout: 5 * time.Second, Debug: true}, *cfg, "built-in hooks still apply")
+	})
+
+	t.Run("custom type from cli string flag", func(t *testing.T) {
+		flags := []cli.Flag{&cli.StringFlag{Name: "level"}}
+
+		cfg := runCLITest(t, Config{}, flags, []string{"test", "--level", "high"}, WithDecodeHook(levelHook))
+		assert.Equal(t, levelHigh, cfg.Level)
+	})
+
+	t.Run("hook error", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "level: medium\n")
+
+		_, err := Load(Config{}, WithConfigPaths(tmpFile), WithDecodeHook(levelHook))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown level "medium"`)
+	})
+
+	t.Run("runs before built-in hooks", func(t *testing.T) {
+		// 以秒为单位的整数字符串解析为 time.Duration，覆盖内置的 time.ParseDuration
+		seconds := func(f, t reflect.Type, data any) (any, error) {
+			if f.Kind() != reflect.String || t != reflect.TypeFor[time.Duration]() {
+				return data, nil
+			}
+			n, err := strconv.Atoi(data.(string))
+			if err != nil {
+				return data, nil //nolint:nilerr // 交给内置钩子处理
+			}
+
+			return time.Duration(n) * time.Second, nil
+		}
+		tmpFile := writeTempConfig(t, "timeout: \"30\"\n")
+
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithDecodeHook(seconds))
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, cfg.Timeout)
+	})
+
+	t.Run("load list", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "- level: high\n- level: low\n")
+
+		items, err := LoadList[Config](WithConfigPaths(tmpFile), WithDecodeHook(levelHook))
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, levelHigh, items[0].Level)
+		assert.Equal(t, levelLow, items[1].Level)
+	})
+}
+
+// =============================================================================
+// 字符串形式的标量测试
+// =============================================================================
+
+func TestLoadQuotedJSONScalars(t *testing.T) {
+	type Config struct {
+		Debug   bool          `koanf:"debug"`
+		Port    int           `koanf:"port"`
+		Ratio   float64       `koanf:"ratio"`
+		Timeout time.Duration `koanf:"timeout"`
+		Retries *uint8        `koanf:"retries"`
+		Ports   []int         `koanf:"ports"`
+	}
+
+	t.Run("all strings", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.json": `{"debug": "true", "port": "8080", "ratio": "0.5", "timeout": "15s", "retries": "3", "ports": ["80", "443"]}`,
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.json")))
+		require.NoError(t, err)
+
+		a := assert.New(t)
+		a.True(cfg.Debug)
+		a.Equal(8080, cfg.Port)
+		a.InDelta(0.5, cfg.Ratio, 0)
+		a.Equal(15*time.Second, cfg.Timeout)
+		require.NotNil(t, cfg.Retries)
+		a.Equal(uint8(3), *cfg.Retries)
+		a.Equal([]int{80, 443}, cfg.Ports)
+	})
+
+	t.Run("templated json", func(t *testing.T) {
+		t.Setenv("QUOTED_PORT", "9090")
+		t.Setenv("QUOTED_DEBUG", "on")
+		dir := writeFiles(t, map[string]string{
+			"config.json": `{"debug": "{{env "QUOTED_DEBUG"}}", "port": "{{env "QUOTED_PORT"}}", "timeout": "{{env "QUOTED_TIMEOUT" | default "30s"}}"}`,
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.json")))
+		require.NoError(t, err)
+		assert.True(t, cfg.Debug)
+		assert.Equal(t, 9090, cfg.Port)
+		assert.Equal(t, 30*time.Second, cfg.Timeout)
+	})
+
+	t.Run("invalid number", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"config.json": `{"port": "http"}`})
+
+		_, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.json")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "port")
+	})
+}
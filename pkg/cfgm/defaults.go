@@ -0,0 +1,76 @@
+package cfgm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// applyDefaultFuncs 为 defaultFunc 标签标记的零值字段调用默认值方法。
+//
+// 标签值为方法名，方法定义在包含该字段的结构体类型上（值或指针接收者均可），
+// 必须无参数且仅返回一个可赋值给字段的值：
+//
+//	type Config struct {
+//	    Addr string `koanf:"addr" defaultFunc:"DefaultAddr"`
+//	}
+//
+//	func (c Config) DefaultAddr() string { return hostname() + ":8080" }
+//
+// 在所有配置源合并并解析后执行，仅填充仍为零值的字段。方法不存在或签名不匹配时返回错误。
+func applyDefaultFuncs(val reflect.Value, prefix string) error {
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := field.Tag.Get("koanf")
+		if key == "" {
+			key = field.Name
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if name := field.Tag.Get("defaultFunc"); name != "" && fieldVal.IsZero() {
+			if err := callDefaultFunc(val, fieldVal, name); err != nil {
+				return fmt.Errorf("defaultFunc %q for %s: %w", name, key, err)
+			}
+		}
+
+		switch {
+		case isNestedStruct(field.Type):
+			if err := applyDefaultFuncs(fieldVal, key); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Pointer && isNestedStruct(field.Type.Elem()) && !fieldVal.IsNil():
+			if err := applyDefaultFuncs(fieldVal.Elem(), key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// callDefaultFunc 调用结构体 owner 上的方法 name，将结果写入 fieldVal。
+func callDefaultFunc(owner, fieldVal reflect.Value, name string) error {
+	method := owner.Addr().MethodByName(name)
+	if !method.IsValid() {
+		return fmt.Errorf("method not found on %s", owner.Type())
+	}
+
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() != 1 {
+		return fmt.Errorf("method must have signature func() %s", fieldVal.Type())
+	}
+	if out := methodType.Out(0); !out.AssignableTo(fieldVal.Type()) {
+		return fmt.Errorf("method returns %s, field type is %s", out, fieldVal.Type())
+	}
+
+	fieldVal.Set(method.Call(nil)[0])
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package cfgm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// defaultFunc 运行时默认值测试
+// =============================================================================
+
+type defaultFuncServer struct {
+	Host string `koanf:"host"`
+	Port int    `koanf:"port" defaultFunc:"DefaultPort"`
+}
+
+func (s *defaultFuncServer) DefaultPort() int { return 8000 + len(s.Host) }
+
+type defaultFuncConfig struct {
+	Name   string            `koanf:"name"`
+	Addr   string            `koanf:"addr" defaultFunc:"DefaultAddr"`
+	Server defaultFuncServer `koanf:"server"`
+}
+
+func (c defaultFuncConfig) DefaultAddr() string { return c.Name + ".internal:80" }
+
+type defaultFuncMismatchConfig struct {
+	Port int `koanf:"port" defaultFunc:"DefaultPort"`
+}
+
+func (defaultFuncMismatchConfig) DefaultPort() string { return "8080" }
+
+func TestLoadDefaultFunc(t *testing.T) {
+	t.Run("fills empty fields", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: api\nserver:\n  host: abcd\n")
+
+		cfg, err := Load(defaultFuncConfig{}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.Equal(t, "api.internal:80", cfg.Addr, "computed from other loaded fields")
+		assert.Equal(t, 8004, cfg.Server.Port, "method on nested struct with pointer receiver")
+	})
+
+	t.Run("keeps non-zero values", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: api\naddr: explicit:1\nserver:\n  port: 9000\n")
+
+		cfg, err := Load(defaultFuncConfig{}, WithConfigPaths(tmpFile))
+		require.NoError(t, err)
+		assert.Equal(t, "explicit:1", cfg.Addr)
+		assert.Equal(t, 9000, cfg.Server.Port)
+	})
+
+	t.Run("missing method", func(t *testing.T) {
+		type Config struct {
+			Addr string `koanf:"addr" defaultFunc:"Missing"`
+		}
+		_, err := Load(Config{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `defaultFunc "Missing" for addr: method not found`)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		_, err := Load(defaultFuncMismatchConfig{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `defaultFunc "DefaultPort" for port: method returns string, field type is int`)
+	})
+}
@@ -0,0 +1,67 @@
+package cfgm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/knadh/koanf/providers/structs"
+	"github.com/knadh/koanf/v2"
+)
+
+// migrateDeprecatedKeys 将 alias 标签声明的旧 key 迁移到字段的新 key，并为使用旧 key 或
+// deprecated 字段的配置记录 [WarningDeprecated]。
+//
+// 字段标签示例：
+//
+//	DocsDir string `koanf:"docs_dir" alias:"docs" deprecated:"use docs_dir instead"`
+//
+// alias 为同一层级下的旧 key 名称，多个旧名称以逗号分隔。旧 key 的值仅在新 key
+// 未被显式设置（仍为默认值）时生效，迁移后旧 key 被删除，不会产生 [WarningUnknownKey]。
+// 无 alias 的 deprecated 字段在值不同于默认值时记录警告。
+func migrateDeprecatedKeys[T any](o *options, k *koanf.Koanf, defaultConfig T) {
+	var defaults *koanf.Koanf
+	isDefault := func(key string) bool {
+		if defaults == nil {
+			defaults = koanf.New(".")
+			_ = defaults.Load(structs.Provider(defaultConfig, "koanf"), nil)
+		}
+
+		return reflect.DeepEqual(k.Get(key), defaults.Get(key))
+	}
+
+	for _, leaf := range koanfTypeInfoFor(reflect.TypeOf(defaultConfig)).leaves {
+		reason := leaf.field.Tag.Get("deprecated")
+		aliasTag := leaf.field.Tag.Get("alias")
+		if aliasTag == "" {
+			if reason != "" && k.Exists(leaf.key) && !isDefault(leaf.key) {
+				o.warn(WarningDeprecated, leaf.key, "deprecated: "+reason)
+			}
+
+			continue
+		}
+
+		parent := ""
+		if idx := strings.LastIndex(leaf.key, "."); idx >= 0 {
+			parent = leaf.key[:idx+1]
+		}
+		for alias := range strings.SplitSeq(aliasTag, ",") {
+			aliasKey := parent + strings.TrimSpace(alias)
+			if !k.Exists(aliasKey) {
+				continue
+			}
+
+			msg := fmt.Sprintf("deprecated key, use %s instead", leaf.key)
+			if reason != "" {
+				msg = "deprecated: " + reason
+			}
+			if isDefault(leaf.key) {
+				_ = k.Set(leaf.key, k.Get(aliasKey))
+			} else {
+				msg += fmt.Sprintf(" (ignored, %s is also set)", leaf.key)
+			}
+			k.Delete(aliasKey)
+			o.warn(WarningDeprecated, aliasKey, msg)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package cfgm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/knadh/koanf/providers/structs"
+	"github.com/knadh/koanf/v2"
+)
+
+// FieldDiff 描述单个配置项在两次加载之间的变化。
+type FieldDiff struct {
+	Key string // 完整的 koanf key，如 server.port
+	Old any    // 旧值，配置项不存在时为 nil
+	New any    // 新值，配置项不存在时为 nil
+}
+
+// DiffConfig 比较两个配置，返回所有值不同的叶子配置项（按 key 排序）。
+//
+// 配置项按 koanf tag 展开为完整 key 后逐一比较，嵌套结构体会展开到叶子节点，
+// 切片和 map 字段作为整体比较。配置相同时返回 nil。
+//
+// 示例：
+//
+//	for _, d := range cfgm.DiffConfig(oldCfg, newCfg) {
+//	    log.Printf("%s: %v → %v", d.Key, d.Old, d.New)
+//	}
+func DiffConfig[T any](oldCfg, newCfg T) []FieldDiff {
+	oldValues := flattenStruct(oldCfg)
+	newValues := flattenStruct(newCfg)
+
+	keys := make([]string, 0, len(oldValues)+len(newValues))
+	for key := range oldValues {
+		keys = append(keys, key)
+	}
+	for key := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var diffs []FieldDiff
+	for _, key := range keys {
+		oldVal, newVal := oldValues[key], newValues[key]
+		if !reflect.DeepEqual(oldVal, newVal) {
+			diffs = append(diffs, FieldDiff{Key: key, Old: oldVal, New: newVal})
+		}
+	}
+
+	return diffs
+}
+
+// NonDefault 返回 cfg 中值不同于 defaults 的叶子配置项，组织为嵌套 map，便于序列化后附在问题报告中。
+//
+// 嵌套结构体按 koanf tag 展开为嵌套 map，切片和 map 字段作为整体比较和输出（map 的 key 不会按 "." 拆分），
+// 相对默认值被清空为 nil 的字段也会输出（值为 nil）。值的转换规则与 [MarshalDiff] 相同（如 time.Duration 输出为 "30s"）。
+// 配置与默认值相同时返回空 map。
+//
+// 示例：
+//
+//	out, _ := yaml.Marshal(cfgm.NonDefault(*cfg, DefaultConfig()))
+//	// server:
+//	//   port: 9090
+func NonDefault[T any](cfg T, defaults T) map[string]any {
+	out := make(map[string]any)
+	cfgVal, defVal := reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(&defaults).Elem()
+	if cfgVal.Kind() == reflect.Struct {
+		nonDefaultFields(cfgVal, defVal, out)
+	}
+
+	return out
+}
+
+// nonDefaultFields 逐字段比较 val 与 def，将不同的叶子字段写入 out，嵌套结构体递归写入子 map。
+func nonDefaultFields(val, def reflect.Value, out map[string]any) {
+	for i := range val.NumField() {
+		field := val.Type().Field(i)
+		key := field.Tag.Get("koanf")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+
+		fieldVal, defFieldVal := val.Field(i), def.Field(i)
+		if isNestedStruct(field.Type) {
+			child := make(map[string]any)
+			nonDefaultFields(fieldVal, defFieldVal, child)
+			if len(child) > 0 {
+				out[key] = child
+			}
+
+			continue
+		}
+
+		if reflect.DeepEqual(fieldVal.Interface(), defFieldVal.Interface()) {
+			continue
+		}
+		if (fieldVal.Kind() == reflect.Slice || fieldVal.Kind() == reflect.Map) && fieldVal.IsNil() {
+			out[key] = nil
+		} else {
+			out[key] = plainValue(fieldVal)
+		}
+	}
+}
+
+// MarshalDiff 将配置差异序列化为 JSON 数组，便于运维面板等工具消费 [Watch] 的变更：
+//
+//	[{"key":"server.port","old":8080,"new":9090}]
+//
+// 值使用其自然的 JSON 类型（数值、bool、字符串、数组、对象），
+// time.Duration 输出为 "30s" 格式，配置项不存在时为 null。diffs 为空时输出 []。
+func MarshalDiff(diffs []FieldDiff) []byte {
+	type jsonDiff struct {
+		Key string `json:"key"`
+		Old any    `json:"old"`
+		New any    `json:"new"`
+	}
+
+	out := make([]jsonDiff, 0, len(diffs))
+	for _, d := range diffs {
+		out = append(out, jsonDiff{Key: d.Key, Old: diffJSONValue(d.Old), New: diffJSONValue(d.New)})
+	}
+	data, _ := json.Marshal(out) //nolint:errchkjson // values are plain config values, safe to encode
+
+	return data
+}
+
+// diffJSONValue 将差异中的值转换为可编码为 JSON 的普通值，规则见 [plainValue]。
+func diffJSONValue(v any) any {
+	if v == nil {
+		return nil
+	}
+
+	return plainValue(reflect.ValueOf(v))
+}
+
+// AssertConfigEqual 断言两个配置相等，不相等时按配置项输出差异并标记测试失败。
+//
+// 差异由 [DiffConfig] 计算，每个不同的配置项输出一行，比完整的结构体 dump 更易定位问题：
+//
+//	config mismatch (1 field):
+//	  server.port: want 8080, got 9090
+//
+// 使用示例：
+//
+//	cfg, err := cfgm.Load(DefaultConfig(), cfgm.WithConfigPaths("testdata/config.yaml"))
+//	require.NoError(t, err)
+//	cfgm.AssertConfigEqual(t, expected, *cfg)
+func AssertConfigEqual[T any](t *testing.T, want, got T) {
+	t.Helper()
+
+	if diffs := DiffConfig(want, got); len(diffs) > 0 {
+		t.Error(formatConfigDiffs(diffs))
+	}
+}
+
+// formatConfigDiffs 将配置差异格式化为每个配置项一行的文本，Old 为期望值，New 为实际值。
+func formatConfigDiffs(diffs []FieldDiff) string {
+	var b strings.Builder
+	noun := "fields"
+	if len(diffs) == 1 {
+		noun = "field"
+	}
+	fmt.Fprintf(&b, "config mismatch (%d %s):", len(diffs), noun)
+	for _, d := range diffs {
+		fmt.Fprintf(&b, "\n  %s: want %s, got %s", d.Key, formatDiffValue(d.Old), formatDiffValue(d.New))
+	}
+
+	return b.String()
+}
+
+// formatDiffValue 格式化差异中的单个值，字符串加引号以区分空字符串，配置项不存在时输出 <missing>。
+func formatDiffValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "<missing>"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// flattenStruct 将配置结构体按 koanf tag 展开为 key → 值 的扁平映射。
+func flattenStruct[T any](cfg T) map[string]any {
+	k := koanf.New(".")
+	_ = k.Load(structs.Provider(cfg, "koanf"), nil)
+
+	return k.All()
+}
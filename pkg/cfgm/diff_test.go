@@ -0,0 +1,163 @@
+package cfgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// =============================================================================
+// DiffConfig 测试
+// =============================================================================
+
+type diffTestConfig struct {
+	Name   string            `koanf:"name"`
+	Hosts  []string          `koanf:"hosts"`
+	Labels map[string]string `koanf:"labels"`
+	Server struct {
+		Port    int           `koanf:"port"`
+		Timeout time.Duration `koanf:"timeout"`
+	} `koanf:"server"`
+}
+
+func TestDiffConfig(t *testing.T) {
+	base := diffTestConfig{Name: "app", Hosts: []string{"a"}, Labels: map[string]string{"env": "dev"}}
+	base.Server.Port = 8080
+	base.Server.Timeout = 15 * time.Second
+
+	t.Run("identical", func(t *testing.T) {
+		assert.Empty(t, DiffConfig(base, base))
+	})
+
+	t.Run("nested field", func(t *testing.T) {
+		changed := base
+		changed.Server.Port = 9090
+
+		assert.Equal(t, []FieldDiff{{Key: "server.port", Old: 8080, New: 9090}}, DiffConfig(base, changed))
+	})
+
+	t.Run("multiple fields sorted", func(t *testing.T) {
+		changed := base
+		changed.Name = "renamed"
+		changed.Hosts = []string{"a", "b"}
+		changed.Labels = map[string]string{"env": "prod"}
+		changed.Server.Timeout = 30 * time.Second
+
+		diffs := DiffConfig(base, changed)
+		keys := make([]string, 0, len(diffs))
+		for _, d := range diffs {
+			keys = append(keys, d.Key)
+		}
+		assert.Equal(t, []string{"hosts", "labels", "name", "server.timeout"}, keys)
+	})
+
+	t.Run("map compared as a whole", func(t *testing.T) {
+		changed := base
+		changed.Labels = map[string]string{"env": "dev", "team": "infra"}
+
+		assert.Equal(t, []FieldDiff{{Key: "labels", Old: base.Labels, New: changed.Labels}}, DiffConfig(base, changed))
+	})
+}
+
+// =============================================================================
+// AssertConfigEqual 测试
+// =============================================================================
+
+func TestAssertConfigEqual(t *testing.T) {
+	want := diffTestConfig{Name: "app", Hosts: []string{"a"}}
+	want.Server.Port = 8080
+
+	t.Run("equal", func(t *testing.T) {
+		got := want
+		AssertConfigEqual(t, want, got)
+	})
+
+	t.Run("mismatch message", func(t *testing.T) {
+		got := want
+		got.Server.Port = 9090
+
+		assert.Equal(t, "config mismatch (1 field):\n  server.port: want 8080, got 9090",
+			formatConfigDiffs(DiffConfig(want, got)))
+
+		got.Name = ""
+		got.Server.Timeout = 5 * time.Second
+		assert.Equal(t, "config mismatch (3 fields):\n"+
+			`  name: want "app", got ""`+"\n"+
+			"  server.port: want 8080, got 9090\n"+
+			"  server.timeout: want 0s, got 5s",
+			formatConfigDiffs(DiffConfig(want, got)))
+	})
+}
+
+// =============================================================================
+// MarshalDiff 测试
+// =============================================================================
+
+func TestMarshalDiff(t *testing.T) {
+	base := diffTestConfig{Name: "app", Hosts: []string{"a"}}
+	base.Server.Port = 8080
+	base.Server.Timeout = 15 * time.Second
+
+	changed := base
+	changed.Name = "renamed"
+	changed.Hosts = []string{"a", "b"}
+	changed.Server.Port = 9090
+	changed.Server.Timeout = 30 * time.Second
+
+	assert.JSONEq(t, `[
+		{"key": "hosts", "old": ["a"], "new": ["a", "b"]},
+		{"key": "name", "old": "app", "new": "renamed"},
+		{"key": "server.port", "old": 8080, "new": 9090},
+		{"key": "server.timeout", "old": "15s", "new": "30s"}
+	]`, string(MarshalDiff(DiffConfig(base, changed))))
+
+	t.Run("missing value is null", func(t *testing.T) {
+		assert.JSONEq(t, `[{"key": "labels", "old": null, "new": {"env": "prod"}}]`,
+			string(MarshalDiff([]FieldDiff{{Key: "labels", New: map[string]string{"env": "prod"}}})))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, "[]", string(MarshalDiff(nil)))
+	})
+}
+
+// =============================================================================
+// NonDefault 测试
+// =============================================================================
+
+func TestNonDefault(t *testing.T) {
+	defaults := diffTestConfig{Name: "app", Hosts: []string{"a"}}
+	defaults.Server.Port = 8080
+	defaults.Server.Timeout = 15 * time.Second
+
+	cfg := defaults
+	cfg.Server.Port = 9090
+	cfg.Server.Timeout = 30 * time.Second
+	cfg.Labels = map[string]string{"env": "prod"}
+
+	assert.Equal(t, map[string]any{
+		"labels": map[string]any{"env": "prod"},
+		"server": map[string]any{"port": 9090, "timeout": "30s"},
+	}, NonDefault(cfg, defaults))
+
+	t.Run("unchanged config", func(t *testing.T) {
+		assert.Empty(t, NonDefault(defaults, defaults))
+	})
+
+	t.Run("value cleared to nil", func(t *testing.T) {
+		cleared := defaults
+		cleared.Hosts = nil
+
+		assert.Equal(t, map[string]any{"hosts": nil}, NonDefault(cleared, defaults))
+	})
+
+	t.Run("map keys containing dots", func(t *testing.T) {
+		dotted := defaults
+		dotted.Labels = map[string]string{"app.kubernetes.io/name": "web"}
+
+		assert.Equal(t, map[string]any{
+			"labels": map[string]any{"app.kubernetes.io/name": "web"},
+		}, NonDefault(dotted, defaults))
+	})
+}
@@ -3,16 +3,26 @@
 // # 特性
 //
 // 使用泛型支持任意配置结构体类型，支持 YAML 和 JSON 格式（根据文件扩展名自动检测）。
+// 以 .gz 结尾的配置文件（如 config.yaml.gz）会先解压，再按其余扩展名选择格式。
+// 使用 [WithMaxConfigSize] 可限制单个配置源的大小，使用 [WithMaxConfigAge] 可拒绝过期（修改时间过早）的配置文件。
+// 非 UTF-8 编码的文件（如 GBK）可通过 [WithConfigEncoding] 指定编码，读取后转码为 UTF-8。
+// 配置打包在 zip 或 tar 归档中时，可使用 [WithConfigArchive] 读取指定条目。
 //
 // 配置加载优先级 (从低到高)：
 //  1. 默认值 - 通过 defaultConfig 参数传入
-//  2. 配置文件 - 通过 [WithConfigPaths] 或 [WithAppName] 设置
+//  2. 配置文件 - 通过 [WithConfigPaths] 或 [WithAppName] 设置；[WithProvider] 添加的 koanf provider 随后加载
 //  3. 环境变量(前缀) - 通过 [WithEnvPrefix] 自动生成绑定
 //  4. 环境变量(配置文件绑定) - 通过 [WithEnvBindKey] 从配置文件读取
 //  5. 环境变量(代码绑定) - 通过 [WithEnvBindings] 在代码中显式指定
 //  6. CLI flags - 通过 [WithCommand] 选项设置，最高优先级
 //
+// 配置文件与默认值深度合并（map 递归合并，其他值替换），可通过 [WithMergeFunc] 自定义单个路径的合并规则。
+// 配置文件使用其他命名规范（如 camelCase 的 serverAddr）时，可通过 [WithKeyMapper] 在合并前转换 key。
+//
+// 通过网络读取的配置源可实现 [ContextProvider]，读取超时由 [WithSourceTimeout] 控制（默认 10 秒）。
+//
 // 注意：同一配置路径若被多个环境变量绑定，代码绑定 > 配置文件绑定 > 前缀自动生成。
+// 通过 [WithLogger] 传入 Debug 级别的 logger 可查看每个配置路径最终生效的绑定来源。
 //
 // # 快速开始
 //
@@ -57,6 +67,40 @@
 //	    cfgm.WithConfigPaths("custom.yaml"), // 覆盖默认路径
 //	)
 //
+// 配置文件格式不固定时，使用 [WithConfigBasename] 依次尝试 config.yaml、config.yml、config.json：
+//
+//	cfgm.Load(config, cfgm.WithConfigBasename("config"))
+//
+// 扩展名无法反映格式时（如 app.conf），使用 [WithConfigPathParser] 为该路径强制指定解析器：
+//
+//	cfgm.Load(config, cfgm.WithConfigPaths("app.conf"), cfgm.WithConfigPathParser("app.conf", "yaml"))
+//
+// CI 等场景可使用 [WithConfigPathsOverrideEnv] 通过环境变量指定唯一的配置文件，完全替换搜索路径：
+//
+//	cfgm.Load(config, cfgm.WithConfigPathsOverrideEnv("CI_CONFIG"))
+//
+// 类似地，[WithConfigFlag] 使用 --config 等 CLI flag 的值作为唯一的配置文件（flag 可定义在父命令上）：
+//
+//	cfgm.Load(config, cfgm.WithCommand(cmd), cfgm.WithConfigFlag("config"))
+//
+// 由中间件决定配置时，使用 [WithConfigFromContext] 从 context 值中获取配置文件路径或配置内容：
+//
+//	cfgm.Load(config, cfgm.WithConfigFromContext(ctx, configPathKey{}))
+//
+// 使用 [WithLenientFile] 在配置文件之后尽力加载本地覆盖文件，文件格式错误时仅记录警告：
+//
+//	cfgm.Load(config, cfgm.WithLenientFile("config.local.yaml"))
+//
+// 使用 [WithIncludes] 可在 YAML 配置文件中通过 !include 拆分配置：
+//
+//	database: !include db.yaml
+//
+// 使用 [WithFileTags] 可通过 !file 将单独文件的内容作为标量值（如证书）：
+//
+//	tls_cert: !file ./tls.pem
+//
+// 多命令工具可使用 [WithSectionFromCommand] 让每个子命令只加载配置文件中以命令名命名的节（如 client:）。
+//
 // # 环境变量(前缀)
 //
 // 通过 [WithEnvPrefix] 启用环境变量支持，命名规则：
@@ -70,6 +114,23 @@
 //
 // 注意：通过反射自动生成所有 koanf key 的绑定，因此支持任意命名的 koanf key。
 //
+// 迁移前缀时可使用 [WithEnvPrefixMulti] 同时兼容新旧前缀，靠前的前缀优先：
+//
+//	cfgm.WithEnvPrefixMulti("NEWAPP_", "OLDAPP_")
+//
+// 只希望部分配置受前缀变量影响时，使用 [WithEnvPrefixExplicit] 列出需要绑定的 key：
+//
+//	cfgm.WithEnvPrefixExplicit("MYAPP_", "server.addr") // MYAPP_SERVER_PORT 不生效
+//
+// 前缀变量只作为兜底时，使用 [WithEnvFillOnly] 使其仅填充配置文件未设置的 key（显式绑定不受影响）：
+//
+//	cfgm.Load(config, cfgm.WithEnvPrefix("MYAPP_"), cfgm.WithEnvFillOnly())
+//
+// 并发程序中可使用 [WithEnvSnapshot] 在加载开始时捕获一次环境变量，绑定和模板展开统一使用该快照。
+//
+// 生产环境中必须通过环境变量注入的字段（如密钥）可标记 envrequired:"true"，
+// 启用 [WithEnforceEnvRequired] 后这些字段未由环境变量提供时加载失败（返回 [ErrEnvRequired]）。
+//
 // # 环境变量(绑定)
 //
 // 方式一：通过代码绑定 [WithEnvBindings]：
@@ -89,23 +150,66 @@
 //	redis:
 //	  url: "redis://localhost:6379"
 //
-// 代码中的绑定优先级高于配置文件中的绑定。
+// 代码中的绑定优先级高于配置文件中的绑定；多次调用 [WithEnvBindings] 绑定同一配置路径时，后注册的生效。
+// 库可使用 [WithEnvBindingsDefault] 注册可被应用覆盖的默认绑定，
+// 应用可使用 [WithEnvBindingsOverride] 注册不受调用顺序影响的最高优先级绑定。
+//
+// 共用前缀的一组变量可使用 [WithEnvBindingsPrefix] 批量绑定：
+//
+//	cfgm.WithEnvBindingsPrefix("PG_", map[string]string{"HOST": "db.host", "PORT": "db.port"})
+//
+// 同一配置项有多个候选变量时，[WithEnvBindingCoalesce] 按参数顺序取第一个已设置的变量：
+//
+//	cfgm.WithEnvBindingCoalesce("redis.url", "REDIS_URL", "CACHE_URL")
+//
+// 大量命名相似的环境变量可使用 [WithEnvBindingRegex] 按正则批量绑定：
+//
+//	cfgm.WithEnvBindingRegex(`^FEATURE_(.+)$`, "features.$1") // FEATURE_BETA → features.beta
+//
+// 使用 [WithStrictBindings] 校验绑定的目标路径，绑定到不存在的配置路径（如拼写错误）时返回错误。
+//
+// 使用 [WithEnvAuditFunc] 记录哪些配置值实际来自环境变量，用于合规审计。
+// 排查问题时可使用 [ActiveEnvOverrides] 在不加载配置的情况下列出当前生效的环境变量。
+//
+// # Docker secrets
+//
+// 使用 [WithDockerSecrets] 从 /run/secrets 按约定读取配置，优先级高于配置文件、低于环境变量：
+//
+//	cfgm.WithDockerSecrets("myapp_") // database.password ← /run/secrets/myapp_database_password
+//
+// secrets 挂载到其他目录时使用 [WithDockerSecretsDir] 指定。
 //
 // # 模板展开
 //
 // 配置文件默认启用模板展开功能，在解析前处理模板语法（YAML 和 JSON 均支持）。
-// 使用 [WithoutTemplateExpansion] 可禁用此功能。
+// 使用 [WithoutTemplateExpansion] 可禁用此功能，使用 [WithTemplateData] 提供额外的模板数据。
+// 使用 [WithTemplateDataFile] 从 YAML/JSON 文件加载模板数据，便于按环境维护 vars.prod.yaml 等变量文件。
+//
+// 测试中可使用 [WithNoEnv] 完全忽略环境变量（包括模板中的环境变量访问），无需修改进程环境。
 //
 // 支持的模板函数：
 //   - env: 获取环境变量 {{env "VAR"}} 或 {{env "VAR" "default"}}
+//   - hasEnv: 环境变量是否已设置且非空 {{- if hasEnv "ENABLE_TLS"}} ... {{- end}}
 //   - default: 管道式默认值 {{.VAR | default "fallback"}}
 //   - coalesce: 返回第一个非空值 {{coalesce .VAR1 .VAR2 "default"}}
+//   - coalesceAny: 返回第一个非零值（0、false、空切片和空 map 也视为空）{{coalesceAny (mul .COUNT 1) 5}}
+//   - add/sub/mul/div: 数值运算 {{mul (env "WORKERS") 4}}
+//   - fromJson/get: 读取 JSON 对象的 key {{get (fromJson .FLAGS) "beta" | default "off"}}
+//   - toInt/toJson: JSON 配置中输出不带引号的数字 "port": {{env "PORT" | toInt | toJson}}
+//   - readYaml/readJson: 读取共享数据文件的字段 {{(readYaml "shared.yaml").region}}（相对路径基于 [WithBaseDir]，受 [WithMaxConfigSize] 限制）
+//   - required: 值为空时展开失败 {{required "DB_PASSWORD is required" .DB_PASSWORD}}
+//   - mustMatch: 值不匹配正则时展开失败 {{env "EMAIL" | mustMatch "^[^@]+@[^@]+$"}}
+//   - randAlphaNum/randHex: 随机字符串 {{env "SESSION_KEY" | default (randAlphaNum 32)}}
 //
 // Taskfile 风格直接访问环境变量：
 //
 //	api_key: "{{.OPENAI_API_KEY}}"
 //	model: "{{.MODEL | default \"gpt-4\"}}"
 //
+// {{.ConfigDir}} 为配置文件所在目录的绝对路径，用于引用同目录下的文件：
+//
+//	cert: "{{.ConfigDir}}/tls.pem"
+//
 // 配置文件示例：
 //
 //	# config.yaml
@@ -120,6 +224,11 @@
 //	    cfgm.WithoutTemplateExpansion(), // 禁用模板展开
 //	)
 //
+// 配置值本身包含 {{ }} 时，可使用 [WithTemplateDelimiters] 更换分隔符（如 [[ 和 ]]），{{ }} 保持原样。
+// 缺失的 {{.VAR}} 默认展开为 "<no value>"，使用 [WithTemplateMissingZero] 可展开为空字符串。
+//
+// 在 CI 中可使用 [ValidateTemplate] 检查配置文件能否在当前环境下展开并解析，不解码到结构体。
+//
 // # CLI Flag 映射
 //
 // 支持两种 CLI flag 格式 (优先使用 kebab-case)：
@@ -130,11 +239,29 @@
 //   - server.url → --server-url 或 --server.url
 //   - tls.skip_verify → --tls-skip_verify 或 --tls.skip_verify
 //
+// 两种格式同时被设置时使用 kebab-case 的值并记录 Warn 日志，[WithStrictCLIFlags] 可将其视为错误。
+//
+// 名称无法按上述规则推导的 flag 可通过 [WithCLIFlagAlias] 显式映射（如 --addr → server.addr）。
+//
+// map[string]string 类型的 flag 默认整体替换其他配置源的 map，[WithCLIMapMerge] 可改为按条目合并。
+//
 // # 支持的类型
 //
-// 基本类型：string, bool, int*, uint*, float*
+// 基本类型：string, bool, int*, uint*, float*（环境变量和 CLI flags 中的整数超出字段位宽时返回溢出错误）
 // 时间类型：time.Duration, time.Time
-// 复合类型：[]string, []int, map[string]string 等
+// 复合类型：[]string, []int, map[string]string 等（未被任何配置源提供时保留默认值，包括非 nil 的空值）
+// 指针类型：*int, *bool 等（可选覆盖值，nil 在示例中输出为 null）
+//
+// 运行时默认值：字段标记 defaultFunc:"Method" 时，若所有配置源合并后仍为零值，
+// 调用所属结构体上的同名方法（无参数、返回字段类型）填充。
+//
+// bool 字段除 true/false 外还接受 yes/no、on/off、1/0（不区分大小写），无法识别的值返回错误。
+//
+// map[string]any 和 json.RawMessage 字段接受 JSON 字符串（如 metadata: '{"a": 1}'），
+// 便于在 YAML 或环境变量中内联 JSON。
+//
+// 其他自定义类型（如字节大小、net.IP）可通过 [WithDecodeHook] 注册 mapstructure 解码钩子，
+// 钩子在内置转换规则之前执行。
 //
 // # 生成配置示例
 //
@@ -143,11 +270,120 @@
 //	yaml := cfgm.ExampleYAML(defaultConfig)
 //	os.WriteFile("config.example.yaml", yaml, 0644)
 //
-// 使用 [MarshalJSON] 序列化为 JSON：
+// bool 字段可通过 format:"yesno" 标签渲染为 yes/no；time.Duration 字段可通过
+// format:"seconds" 或 format:"minutes" 以单一单位渲染（如 90s 而非 1m30s）。
+// 带 validate:"required" 或 required:"true" 标签的字段在注释后标注 (required)。
+//
+// 传入 [WithExampleEnvPrefix] 可在注释中标注每个配置项对应的环境变量名：
+//
+//	yaml := cfgm.ExampleYAML(defaultConfig, cfgm.WithExampleEnvPrefix("APP_"))
+//	// name: "app" # 应用名称 (env: APP_NAME)
+//
+// 空切片和空 map 默认渲染为 [] 和 {}，可通过 [WithExampleEmptyStyle] 改为仅输出 key（[EmptyBlock]）
+// 或省略字段（[EmptyOmit]）。
+//
+// 字段默认按结构体声明顺序输出，[WithExampleSortKeys] 改为在每一层按 key 字母序输出。
+//
+// 使用 [MarshalJSON] 序列化为 JSON（未设置 json tag 的字段使用 koanf tag 作为 key）：
 //
 //	jsonBytes := cfgm.MarshalJSON(defaultConfig)
 //	os.WriteFile("config.json", jsonBytes, 0644)
 //
+// 机器读取时可使用 [MarshalJSONCompact] 输出单行 JSON。
+//
+// 使用 [JSONSchema] 生成 JSON Schema，供编辑器补全和校验配置文件；
+// [SchemaCommand] 提供现成的 schema 子命令（支持 --format json/yaml）：
+//
+//	app.Commands = append(app.Commands, cfgm.SchemaCommand(DefaultConfig()))
+//
+// 加载时使用 [WithSchemaValidation] 按同一 Schema 校验合并后的配置，错误带完整路径（如 server.port: expected integer, got string）。
+//
+// 使用 [FieldMetadata] 获取每个配置项的 key、类型、默认值、描述和 sensitive/required 标记，
+// 供生成管理界面等工具使用。
+//
+// 使用 [MarkdownDocs] 生成配置项的 Markdown 表格，配合 [WithExampleEnvPrefix] 增加 Env Var 列：
+//
+//	docs := cfgm.MarkdownDocs(DefaultConfig(), cfgm.WithExampleEnvPrefix("APP_"))
+//
+// 使用 [UsageDoc] 生成逐项的完整参考，同时列出 YAML 路径、环境变量和 CLI flag 三种写法：
+//
+//	docs := cfgm.UsageDoc(DefaultConfig(), "APP_")
+//
+// # 生命周期回调
+//
+// 使用 [WithHooks] 集中观察加载过程，OnBeforeUnmarshal 和 OnLoaded 返回 error 时中止加载：
+//
+//	cfgm.WithHooks(cfgm.Hooks{
+//	    OnFileLoaded:      func(path string) { ... },
+//	    OnBeforeUnmarshal: func(k *koanf.Koanf) error { ... },
+//	    OnLoaded:          func(cfg any) error { ... },
+//	})
+//
+// 需要规范化单个配置值（如邮箱转小写）时，使用 [WithValueTransformer] 在解析前转换该 key 的值：
+//
+//	cfgm.WithValueTransformer("admin.email", func(v any) (any, error) { return strings.ToLower(fmt.Sprint(v)), nil })
+//
+// 使用 [WithMetrics] 观测每次加载的耗时、配置来源和错误，由应用对接 Prometheus 等指标系统。
+//
+// 使用 [LoadWithResult] 获取加载过程中的非致命问题（[Warning]），如被忽略的未知 key、
+// 被更高优先级绑定遮蔽的环境变量：
+//
+//	res, err := cfgm.LoadWithResult(DefaultConfig())
+//	for _, w := range res.Warnings { slog.Warn(w.String(), "code", w.Code) }
+//
+// 重命名配置项时，可通过 alias 标签兼容旧 key，旧 key 的值迁移到新字段并记录 [WarningDeprecated]：
+//
+//	DocsDir string `koanf:"docs_dir" alias:"docs" deprecated:"use docs_dir instead"`
+//
+// # 校验
+//
+// 使用 [ValidateStruct] 按 validate 标签（required、min、max、oneof）校验配置，
+// 可用于手动构建的配置，或在 OnLoaded 回调中校验加载结果：
+//
+//	cfgm.WithHooks(cfgm.Hooks{OnLoaded: func(cfg any) error { return cfgm.ValidateStruct(cfg) }})
+//
+// 校验通过后需要执行的副作用（如初始化连接池）可使用 [WithAfterLoad]，返回 error 时 [Load] 失败：
+//
+//	cfgm.WithAfterLoad(func(cfg *Config) error { return initPool(cfg.Database) })
+//
+// # 监听配置变化
+//
+// 使用 [Watch] 监听配置文件，文件变化时重新加载，回调参数中的 [FieldDiff] 列出变化的配置项：
+//
+//	cfg, err := cfgm.Watch(ctx, DefaultConfig(), func(cfg *Config, diffs []cfgm.FieldDiff, err error) {
+//	    // 仅在配置实际变化时回调
+//	}, cfgm.WithAppName("myapp"))
+//
+// 需要重启才能生效的字段可标记 immutable:"true"，其变化会使 [Watch] 和 [OnSignalReload] 的重新加载被拒绝
+// （回调 [ErrImmutableChanged]）：
+//
+//	Addr string `koanf:"addr" immutable:"true"`
+//
+// 也可使用 [DiffConfig] 直接比较两个配置，使用 [MarshalDiff] 将差异输出为 JSON 供其他工具消费。
+// 提交问题报告时，可使用 [NonDefault] 只输出不同于默认值的配置项（嵌套 map）。
+//
+// 传统守护进程可使用 [OnSignalReload] 在收到 SIGHUP 时重新加载：
+//
+//	stop := cfgm.OnSignalReload(syscall.SIGHUP, DefaultConfig(), func(cfg *Config, err error) {
+//	    // 每次收到信号都回调
+//	}, cfgm.WithAppName("myapp"))
+//	defer stop()
+//
+// # 导出生效配置
+//
+// 使用 [Dump] 将合并后的配置输出为 YAML 或 JSON，配合 [Redacted] 隐藏 sensitive:"true" 字段：
+//
+//	out, err := cfgm.Dump(cfgm.Redacted(cfg), "yaml") // cfg 为 *Config
+//
+// 使用 [WithDebugConfigEnv] 可在指定环境变量为真值时（如 APP_DEBUG_CONFIG=1），
+// 由 [Load] 自动将脱敏后的生效配置记录到 logger。
+//
+// 使用 [MarshalEnv] 输出 export 语句，用于复现环境变量配置：
+//
+//	os.Stdout.Write(cfgm.MarshalEnv(*cfg, "MYAPP_"))
+//
+// 使用 [FlattenConfig] 将配置展开为 server.addr → ":8080" 形式的扁平映射，便于写入 Consul KV 等键值存储。
+//
 // # 测试辅助
 //
 // 使用 [ConfigTestHelper] 提供测试辅助功能：
@@ -159,4 +395,12 @@
 //
 //	func TestWriteExample(t *testing.T) { helper.WriteExampleFile(t, DefaultConfig()) }
 //	func TestConfigKeysValid(t *testing.T) { helper.ValidateKeys(t) }
+//
+// 设置 ExampleJSONPath 后可使用 GenerateExamples 同时生成 YAML 和 JSON 示例。
+//
+// CI 中可使用 AssertExampleUpToDate 检查示例文件是否过期（不写入文件）：
+//
+//	func TestExampleUpToDate(t *testing.T) { helper.AssertExampleUpToDate(t, DefaultConfig()) }
+//
+// 使用 [AssertConfigEqual] 断言加载结果，失败时按配置项输出差异（如 server.port: want 8080, got 9090）。
 package cfgm
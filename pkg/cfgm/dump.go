@@ -0,0 +1,159 @@
+package cfgm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// redactedValue 脱敏后字符串字段的占位值。
+const redactedValue = "******"
+
+// Dump 将合并后的生效配置序列化为指定格式，便于调试。
+//
+// format 支持 "yaml"（或 "yml"）和 "json"，分别复用 [MarshalYAML] 和 [MarshalJSON]。
+// 如需隐藏敏感字段，先调用 [Redacted] 再传入。
+//
+// 典型用法是为应用添加 config dump 子命令：
+//
+//	cfg, err := cfgm.LoadCmd(cmd, DefaultConfig(), "myapp")
+//	if cmd.Bool("redact") {
+//	    cfg = cfgm.Redacted(cfg)
+//	}
+//	out, err := cfgm.Dump(cfg, cmd.String("format"))
+func Dump[T any](cfg *T, format string) ([]byte, error) {
+	if cfg == nil {
+		return nil, errors.New("dump config: nil config")
+	}
+
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		return MarshalYAML(*cfg), nil
+	case "json":
+		return MarshalJSON(*cfg), nil
+	default:
+		return nil, fmt.Errorf("dump config: unsupported format %q", format)
+	}
+}
+
+// Redacted 返回配置的副本，其中标记 sensitive:"true" 的字段已被脱敏。
+//
+// 字符串字段替换为 "******"，其他类型字段置为零值。嵌套结构体、结构体指针以及
+// 切片、数组、map 中的结构体元素均递归处理，cfg 本身为指针时脱敏其指向的结构体。
+// 沿途的指针、切片和 map 均会复制，原配置不受影响。
+//
+// 示例：
+//
+//	type Config struct {
+//	    Password string `koanf:"password" sensitive:"true"`
+//	}
+func Redacted[T any](cfg T) T {
+	val := reflect.ValueOf(&cfg).Elem()
+	redactRecursive(val)
+
+	return cfg
+}
+
+// redactRecursive 递归脱敏 val 中的敏感字段，val 必须可设置。
+//
+// 指针、切片和 map 先复制再脱敏，避免修改与原配置共享的数据。
+func redactRecursive(val reflect.Value) {
+	if !mayContainStruct(val.Type()) {
+		return
+	}
+
+	switch val.Kind() {
+	case reflect.Pointer:
+		if val.IsNil() {
+			return
+		}
+		cp := reflect.New(val.Type().Elem())
+		cp.Elem().Set(val.Elem())
+		redactRecursive(cp.Elem())
+		val.Set(cp)
+
+	case reflect.Interface:
+		if val.IsNil() {
+			return
+		}
+		cp := reflect.New(val.Elem().Type()).Elem()
+		cp.Set(val.Elem())
+		redactRecursive(cp)
+		val.Set(cp)
+
+	case reflect.Slice:
+		if val.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		reflect.Copy(cp, val)
+		for i := range cp.Len() {
+			redactRecursive(cp.Index(i))
+		}
+		val.Set(cp)
+
+	case reflect.Array:
+		for i := range val.Len() {
+			redactRecursive(val.Index(i))
+		}
+
+	case reflect.Map:
+		if val.IsNil() {
+			return
+		}
+		cp := reflect.MakeMapWithSize(val.Type(), val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			elem := reflect.New(val.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			redactRecursive(elem)
+			cp.SetMapIndex(iter.Key(), elem)
+		}
+		val.Set(cp)
+
+	case reflect.Struct:
+		redactStructFields(val)
+	}
+}
+
+// redactStructFields 脱敏结构体的 sensitive 字段，其余字段递归处理。
+func redactStructFields(val reflect.Value) {
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+		if !field.IsExported() || !fieldVal.CanSet() {
+			continue
+		}
+
+		if field.Tag.Get("sensitive") == "true" {
+			if fieldVal.Kind() == reflect.String {
+				if fieldVal.String() != "" {
+					fieldVal.SetString(redactedValue)
+				}
+			} else {
+				fieldVal.Set(reflect.Zero(field.Type))
+			}
+
+			continue
+		}
+
+		redactRecursive(fieldVal)
+	}
+}
+
+// mayContainStruct 判断 typ 去掉指针、切片、数组和 map 包装后是否为结构体或接口，
+// 仅这类值可能包含 sensitive 字段。
+func mayContainStruct(typ reflect.Type) bool {
+	for {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+		case reflect.Struct, reflect.Interface:
+			return true
+		default:
+			return false
+		}
+	}
+}
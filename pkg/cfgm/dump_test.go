@@ -0,0 +1,310 @@
+package cfgm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Dump / Redacted 测试
+// =============================================================================
+
+type dumpTestConfig struct {
+	Name   string `koanf:"name" json:"name"`
+	Server struct {
+		Addr     string `koanf:"addr" json:"addr"`
+		Password string `koanf:"password" json:"password" sensitive:"true"`
+	} `koanf:"server" json:"server"`
+}
+
+func newDumpTestConfig() dumpTestConfig {
+	var cfg dumpTestConfig
+	cfg.Name = "dump-app"
+	cfg.Server.Addr = ":8080"
+	cfg.Server.Password = "s3cret"
+
+	return cfg
+}
+
+func TestDump(t *testing.T) {
+	cfg := newDumpTestConfig()
+
+	t.Run("yaml", func(t *testing.T) {
+		out, err := Dump(&cfg, "yaml")
+		require.NoError(t, err)
+		a := assert.New(t)
+		a.Contains(string(out), "name: dump-app")
+		a.Contains(string(out), "addr: :8080")
+		a.Contains(string(out), "password: s3cret")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := Dump(&cfg, "json")
+		require.NoError(t, err)
+
+		var parsed map[string]any
+		require.NoError(t, json.Unmarshal(out, &parsed))
+		a := assert.New(t)
+		a.Equal("dump-app", parsed["name"])
+		a.Contains(parsed, "server")
+		a.Equal(":8080", parsed["server"].(map[string]any)["addr"])
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Dump(&cfg, "toml")
+		assert.ErrorContains(t, err, "unsupported format")
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		_, err := Dump[dumpTestConfig](nil, "yaml")
+		assert.Error(t, err)
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := newDumpTestConfig()
+	redacted := Redacted(cfg)
+
+	a := assert.New(t)
+	a.Equal("******", redacted.Server.Password)
+	a.Equal(":8080", redacted.Server.Addr)
+	a.Equal("s3cret", cfg.Server.Password, "original config must not be modified")
+
+	out, err := Dump(&redacted, "yaml")
+	require.NoError(t, err)
+	a.NotContains(string(out), "s3cret")
+	a.Contains(string(out), "password: '******'")
+}
+
+func TestRedacted_NestedShapes(t *testing.T) {
+	type Sub struct {
+		Host  string `koanf:"host"`
+		Token string `koanf:"token" sensitive:"true"`
+	}
+	type Config struct {
+		Password string         `koanf:"password" sensitive:"true"`
+		Ptr      *Sub           `koanf:"ptr"`
+		NilPtr   *Sub           `koanf:"nil_ptr"`
+		List     []Sub          `koanf:"list"`
+		PtrList  []*Sub         `koanf:"ptr_list"`
+		Array    [1]Sub         `koanf:"array"`
+		ByName   map[string]Sub `koanf:"by_name"`
+		Tags     []string       `koanf:"tags"`
+	}
+	newConfig := func() Config {
+		return Config{
+			Password: "p",
+			Ptr:      &Sub{Host: "a", Token: "t1"},
+			List:     []Sub{{Host: "b", Token: "t2"}},
+			PtrList:  []*Sub{{Host: "c", Token: "t3"}, nil},
+			Array:    [1]Sub{{Host: "d", Token: "t4"}},
+			ByName:   map[string]Sub{"e": {Host: "e", Token: "t5"}},
+			Tags:     []string{"x"},
+		}
+	}
+	assertRedacted := func(t *testing.T, cfg Config) {
+		t.Helper()
+		a := assert.New(t)
+		a.Equal("******", cfg.Password)
+		a.Equal(Sub{Host: "a", Token: "******"}, *cfg.Ptr)
+		a.Nil(cfg.NilPtr)
+		a.Equal([]Sub{{Host: "b", Token: "******"}}, cfg.List)
+		a.Equal(&Sub{Host: "c", Token: "******"}, cfg.PtrList[0])
+		a.Nil(cfg.PtrList[1])
+		a.Equal([1]Sub{{Host: "d", Token: "******"}}, cfg.Array)
+		a.Equal(map[string]Sub{"e": {Host: "e", Token: "******"}}, cfg.ByName)
+		a.Equal([]string{"x"}, cfg.Tags)
+	}
+
+	t.Run("value input", func(t *testing.T) {
+		cfg := newConfig()
+		assertRedacted(t, Redacted(cfg))
+		assert.Equal(t, newConfig(), cfg, "original config must not be modified")
+	})
+
+	t.Run("pointer input", func(t *testing.T) {
+		cfg := newConfig()
+		redacted := Redacted(&cfg)
+		require.NotSame(t, &cfg, redacted)
+		assertRedacted(t, *redacted)
+		assert.Equal(t, newConfig(), cfg, "original config must not be modified")
+
+		out, err := Dump(redacted, "yaml")
+		require.NoError(t, err)
+		for _, secret := range []string{"t1", "t2", "t3", "t4", "t5"} {
+			assert.NotContains(t, string(out), secret)
+		}
+	})
+
+	t.Run("nil pointer input", func(t *testing.T) {
+		assert.Nil(t, Redacted[*Config](nil))
+	})
+}
+
+func TestMarshalJSONCompact(t *testing.T) {
+	cfg := newDumpTestConfig()
+
+	compact := MarshalJSONCompact(cfg)
+	assert.NotContains(t, string(compact), "\n")
+	assert.Equal(t, `{"name":"dump-app","server":{"addr":":8080","password":"s3cret"}}`, string(compact))
+
+	var fromCompact, fromPretty map[string]any
+	require.NoError(t, json.Unmarshal(compact, &fromCompact))
+	require.NoError(t, json.Unmarshal(MarshalJSON(cfg), &fromPretty))
+	assert.Equal(t, fromPretty, fromCompact)
+}
+
+func TestMarshalJSON_KoanfKeys(t *testing.T) {
+	type Backend struct {
+		Host string `koanf:"host"`
+	}
+	type Base struct {
+		Region string `koanf:"region"`
+	}
+	type Config struct {
+		Base
+		AppName  string              `koanf:"app-name"`
+		Server   *struct{ Port int } `koanf:"server"`
+		Backends []Backend           `koanf:"backends"`
+		Labels   map[string]string   `koanf:"labels"`
+		Started  time.Time           `koanf:"started"`
+		Renamed  string              `koanf:"koanf-name" json:"json_name"`
+		Optional string              `koanf:"optional" json:",omitempty"`
+		Skipped  string              `koanf:"skipped" json:"-"`
+		Untagged int
+	}
+	cfg := Config{
+		Base:     Base{Region: "cn"},
+		AppName:  "app",
+		Server:   &struct{ Port int }{Port: 8080},
+		Backends: []Backend{{Host: "db"}},
+		Labels:   map[string]string{"b": "2", "a": "1"},
+		Started:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Renamed:  "r",
+		Skipped:  "s",
+		Untagged: 1,
+	}
+
+	assert.Equal(t, `{
+  "region": "cn",
+  "app-name": "app",
+  "server": {
+    "Port": 8080
+  },
+  "backends": [
+    {
+      "host": "db"
+    }
+  ],
+  "labels": {
+    "a": "1",
+    "b": "2"
+  },
+  "started": "2025-01-02T03:04:05Z",
+  "json_name": "r",
+  "Untagged": 1
+}
+`, string(MarshalJSON(cfg)))
+
+	t.Run("loads back", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, MarshalJSON(cfg), 0600))
+
+		loaded, err := Load(Config{}, WithConfigPaths(path))
+		require.NoError(t, err)
+		assert.Equal(t, "app", loaded.AppName)
+		assert.Equal(t, cfg.Backends, loaded.Backends)
+		assert.Equal(t, cfg.Labels, loaded.Labels)
+		assert.True(t, cfg.Started.Equal(loaded.Started))
+	})
+}
+
+func TestMarshalJSON_MapKeyOrder(t *testing.T) {
+	type Route struct {
+		Target string `koanf:"target"`
+	}
+	type Config struct {
+		Limits map[string]int            `koanf:"limits"`
+		Shards map[int]string            `koanf:"shards"`
+		Routes map[string]Route          `koanf:"routes"`
+		Nested map[string]map[string]int `koanf:"nested"`
+	}
+	cfg := Config{
+		Limits: map[string]int{"zeta": 1, "alpha": 2, "mid": 3},
+		Shards: map[int]string{10: "c", 2: "b", 1: "a"},
+		Routes: map[string]Route{"/b": {Target: "b"}, "/a": {Target: "a"}},
+		Nested: map[string]map[string]int{"outer": {"y": 1, "x": 2}},
+	}
+
+	assert.Equal(t, `{"limits":{"alpha":2,"mid":3,"zeta":1},"shards":{"1":"a","10":"c","2":"b"},`+
+		`"routes":{"/a":{"target":"a"},"/b":{"target":"b"}},"nested":{"outer":{"x":2,"y":1}}}`,
+		string(MarshalJSONCompact(cfg)))
+
+	t.Run("same order as ExampleYAML", func(t *testing.T) {
+		yaml := string(ExampleYAML(cfg))
+		for _, keys := range [][]string{{"alpha", "mid", "zeta"}, {"  1: ", "  10: ", "  2: "}} {
+			last := -1
+			for _, key := range keys {
+				idx := strings.Index(yaml, key)
+				assert.Greater(t, idx, last, "%q out of order", key)
+				last = idx
+			}
+		}
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		first := MarshalJSON(cfg)
+		for range 10 {
+			assert.Equal(t, first, MarshalJSON(cfg))
+		}
+	})
+}
+
+// =============================================================================
+// WithDebugConfigEnv 测试
+// =============================================================================
+
+func TestLoadWithDebugConfigEnv(t *testing.T) {
+	defaultCfg := newDumpTestConfig()
+
+	tests := []struct {
+		name    string
+		value   string
+		wantLog bool
+	}{
+		{"unset", "", false},
+		{"falsy", "0", false},
+		{"unparseable", "maybe", false},
+		{"truthy 1", "1", true},
+		{"truthy yes", "yes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_DEBUG_CONFIG", tt.value)
+
+			logger, records := newRecordLogger()
+			_, err := Load(defaultCfg, WithDebugConfigEnv("APP_DEBUG_CONFIG"), WithLogger(logger))
+			require.NoError(t, err)
+
+			logs := records.find("Effective config")
+			if !tt.wantLog {
+				assert.Empty(t, logs)
+
+				return
+			}
+			require.Len(t, logs, 1)
+			assert.Equal(t, "APP_DEBUG_CONFIG", logs[0]["env"])
+			assert.Contains(t, logs[0]["config"], "addr: :8080")
+			assert.Contains(t, logs[0]["config"], redactedValue)
+			assert.NotContains(t, logs[0]["config"], "s3cret")
+		})
+	}
+}
@@ -0,0 +1,603 @@
+package cfgm
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// envBindingSource 环境变量绑定的来源，数值越大优先级越高。
+type envBindingSource int
+
+const (
+	envSourcePrefix  envBindingSource = iota // 前缀自动生成 (WithEnvPrefix)
+	envSourceBindKey                         // 配置文件绑定 (WithEnvBindKey)
+	envSourceCode                            // 代码绑定 (WithEnvBindings)
+)
+
+// String 返回绑定来源的名称，用于日志输出。
+func (s envBindingSource) String() string {
+	switch s {
+	case envSourcePrefix:
+		return "prefix"
+	case envSourceBindKey:
+		return "bindkey"
+	case envSourceCode:
+		return "code"
+	default:
+		return "unknown"
+	}
+}
+
+// envBinding 单个环境变量到配置路径的绑定。
+type envBinding struct {
+	envKey string
+	path   string
+	source envBindingSource
+	order  int // 同一配置路径的应用顺序，越大越晚应用（优先级越高）
+}
+
+// codeEnvBinding 通过代码注册的环境变量绑定，见 [WithEnvBindings]。
+type codeEnvBinding struct {
+	path  string
+	order int // 应用顺序，由优先级层级和注册顺序决定
+}
+
+// 代码绑定的优先级层级，层级内按注册顺序，后注册的优先。
+const (
+	envBindingTierDefault  = -1 // WithEnvBindingsDefault
+	envBindingTierNormal   = 0  // WithEnvBinding、WithEnvBindings
+	envBindingTierOverride = 1  // WithEnvBindingsOverride
+
+	envBindingTierSpan = 1 << 20 // 单个层级内的顺序空间
+)
+
+// addEnvBindings 以指定的优先级层级注册代码绑定。
+//
+// 默认层级的顺序低于正则绑定（-1），普通层级从 0 开始，保证层级之间互不交叉。
+func (o *options) addEnvBindings(bindings map[string]string, tier int) {
+	if o.envBindings == nil {
+		o.envBindings = make(map[string]codeEnvBinding)
+	}
+
+	order := tier*envBindingTierSpan + o.envBindingCalls
+	if tier == envBindingTierDefault {
+		order -= envBindingTierSpan // 保证低于正则绑定
+	}
+	o.envBindingCalls++
+
+	for envKey, path := range bindings {
+		o.envBindings[envKey] = codeEnvBinding{path: path, order: order}
+	}
+}
+
+// envBindingRegex 按正则匹配环境变量名的绑定规则，见 [WithEnvBindingRegex]。
+type envBindingRegex struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// generateRegexEnvBindings 扫描环境变量名，为名称匹配规则的变量生成 env → path 绑定。
+//
+// 多个规则匹配同一环境变量时，先注册的规则生效。
+func generateRegexEnvBindings(envKeys []string, rules []envBindingRegex) map[string]string {
+	bindings := make(map[string]string)
+	for _, envKey := range envKeys {
+		if _, ok := bindings[envKey]; ok {
+			continue
+		}
+		for _, rule := range rules {
+			match := rule.re.FindStringSubmatchIndex(envKey)
+			if match == nil {
+				continue
+			}
+			path := rule.re.ExpandString(nil, rule.replacement, envKey, match)
+			bindings[envKey] = strings.ToLower(string(path))
+
+			break
+		}
+	}
+
+	return bindings
+}
+
+// resolveEnvBindings 汇总所有来源的环境变量绑定，并按优先级解决冲突。
+//
+// 同一配置路径若被多个来源绑定，仅保留优先级最高来源的绑定：
+// 代码绑定 > 配置文件绑定 > 前缀自动生成。
+// 每个配置路径的决议结果以 Debug 级别记录到 logger，便于排查优先级问题。
+//
+// 返回的绑定按配置路径、应用顺序和环境变量名排序，保证应用顺序稳定。
+func resolveEnvBindings(o *options, k *koanf.Koanf, koanfKeys []string) []envBinding {
+	var candidates []envBinding
+
+	prefixKeys := koanfKeys
+	if o.envPrefixKeys != nil {
+		prefixKeys = o.envPrefixKeys
+	}
+	// 靠前的前缀优先，因此排在后面应用
+	for i, prefix := range o.envPrefixes {
+		if prefix == "" {
+			continue
+		}
+		candidates = appendBindings(candidates, generateEnvBindings(prefix, prefixKeys), envSourcePrefix, len(o.envPrefixes)-1-i)
+		o.logger.Debug("Generated auto env bindings", "prefix", prefix, "count", len(prefixKeys))
+	}
+	if o.envBindKey != "" {
+		candidates = appendBindings(candidates, readEnvBindingsFromConfig(k, o.envBindKey, o.logger), envSourceBindKey, 0)
+	}
+	// 正则绑定先于显式绑定应用，同一路径由显式绑定覆盖
+	if len(o.envBindingRegexes) > 0 {
+		candidates = appendBindings(candidates, generateRegexEnvBindings(o.envKeys(), o.envBindingRegexes), envSourceCode, -1)
+	}
+	for _, envKey := range slices.Sorted(maps.Keys(o.envBindings)) {
+		b := o.envBindings[envKey]
+		candidates = append(candidates, envBinding{envKey: envKey, path: b.path, source: envSourceCode, order: b.order})
+	}
+
+	// 计算每个配置路径的最高优先级来源
+	winners := make(map[string]envBindingSource)
+	for _, b := range candidates {
+		if src, ok := winners[b.path]; !ok || b.source > src {
+			winners[b.path] = b.source
+		}
+	}
+
+	var resolved []envBinding
+	for _, b := range candidates {
+		if b.source == winners[b.path] {
+			resolved = append(resolved, b)
+		}
+	}
+	slices.SortFunc(resolved, func(a, b envBinding) int {
+		if a.path != b.path {
+			return cmp.Compare(a.path, b.path)
+		}
+		if a.order != b.order {
+			return cmp.Compare(a.order, b.order)
+		}
+
+		return cmp.Compare(a.envKey, b.envKey)
+	})
+
+	for _, b := range resolved {
+		o.logger.Debug("Resolved env binding", "path", b.path, "env", b.envKey, "source", b.source.String())
+	}
+	warnShadowedEnvBindings(o, candidates, resolved)
+
+	return resolved
+}
+
+// warnShadowedEnvBindings 为已设置但因同一配置路径存在更高优先级绑定而被忽略的环境变量记录 [WarningShadowedEnv]。
+//
+// 未设置的环境变量不会生效，也就不存在被遮蔽的问题，因此不记录。
+func warnShadowedEnvBindings(o *options, candidates, resolved []envBinding) {
+	if o.noEnv {
+		return
+	}
+
+	winners := make(map[string][]string)
+	for _, b := range resolved {
+		winners[b.path] = append(winners[b.path], b.envKey)
+	}
+
+	shadowed := slices.DeleteFunc(slices.Clone(candidates), func(b envBinding) bool {
+		return slices.Contains(resolved, b)
+	})
+	slices.SortFunc(shadowed, func(a, b envBinding) int {
+		return cmp.Or(cmp.Compare(a.path, b.path), cmp.Compare(a.envKey, b.envKey))
+	})
+	for _, b := range shadowed {
+		if _, ok := o.lookupEnv(b.envKey); !ok {
+			continue
+		}
+		o.warn(WarningShadowedEnv, b.path, fmt.Sprintf("env %s (%s binding) is ignored: path is also bound to %s with higher priority",
+			b.envKey, b.source, strings.Join(winners[b.path], ", ")))
+	}
+}
+
+// appendBindings 将 env → path 映射按环境变量名排序后追加为指定来源和应用顺序的绑定。
+func appendBindings(dst []envBinding, bindings map[string]string, source envBindingSource, order int) []envBinding {
+	envKeys := make([]string, 0, len(bindings))
+	for envKey := range bindings {
+		envKeys = append(envKeys, envKey)
+	}
+	slices.Sort(envKeys)
+
+	for _, envKey := range envKeys {
+		dst = append(dst, envBinding{envKey: envKey, path: bindings[envKey], source: source, order: order})
+	}
+
+	return dst
+}
+
+// readEnvBindingsFromConfig 从配置文件的绑定节点读取环境变量绑定。
+//
+// 读取后删除绑定节点，不污染用户配置。
+func readEnvBindingsFromConfig(k *koanf.Koanf, bindKey string, logger *slog.Logger) map[string]string {
+	bindings := k.StringMap(bindKey)
+	if len(bindings) == 0 {
+		return nil
+	}
+
+	k.Delete(bindKey)
+	logger.Debug("Loaded env bindings from config", "key", bindKey, "count", len(bindings))
+
+	return bindings
+}
+
+// validateEnvBindingPaths 校验绑定的目标路径是否对应配置结构体的字段，返回汇总所有无效绑定的错误。
+//
+// 有效路径为叶子 key，或 map 类型叶子 key 下的子 key。
+func validateEnvBindingPaths(bindings []envBinding, fieldTypes map[string]reflect.Type) error {
+	var errs []error
+	for _, b := range bindings {
+		if !isBindablePath(b.path, fieldTypes) {
+			errs = append(errs, fmt.Errorf("env %s is bound to unknown config path %q (%s binding)", b.envKey, b.path, b.source))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isBindablePath 判断配置路径能否作为环境变量绑定的目标。
+func isBindablePath(path string, fieldTypes map[string]reflect.Type) bool {
+	if _, ok := fieldTypes[path]; ok {
+		return true
+	}
+	for key, typ := range fieldTypes {
+		if typ.Kind() == reflect.Map && strings.HasPrefix(path, key+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyEnvBindings 将已设置的环境变量按绑定写入 koanf。
+//
+// fieldTypes 为配置路径到字段类型的映射，数值、bool 和 time.Duration 字段的
+// 环境变量值会先校验格式，无效时返回指明环境变量和配置路径的错误。
+// 写入后调用 [WithEnvAuditFunc] 设置的审计回调。
+func applyEnvBindings(o *options, k *koanf.Koanf, bindings []envBinding, fieldTypes map[string]reflect.Type) error {
+	for _, b := range bindings {
+		val := o.getenv(b.envKey)
+		if val == "" {
+			continue
+		}
+		if o.fillOnlySkips(b) {
+			o.logger.Debug("Skipped env binding, key set by config file", "env", b.envKey, "path", b.path)
+
+			continue
+		}
+		if typ, ok := fieldTypes[b.path]; ok && o.prevalidates(typ) {
+			if typeName, err := validateEnvValue(val, typ); err != nil {
+				if errors.Is(err, strconv.ErrRange) {
+					return fmt.Errorf("env %s=%q overflows %s for %s", b.envKey, val, typeName, b.path)
+				}
+				if errors.Is(err, errDurationCommaGrouping) {
+					return fmt.Errorf("env %s for %s: %w", b.envKey, b.path, err)
+				}
+
+				return fmt.Errorf("env %s=%q is not a valid %s for %s", b.envKey, val, typeName, b.path)
+			}
+		}
+
+		_ = k.Set(b.path, val)
+		if o.enforceEnvRequired {
+			if o.envSetPaths == nil {
+				o.envSetPaths = make(map[string]bool)
+			}
+			o.envSetPaths[b.path] = true
+		}
+		o.logger.Debug("Loaded env binding", "env", b.envKey, "path", b.path, "source", b.source.String())
+		if o.envAuditFunc != nil {
+			o.envAuditFunc(b.envKey, b.path)
+		}
+	}
+
+	return nil
+}
+
+// fillOnlySkips 判断前缀绑定是否因 [WithEnvFillOnly] 被跳过：配置文件已设置该 key，
+// 且启用 [WithEnforceEnvRequired] 时该 key 不要求由环境变量提供。
+func (o *options) fillOnlySkips(b envBinding) bool {
+	return b.source == envSourcePrefix && o.envFillOnly && o.setByFile(b.path) && !o.envRequiredPaths[b.path]
+}
+
+// envRequiredKeys 返回带 envrequired:"true" 标签的叶子 key 集合。
+func envRequiredKeys(typ reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for _, leaf := range koanfTypeInfoFor(typ).leaves {
+		if required, err := strconv.ParseBool(leaf.field.Tag.Get("envrequired")); err == nil && required {
+			keys[leaf.key] = true
+		}
+	}
+
+	return keys
+}
+
+// checkEnvRequired 校验带 envrequired:"true" 标签的字段均已由环境变量绑定写入，见 [WithEnforceEnvRequired]。
+//
+// 返回汇总所有缺失字段的错误，每个错误都包装了 [ErrEnvRequired]。
+func checkEnvRequired[T any](o *options, defaultConfig T, bindings []envBinding) error {
+	var errs []error
+	for _, leaf := range koanfTypeInfoFor(reflect.TypeOf(defaultConfig)).leaves {
+		if !o.envRequiredPaths[leaf.key] || o.envSetPaths[leaf.key] {
+			continue
+		}
+
+		var envKeys []string
+		for _, b := range bindings {
+			if b.path == leaf.key {
+				envKeys = append(envKeys, b.envKey)
+			}
+		}
+		if len(envKeys) == 0 {
+			errs = append(errs, fmt.Errorf("%w: %s has no env binding", ErrEnvRequired, leaf.key))
+		} else {
+			errs = append(errs, fmt.Errorf("%w: %s must be set via env %s", ErrEnvRequired, leaf.key, strings.Join(envKeys, " or ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// recordFileKeys 记录配置文件设置的 key，供 [WithEnvFillOnly] 判断前缀绑定是否生效。
+func (o *options) recordFileKeys(path string, content []byte) {
+	fileK := koanf.New(".")
+	if err := loadConfigContent(o, fileK, path, content); err == nil {
+		o.fileKeys = fileK.Keys()
+	}
+}
+
+// setByFile 判断配置路径是否由配置文件设置，路径下的子 key（如 map 的条目）被设置时同样视为已设置。
+func (o *options) setByFile(path string) bool {
+	return slices.ContainsFunc(o.fileKeys, func(key string) bool {
+		return key == path || strings.HasPrefix(key, path+".")
+	})
+}
+
+// lookupEnv 读取环境变量，启用 [WithEnvSnapshot] 时从快照读取。
+func (o *options) lookupEnv(key string) (string, bool) {
+	if o.env != nil {
+		val, ok := o.env[key]
+
+		return val, ok
+	}
+
+	return os.LookupEnv(key)
+}
+
+// getenv 读取环境变量，未设置时返回空字符串，规则同 lookupEnv。
+func (o *options) getenv(key string) string {
+	val, _ := o.lookupEnv(key)
+
+	return val
+}
+
+// envKeys 返回所有环境变量名，启用 [WithEnvSnapshot] 时从快照读取。
+func (o *options) envKeys() []string {
+	if o.env != nil {
+		return slices.Collect(maps.Keys(o.env))
+	}
+
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// prevalidates 判断是否在写入 koanf 前按字段类型校验环境变量和 Docker secret 的值。
+//
+// 注册了 [WithDecodeHook] 时，自定义的命名类型（如 type Level int）可能由钩子从字符串转换，
+// 不做预校验，交由解码处理。
+func (o *options) prevalidates(typ reflect.Type) bool {
+	if len(o.decodeHooks) == 0 {
+		return true
+	}
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return typ.PkgPath() == "" || typ == reflect.TypeFor[time.Duration]()
+}
+
+// validateEnvValue 按字段类型校验环境变量字符串，与解码时的转换规则保持一致。
+//
+// 仅校验数值、bool 和 time.Duration，其他类型交由解码处理。
+// 返回期望的类型名称和解析错误；数值超出字段类型的位宽时错误包装 strconv.ErrRange。
+func validateEnvValue(val string, typ reflect.Type) (string, error) {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == reflect.TypeFor[time.Duration]() {
+		_, err := parseDuration(val)
+
+		return "duration", err
+	}
+
+	var err error
+	switch typ.Kind() {
+	case reflect.Bool:
+		_, err = parseBool(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(val, 0, typ.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(val, 0, typ.Bits())
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(val, typ.Bits())
+	}
+
+	return typ.Kind().String(), err
+}
+
+// ActiveEnvOverrides 返回当前正在覆盖配置的环境变量，格式为 环境变量名 → 配置路径。
+//
+// 按与 [Load] 相同的规则汇总所有绑定（前缀、配置文件绑定、代码绑定），
+// 只返回已设置且非空、并且最终生效的环境变量：被更高优先级绑定遮蔽的变量不会出现。
+// 不执行完整加载；仅在使用 [WithEnvBindKey] 或 [WithEnvFillOnly] 时读取配置文件以获取其中的绑定
+// 和已设置的 key，读取失败时忽略配置文件。
+// 使用 [WithNoEnv] 时返回空 map。
+//
+// 示例：
+//
+//	for env, path := range cfgm.ActiveEnvOverrides(DefaultConfig(), cfgm.WithEnvPrefix("APP_")) {
+//	    fmt.Printf("%s → %s\n", env, path)
+//	}
+func ActiveEnvOverrides[T any](defaultConfig T, opts ...Option) map[string]string {
+	o := newOptions(2, opts)
+	active := make(map[string]string)
+	if o.noEnv {
+		return active
+	}
+
+	if o.enforceEnvRequired {
+		o.envRequiredPaths = envRequiredKeys(reflect.TypeOf(defaultConfig))
+	}
+
+	k := koanf.New(".")
+	if o.envBindKey != "" || o.envFillOnly {
+		if path, content, err := findConfigFile(o); err == nil && path != "" {
+			_ = loadConfigContent(o, k, path, content)
+			o.fileKeys = k.Keys()
+		}
+	}
+
+	// 同一配置路径的绑定按应用顺序排列，最后一个已设置的变量生效
+	effective := make(map[string]string)
+	for _, b := range resolveEnvBindings(o, k, collectKoanfKeys(defaultConfig)) {
+		if o.fillOnlySkips(b) {
+			continue
+		}
+		if o.getenv(b.envKey) != "" {
+			effective[b.path] = b.envKey
+		}
+	}
+	for path, envKey := range effective {
+		active[envKey] = path
+	}
+
+	return active
+}
+
+// MarshalEnv 将配置序列化为 shell export 语句，是环境变量绑定的逆操作。
+//
+// 环境变量名与 [WithEnvPrefix] 自动生成的绑定规则一致，值使用单引号转义，
+// 输出可直接 source 以复现当前配置：
+//
+//	export APP_SERVER_ADDR=':8080'
+//	export APP_SERVER_TIMEOUT='30s'
+//
+// 切片和 map 无法通过单个环境变量表达，输出为注释行；nil 指针字段不输出。
+//
+// 使用示例：
+//
+//	os.WriteFile(".env.sh", cfgm.MarshalEnv(*cfg, "APP_"), 0600)
+func MarshalEnv[T any](cfg T, prefix string) []byte {
+	var buf bytes.Buffer
+	marshalEnvRecursive(&buf, reflect.ValueOf(cfg), prefix, "")
+
+	return buf.Bytes()
+}
+
+// marshalEnvRecursive 递归输出结构体叶子字段的 export 语句。
+func marshalEnvRecursive(buf *bytes.Buffer, val reflect.Value, prefix, keyPrefix string) {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		koanfKey := field.Tag.Get("koanf")
+		if koanfKey == "" || !field.IsExported() {
+			continue
+		}
+
+		fullKey := koanfKey
+		if keyPrefix != "" {
+			fullKey = keyPrefix + "." + koanfKey
+		}
+
+		fieldVal := val.Field(i)
+		if isNestedStruct(field.Type) {
+			marshalEnvRecursive(buf, fieldVal, prefix, fullKey)
+
+			continue
+		}
+
+		envKey := envKeyFor(prefix, fullKey)
+		if fieldVal.Kind() == reflect.Pointer {
+			if fieldVal.IsNil() {
+				continue
+			}
+			fieldVal = fieldVal.Elem()
+		}
+
+		value, ok := envValue(fieldVal)
+		if !ok {
+			fmt.Fprintf(buf, "# %s: %s value cannot be expressed as env, skipped\n", envKey, fieldVal.Kind())
+
+			continue
+		}
+		fmt.Fprintf(buf, "export %s=%s\n", envKey, shellQuote(value))
+	}
+}
+
+// envValue 将标量字段格式化为可被加载流程解析回原值的字符串。
+//
+// 切片、map 等复合类型返回 false。
+func envValue(val reflect.Value) (string, bool) {
+	// time.Time 等实现了 TextMarshaler 的类型使用其文本格式
+	if val.CanInterface() {
+		if m, ok := val.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			if err != nil {
+				return "", false
+			}
+
+			return string(text), true
+		}
+	}
+
+	switch val.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// time.Duration 通过 Stringer 输出为 "30s" 格式
+		return fmt.Sprint(val.Interface()), true
+	default:
+		return "", false
+	}
+}
+
+// shellQuote 使用单引号转义字符串，结果可安全用于 POSIX shell。
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,782 @@
+package cfgm
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+// =============================================================================
+// 测试辅助函数
+// =============================================================================
+
+// recordHandler 记录所有日志的 slog.Handler，用于断言日志输出。
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+// find 返回消息匹配的所有记录的属性。
+func (h *recordHandler) find(msg string) []map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var found []map[string]string
+	for _, r := range h.records {
+		if r.Message != msg {
+			continue
+		}
+		attrs := make(map[string]string)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value.String()
+
+			return true
+		})
+		found = append(found, attrs)
+	}
+
+	return found
+}
+
+// newRecordLogger 创建记录日志的 logger。
+func newRecordLogger() (*slog.Logger, *recordHandler) {
+	h := &recordHandler{}
+
+	return slog.New(h), h
+}
+
+// newKoanfWith 创建加载了指定数据的 koanf 实例。
+func newKoanfWith(t *testing.T, data map[string]any) *koanf.Koanf {
+	t.Helper()
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(data, ""), nil))
+
+	return k
+}
+
+// =============================================================================
+// 环境变量绑定决议测试
+// =============================================================================
+
+func TestEnvBindingPrecedenceLogging(t *testing.T) {
+	type Config struct {
+		Password string `koanf:"password"`
+		Name     string `koanf:"name"`
+	}
+
+	tmpFile := writeTempConfig(t, `
+envbind:
+  FILE_PWD: password
+password: "file-value"
+`)
+
+	t.Setenv("APP_PASSWORD", "from-prefix")
+	t.Setenv("FILE_PWD", "from-file-binding")
+	t.Setenv("CODE_PWD", "from-code-binding")
+
+	logger, records := newRecordLogger()
+	cfg, err := Load(
+		Config{Password: "default", Name: "default"},
+		WithConfigPaths(tmpFile),
+		WithEnvPrefix("APP_"),
+		WithEnvBindKey("envbind"),
+		WithEnvBinding("CODE_PWD", "password"),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "from-code-binding", cfg.Password)
+
+	var passwordRecords []map[string]string
+	for _, attrs := range records.find("Resolved env binding") {
+		if attrs["path"] == "password" {
+			passwordRecords = append(passwordRecords, attrs)
+		}
+	}
+	require.Len(t, passwordRecords, 1, "one record per path")
+	assert.Equal(t, "code", passwordRecords[0]["source"])
+	assert.Equal(t, "CODE_PWD", passwordRecords[0]["env"])
+}
+
+func TestResolveEnvBindings(t *testing.T) {
+	k := newKoanfWith(t, map[string]any{
+		"envbind": map[string]any{"FILE_NAME": "name", "FILE_PORT": "port"},
+	})
+
+	o := &options{
+		logger:      slog.Default(),
+		envPrefixes: []string{"APP_"},
+		envBindKey:  "envbind",
+		envBindings: map[string]codeEnvBinding{"CODE_NAME": {path: "name"}},
+	}
+	bindings := resolveEnvBindings(o, k, []string{"name", "port", "debug"})
+
+	assert.Equal(t, []envBinding{
+		{envKey: "APP_DEBUG", path: "debug", source: envSourcePrefix},
+		{envKey: "CODE_NAME", path: "name", source: envSourceCode},
+		{envKey: "FILE_PORT", path: "port", source: envSourceBindKey},
+	}, bindings)
+	assert.False(t, k.Exists("envbind"), "bind key node should be removed")
+}
+
+func TestLoadWithEnvPrefixMulti(t *testing.T) {
+	type Config struct {
+		Port int    `koanf:"port"`
+		Name string `koanf:"name"`
+	}
+	defaultCfg := Config{Port: 8080, Name: "default"}
+
+	t.Run("earlier prefix wins", func(t *testing.T) {
+		t.Setenv("NEWAPP_PORT", "9001")
+		t.Setenv("OLDAPP_PORT", "9002")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefixMulti("NEWAPP_", "OLDAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, 9001, cfg.Port)
+	})
+
+	t.Run("new prefix alone", func(t *testing.T) {
+		t.Setenv("NEWAPP_NAME", "new")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefixMulti("NEWAPP_", "OLDAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, "new", cfg.Name)
+	})
+
+	t.Run("old prefix alone", func(t *testing.T) {
+		t.Setenv("OLDAPP_PORT", "9002")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefixMulti("NEWAPP_", "OLDAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, 9002, cfg.Port)
+	})
+}
+
+func TestLoadWithEnvPrefixExplicit(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Addr string `koanf:"addr"`
+			Port int    `koanf:"port"`
+		} `koanf:"server"`
+	}
+	defaultCfg := Config{}
+	defaultCfg.Server.Addr = "localhost"
+	defaultCfg.Server.Port = 8080
+
+	t.Run("only listed keys are bound", func(t *testing.T) {
+		t.Setenv("MYAPP_SERVER_ADDR", "0.0.0.0")
+		t.Setenv("MYAPP_SERVER_PORT", "9090")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefixExplicit("MYAPP_", "server.addr"))
+		require.NoError(t, err)
+		assert.Equal(t, "0.0.0.0", cfg.Server.Addr)
+		assert.Equal(t, 8080, cfg.Server.Port, "unlisted key ignores prefixed env")
+	})
+
+	t.Run("no keys binds nothing", func(t *testing.T) {
+		t.Setenv("MYAPP_SERVER_ADDR", "0.0.0.0")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefixExplicit("MYAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", cfg.Server.Addr)
+	})
+
+	t.Run("later WithEnvPrefix binds all keys", func(t *testing.T) {
+		t.Setenv("MYAPP_SERVER_PORT", "9090")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefixExplicit("MYAPP_", "server.addr"), WithEnvPrefix("MYAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+	})
+}
+
+func TestLoadWithEnvFillOnly(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Addr string `koanf:"addr"`
+			Port int    `koanf:"port"`
+		} `koanf:"server"`
+		Labels map[string]string `koanf:"labels"`
+	}
+	defaultCfg := Config{}
+	defaultCfg.Server.Addr = "localhost"
+	defaultCfg.Server.Port = 8080
+
+	tmpFile := writeTempConfig(t, "server:\n  port: 9090\nlabels:\n  env: file\n")
+	opts := []Option{WithConfigPaths(tmpFile), WithEnvPrefix("MYAPP_"), WithEnvFillOnly()}
+
+	t.Run("file value wins over prefix env", func(t *testing.T) {
+		t.Setenv("MYAPP_SERVER_PORT", "7070")
+		t.Setenv("MYAPP_LABELS", "env=env")
+
+		cfg, err := Load(defaultCfg, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+		assert.Equal(t, map[string]string{"env": "file"}, cfg.Labels)
+	})
+
+	t.Run("prefix env fills unset key", func(t *testing.T) {
+		t.Setenv("MYAPP_SERVER_ADDR", "0.0.0.0")
+
+		cfg, err := Load(defaultCfg, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, "0.0.0.0", cfg.Server.Addr)
+	})
+
+	t.Run("explicit binding keeps priority", func(t *testing.T) {
+		t.Setenv("PORT", "6060")
+
+		cfg, err := Load(defaultCfg, append(opts, WithEnvBinding("PORT", "server.port"))...)
+		require.NoError(t, err)
+		assert.Equal(t, 6060, cfg.Server.Port)
+	})
+
+	t.Run("active overrides exclude skipped env", func(t *testing.T) {
+		t.Setenv("MYAPP_SERVER_PORT", "7070")
+		t.Setenv("MYAPP_SERVER_ADDR", "0.0.0.0")
+
+		assert.Equal(t, map[string]string{"MYAPP_SERVER_ADDR": "server.addr"}, ActiveEnvOverrides(defaultCfg, opts...))
+	})
+
+	t.Run("without option env overrides file", func(t *testing.T) {
+		t.Setenv("MYAPP_SERVER_PORT", "7070")
+
+		cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile), WithEnvPrefix("MYAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, 7070, cfg.Server.Port)
+	})
+}
+
+func TestLoadWithEnvSnapshot(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+		Mode string `koanf:"mode"`
+	}
+	tmpFile := writeTempConfig(t, "name: '{{env \"TPL_NAME\"}}'\n")
+
+	// 配置文件加载后（模板已展开、环境变量尚未应用）修改环境变量，模拟并发修改
+	mutate := WithHooks(Hooks{OnFileLoaded: func(string) {
+		t.Setenv("SNAP_PORT", "9090")
+		t.Setenv("MODE", "after")
+	}})
+	opts := []Option{WithConfigPaths(tmpFile), WithEnvPrefix("SNAP_"), WithEnvBinding("MODE", "mode"), mutate}
+
+	t.Run("snapshot used uniformly", func(t *testing.T) {
+		t.Setenv("TPL_NAME", "before")
+		t.Setenv("SNAP_PORT", "8080")
+		t.Setenv("MODE", "before")
+
+		cfg, err := Load(Config{}, append(opts, WithEnvSnapshot())...)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "before", Port: 8080, Mode: "before"}, *cfg)
+	})
+
+	t.Run("without snapshot reads live env", func(t *testing.T) {
+		t.Setenv("TPL_NAME", "before")
+		t.Setenv("SNAP_PORT", "8080")
+		t.Setenv("MODE", "before")
+
+		cfg, err := Load(Config{}, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "before", Port: 9090, Mode: "after"}, *cfg)
+	})
+}
+
+func TestLoadWithEnforceEnvRequired(t *testing.T) {
+	type Config struct {
+		Name     string `koanf:"name"`
+		Password string `koanf:"password" envrequired:"true"`
+		Token    string `koanf:"token" envrequired:"true"`
+	}
+
+	tmpFile := writeTempConfig(t, "password: from-file\n")
+	opts := []Option{WithConfigPaths(tmpFile), WithEnvPrefix("MYAPP_"), WithEnvBinding("API_TOKEN", "token"), WithEnforceEnvRequired()}
+
+	t.Run("supplied via env passes", func(t *testing.T) {
+		t.Setenv("MYAPP_PASSWORD", "from-env")
+		t.Setenv("API_TOKEN", "tok")
+
+		cfg, err := Load(Config{}, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", cfg.Password)
+		assert.Equal(t, "tok", cfg.Token)
+	})
+
+	t.Run("file or default value is rejected", func(t *testing.T) {
+		_, err := Load(Config{Token: "default"}, opts...)
+		require.ErrorIs(t, err, ErrEnvRequired)
+		assert.Contains(t, err.Error(), "password must be set via env MYAPP_PASSWORD")
+		assert.Contains(t, err.Error(), "token must be set via env API_TOKEN")
+	})
+
+	t.Run("field without binding", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigPaths(tmpFile), WithEnvBinding("API_TOKEN", "token"), WithEnforceEnvRequired())
+		require.ErrorIs(t, err, ErrEnvRequired)
+		assert.Contains(t, err.Error(), "password has no env binding")
+	})
+
+	t.Run("not enforced without option", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithEnvPrefix("MYAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, "from-file", cfg.Password)
+	})
+
+	t.Run("fill-only does not skip env-required fields", func(t *testing.T) {
+		t.Setenv("MYAPP_PASSWORD", "from-env")
+		t.Setenv("API_TOKEN", "tok")
+
+		cfg, err := Load(Config{}, append(opts, WithEnvFillOnly())...)
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", cfg.Password)
+		assert.Equal(t, map[string]string{"MYAPP_PASSWORD": "password", "API_TOKEN": "token"},
+			ActiveEnvOverrides(Config{}, append(opts, WithEnvFillOnly())...))
+	})
+
+	t.Run("conflicts with WithNoEnv", func(t *testing.T) {
+		t.Setenv("MYAPP_PASSWORD", "from-env")
+
+		_, err := Load(Config{}, append(opts, WithNoEnv())...)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrEnvRequired)
+		assert.Contains(t, err.Error(), "WithEnforceEnvRequired cannot be used with WithNoEnv")
+	})
+}
+
+func TestActiveEnvOverrides(t *testing.T) {
+	type Config struct {
+		Host  string `koanf:"host"`
+		Port  int    `koanf:"port"`
+		Debug bool   `koanf:"debug"`
+	}
+	opts := []Option{WithEnvBindings(map[string]string{
+		"SVC_HOST":  "host",
+		"SVC_PORT":  "port",
+		"SVC_DEBUG": "debug",
+	})}
+
+	t.Run("only set vars", func(t *testing.T) {
+		t.Setenv("SVC_HOST", "db.local")
+		t.Setenv("SVC_PORT", "5432")
+		t.Setenv("SVC_DEBUG", "")
+
+		assert.Equal(t, map[string]string{
+			"SVC_HOST": "host",
+			"SVC_PORT": "port",
+		}, ActiveEnvOverrides(Config{}, opts...))
+	})
+
+	t.Run("shadowed prefix var excluded", func(t *testing.T) {
+		t.Setenv("APP_HOST", "from-prefix")
+		t.Setenv("SVC_HOST", "from-binding")
+		t.Setenv("APP_DEBUG", "true")
+
+		// 与 Load 一致：debug 存在代码绑定 SVC_DEBUG，即使其未设置，APP_DEBUG 也不生效
+		assert.Equal(t, map[string]string{"SVC_HOST": "host"},
+			ActiveEnvOverrides(Config{}, append(opts, WithEnvPrefix("APP_"))...))
+
+		cfg, err := Load(Config{}, append(opts, WithEnvPrefix("APP_"))...)
+		require.NoError(t, err)
+		assert.False(t, cfg.Debug)
+	})
+
+	t.Run("coalesce reports the effective var", func(t *testing.T) {
+		t.Setenv("PRIMARY_HOST", "a")
+		t.Setenv("FALLBACK_HOST", "b")
+
+		assert.Equal(t, map[string]string{"PRIMARY_HOST": "host"},
+			ActiveEnvOverrides(Config{}, WithEnvBindingCoalesce("host", "PRIMARY_HOST", "FALLBACK_HOST")))
+	})
+
+	t.Run("no env", func(t *testing.T) {
+		t.Setenv("SVC_HOST", "db.local")
+
+		assert.Empty(t, ActiveEnvOverrides(Config{}, append(opts, WithNoEnv())...))
+	})
+}
+
+func TestMarshalEnv(t *testing.T) {
+	type Server struct {
+		Addr    string        `koanf:"addr"`
+		Timeout time.Duration `koanf:"idle-timeout"`
+	}
+	type Config struct {
+		Name    string   `koanf:"name"`
+		Debug   bool     `koanf:"debug"`
+		Workers *int     `koanf:"workers"`
+		Tags    []string `koanf:"tags"`
+		Server  Server   `koanf:"server"`
+		Ignored string
+	}
+
+	cfg := Config{
+		Name:   "it's",
+		Debug:  true,
+		Tags:   []string{"a", "b"},
+		Server: Server{Addr: ":8080", Timeout: 30 * time.Second},
+	}
+
+	assert.Equal(t, `export APP_NAME='it'\''s'
+export APP_DEBUG='true'
+# APP_TAGS: slice value cannot be expressed as env, skipped
+export APP_SERVER_ADDR=':8080'
+export APP_SERVER_IDLE_TIMEOUT='30s'
+`, string(MarshalEnv(cfg, "APP_")))
+}
+
+func TestMarshalEnv_RoundTrip(t *testing.T) {
+	type Server struct {
+		Addr    string        `koanf:"addr"`
+		Timeout time.Duration `koanf:"timeout"`
+	}
+	type Config struct {
+		Debug  bool   `koanf:"debug"`
+		Port   int    `koanf:"port"`
+		Server Server `koanf:"server"`
+	}
+
+	want := Config{Debug: true, Port: 9000, Server: Server{Addr: "0.0.0.0:80", Timeout: time.Minute}}
+	for line := range strings.Lines(string(MarshalEnv(want, "RT_"))) {
+		key, value, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		require.True(t, ok)
+		t.Setenv(key, strings.Trim(value, "'"))
+	}
+
+	got, err := Load(Config{}, WithEnvPrefix("RT_"))
+	require.NoError(t, err)
+	assert.Equal(t, want, *got)
+}
+
+func TestLoadInvalidEnvValue(t *testing.T) {
+	type Server struct {
+		Port    int           `koanf:"port"`
+		Timeout time.Duration `koanf:"timeout"`
+	}
+	type Config struct {
+		Server Server `koanf:"server"`
+		Debug  bool   `koanf:"debug"`
+	}
+
+	tests := []struct {
+		name    string
+		env     string
+		value   string
+		wantErr string
+	}{
+		{"invalid int", "APP_SERVER_PORT", "abc", `env APP_SERVER_PORT="abc" is not a valid int for server.port`},
+		{"invalid duration", "APP_SERVER_TIMEOUT", "5 minutes", `env APP_SERVER_TIMEOUT="5 minutes" is not a valid duration for server.timeout`},
+		{"invalid bool", "APP_DEBUG", "maybe", `env APP_DEBUG="maybe" is not a valid bool for debug`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+
+			_, err := Load(Config{}, WithEnvPrefix("APP_"))
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+
+	t.Run("valid values", func(t *testing.T) {
+		t.Setenv("APP_SERVER_PORT", "0x1F90")
+		t.Setenv("APP_SERVER_TIMEOUT", "5m")
+
+		cfg, err := Load(Config{}, WithEnvPrefix("APP_"))
+		require.NoError(t, err)
+		assert.Equal(t, 8080, cfg.Server.Port)
+		assert.Equal(t, 5*time.Minute, cfg.Server.Timeout)
+	})
+}
+
+func TestLoadWithEnvBindingRegex(t *testing.T) {
+	type Config struct {
+		Features map[string]bool `koanf:"features"`
+		Limits   struct {
+			Read  int `koanf:"read"`
+			Write int `koanf:"write"`
+		} `koanf:"limits"`
+	}
+	defaultCfg := Config{Features: map[string]bool{}}
+
+	t.Run("feature flags", func(t *testing.T) {
+		t.Setenv("FEATURE_BETA", "on")
+		t.Setenv("FEATURE_DARK_MODE", "false")
+
+		cfg, err := Load(defaultCfg, WithEnvBindingRegex(`^FEATURE_(.+)$`, "features.$1"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"beta": true, "dark_mode": false}, cfg.Features)
+	})
+
+	t.Run("named groups and validation", func(t *testing.T) {
+		t.Setenv("LIMIT_READ_QPS", "100")
+		t.Setenv("LIMIT_WRITE_QPS", "abc")
+
+		_, err := Load(defaultCfg, WithEnvBindingRegex(`^LIMIT_(?P<op>[A-Z]+)_QPS$`, "limits.${op}"))
+		require.Error(t, err)
+		assert.Equal(t, `env LIMIT_WRITE_QPS="abc" is not a valid int for limits.write`, err.Error())
+	})
+
+	t.Run("explicit binding wins", func(t *testing.T) {
+		t.Setenv("LIMIT_READ_QPS", "100")
+		t.Setenv("READ_LIMIT", "200")
+
+		cfg, err := Load(defaultCfg,
+			WithEnvBindingRegex(`^LIMIT_([A-Z]+)_QPS$`, "limits.$1"),
+			WithEnvBinding("READ_LIMIT", "limits.read"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 200, cfg.Limits.Read)
+	})
+
+	t.Run("invalid pattern returns error", func(t *testing.T) {
+		var opt Option
+		require.NotPanics(t, func() { opt = WithEnvBindingRegex(`(`, "x") })
+
+		_, err := Load(defaultCfg, opt)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid env binding regex "("`)
+	})
+}
+
+func TestLoadWithStrictBindings(t *testing.T) {
+	type Config struct {
+		Name   string            `koanf:"name"`
+		Labels map[string]string `koanf:"labels"`
+		Server struct {
+			Port int `koanf:"port"`
+		} `koanf:"server"`
+	}
+	defaultCfg := Config{Labels: map[string]string{}}
+
+	t.Run("valid bindings pass", func(t *testing.T) {
+		t.Setenv("PORT", "9000")
+		t.Setenv("ENV_LABEL", "prod")
+
+		cfg, err := Load(defaultCfg,
+			WithEnvPrefix("APP_"),
+			WithEnvBinding("PORT", "server.port"),
+			WithEnvBinding("ENV_LABEL", "labels.env"),
+			WithStrictBindings(),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 9000, cfg.Server.Port)
+		assert.Equal(t, map[string]string{"env": "prod"}, cfg.Labels)
+	})
+
+	t.Run("typo path errors", func(t *testing.T) {
+		_, err := Load(defaultCfg,
+			WithEnvBindings(map[string]string{"PORT": "server.prot", "SRV": "server"}),
+			WithStrictBindings(),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `env PORT is bound to unknown config path "server.prot" (code binding)`)
+		assert.Contains(t, err.Error(), `env SRV is bound to unknown config path "server" (code binding)`)
+	})
+
+	t.Run("config file binding", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "envbind:\n  MY_NAME: nmae\n")
+
+		_, err := Load(defaultCfg, WithConfigPaths(tmpFile), WithEnvBindKey("envbind"), WithStrictBindings())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"nmae" (bindkey binding)`)
+	})
+
+	t.Run("ignored without option", func(t *testing.T) {
+		_, err := Load(defaultCfg, WithEnvBinding("PORT", "server.prot"))
+		require.NoError(t, err)
+	})
+}
+
+func TestLoadNumericOverflow(t *testing.T) {
+	type Config struct {
+		Level int8   `koanf:"level"`
+		Port  uint16 `koanf:"port"`
+	}
+
+	t.Run("env", func(t *testing.T) {
+		tests := []struct {
+			env, value, wantErr string
+		}{
+			{"APP_LEVEL", "128", `env APP_LEVEL="128" overflows int8 for level`},
+			{"APP_LEVEL", "-129", `env APP_LEVEL="-129" overflows int8 for level`},
+			{"APP_PORT", "70000", `env APP_PORT="70000" overflows uint16 for port`},
+			{"APP_PORT", "99999999999999999999", `env APP_PORT="99999999999999999999" overflows uint16 for port`},
+			{"APP_PORT", "-1", `env APP_PORT="-1" is not a valid uint16 for port`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+				t.Setenv(tt.env, tt.value)
+
+				_, err := Load(Config{}, WithEnvPrefix("APP_"))
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+			})
+		}
+	})
+
+	t.Run("cli", func(t *testing.T) {
+		tests := []struct {
+			args    []string
+			wantErr string
+		}{
+			{[]string{"test", "--level", "300"}, "CLI flag --level: value 300 overflows int8 for level"},
+			{[]string{"test", "--port", "70000"}, "CLI flag --port: value 70000 overflows uint16 for port"},
+			{[]string{"test", "--port", "-1"}, "CLI flag --port: value -1 overflows uint16 for port"},
+		}
+		for _, tt := range tests {
+			t.Run(strings.Join(tt.args[1:], " "), func(t *testing.T) {
+				cmd := &cli.Command{
+					Name:  "test",
+					Flags: []cli.Flag{&cli.IntFlag{Name: "level"}, &cli.IntFlag{Name: "port"}},
+					Action: func(_ context.Context, cmd *cli.Command) error {
+						_, err := Load(Config{}, WithCommand(cmd))
+
+						return err
+					},
+				}
+				err := cmd.Run(context.Background(), tt.args)
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+			})
+		}
+	})
+
+	t.Run("cli in range with wider flag type", func(t *testing.T) {
+		flags := []cli.Flag{&cli.IntFlag{Name: "level"}, &cli.Uint64Flag{Name: "port"}}
+		cfg := runCLITest(t, Config{}, flags, []string{"test", "--level", "-5", "--port", "8080"})
+		assert.Equal(t, Config{Level: -5, Port: 8080}, *cfg)
+	})
+}
+
+func TestReadEnvBindingsFromConfigNested(t *testing.T) {
+	k := newKoanfWith(t, map[string]any{
+		"name": "app",
+		"meta": map[string]any{
+			"envbind": map[string]any{"APP_PWD": "password"},
+		},
+	})
+
+	logger, _ := newRecordLogger()
+	bindings := readEnvBindingsFromConfig(k, "meta.envbind", logger)
+
+	assert.Equal(t, map[string]string{"APP_PWD": "password"}, bindings)
+	assert.False(t, k.Exists("meta.envbind"))
+	assert.False(t, k.Exists("meta"), "empty parent node should be removed")
+	assert.Equal(t, "app", k.String("name"))
+}
+
+func TestLoadWithEnvAuditFunc(t *testing.T) {
+	type Config struct {
+		Name     string `koanf:"name"`
+		Password string `koanf:"password"`
+		Token    string `koanf:"token"`
+		Server   struct {
+			Port int `koanf:"port"`
+		} `koanf:"server"`
+	}
+
+	t.Setenv("APP_PASSWORD", "secret")
+	t.Setenv("APP_SERVER_PORT", "9090")
+	t.Setenv("API_TOKEN", "")
+
+	var audited [][2]string
+	cfg, err := Load(Config{Name: "app"},
+		WithEnvPrefix("APP_"),
+		WithEnvBinding("API_TOKEN", "token"),
+		WithEnvAuditFunc(func(envKey, configPath string) {
+			audited = append(audited, [2]string{envKey, configPath})
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", cfg.Password)
+	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, [][2]string{
+		{"APP_PASSWORD", "password"},
+		{"APP_SERVER_PORT", "server.port"},
+	}, audited)
+
+	t.Run("not called with WithNoEnv", func(t *testing.T) {
+		called := false
+		_, err := Load(Config{}, WithEnvPrefix("APP_"), WithNoEnv(), WithEnvAuditFunc(func(string, string) { called = true }))
+		require.NoError(t, err)
+		assert.False(t, called)
+	})
+}
+
+func TestLoadWithEnvBindingsPrecedence(t *testing.T) {
+	type Config struct {
+		Redis struct {
+			URL string `koanf:"url"`
+		} `koanf:"redis"`
+	}
+	lib := map[string]string{"REDIS_URL": "redis.url"}
+	app := map[string]string{"APP_REDIS_URL": "redis.url"}
+
+	load := func(t *testing.T, opts ...Option) string {
+		t.Helper()
+		cfg, err := Load(Config{}, opts...)
+		require.NoError(t, err)
+
+		return cfg.Redis.URL
+	}
+
+	t.Run("last call wins", func(t *testing.T) {
+		t.Setenv("REDIS_URL", "redis://lib")
+		t.Setenv("APP_REDIS_URL", "redis://app")
+
+		assert.Equal(t, "redis://app", load(t, WithEnvBindings(lib), WithEnvBindings(app)))
+		assert.Equal(t, "redis://lib", load(t, WithEnvBindings(app), WithEnvBindings(lib)))
+		assert.Equal(t, "redis://lib", load(t, WithEnvBindings(app), WithEnvBinding("REDIS_URL", "redis.url")))
+	})
+
+	t.Run("default is overridden regardless of order", func(t *testing.T) {
+		t.Setenv("REDIS_URL", "redis://lib")
+		t.Setenv("APP_REDIS_URL", "redis://app")
+
+		assert.Equal(t, "redis://app", load(t, WithEnvBindingsDefault(lib), WithEnvBindings(app)))
+		assert.Equal(t, "redis://app", load(t, WithEnvBindings(app), WithEnvBindingsDefault(lib)))
+	})
+
+	t.Run("default applies when only its env is set", func(t *testing.T) {
+		t.Setenv("REDIS_URL", "redis://lib")
+
+		assert.Equal(t, "redis://lib", load(t, WithEnvBindings(app), WithEnvBindingsDefault(lib)))
+	})
+
+	t.Run("default wins over regex and prefix bindings", func(t *testing.T) {
+		t.Setenv("REDIS_URL", "redis://lib")
+		t.Setenv("X_REDIS_URL", "redis://prefix")
+
+		assert.Equal(t, "redis://lib", load(t, WithEnvPrefix("X_"), WithEnvBindingsDefault(lib)))
+	})
+
+	t.Run("override wins regardless of order", func(t *testing.T) {
+		t.Setenv("REDIS_URL", "redis://lib")
+		t.Setenv("APP_REDIS_URL", "redis://app")
+
+		assert.Equal(t, "redis://lib", load(t, WithEnvBindingsOverride(lib), WithEnvBindings(app)))
+		assert.Equal(t, "redis://lib", load(t, WithEnvBindings(app), WithEnvBindingsOverride(lib)))
+	})
+}
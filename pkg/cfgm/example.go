@@ -2,13 +2,17 @@ package cfgm
 
 import (
 	"bytes"
+	"cmp"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -21,25 +25,264 @@ import (
 	yamlv3 "go.yaml.in/yaml/v3"
 )
 
+// ExampleOption 示例生成选项函数，用于 [ExampleYAML]、[ExampleYAMLSection] 和 [MarkdownDocs]。
+type ExampleOption func(*exampleOptions)
+
+// exampleOptions 示例生成选项。
+type exampleOptions struct {
+	envPrefix  string     // 非空时在注释中标注对应的环境变量名
+	emptyStyle EmptyStyle // 空切片和空 map 的渲染方式
+	sortKeys   bool       // 按 key 字母序输出字段
+}
+
+// EmptyStyle 空切片和空 map 在示例 YAML 中的渲染方式。
+type EmptyStyle int
+
+const (
+	// EmptyFlow 渲染为 [] 和 {}（默认）。
+	EmptyFlow EmptyStyle = iota
+	// EmptyBlock 仅输出 key（如 tags:），便于在其下方按块格式补充条目。
+	// 注意：不补充条目直接加载时该值为 null。
+	EmptyBlock
+	// EmptyOmit 省略该字段。
+	EmptyOmit
+)
+
+// WithExampleEnvPrefix 在每个配置项的注释后标注对应的环境变量名，如 # 应用名称 (env: APP_NAME)。
+//
+// 环境变量名按 [WithEnvPrefix] 的规则生成，便于将 YAML 示例和环境变量文档合并为一份。
+func WithExampleEnvPrefix(prefix string) ExampleOption {
+	return func(o *exampleOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithExampleEmptyStyle 设置空切片和空 map 的渲染方式，见 [EmptyStyle]。
+func WithExampleEmptyStyle(style EmptyStyle) ExampleOption {
+	return func(o *exampleOptions) {
+		o.emptyStyle = style
+	}
+}
+
+// WithExampleSortKeys 在每一层映射内按 koanf key 的字母序输出字段，而非结构体的声明顺序。
+//
+// 嵌套结构体（包括切片元素和 map 值中的结构体）各自独立排序，注释随字段一起移动。
+func WithExampleSortKeys() ExampleOption {
+	return func(o *exampleOptions) {
+		o.sortKeys = true
+	}
+}
+
 // ExampleYAML 将配置结构体序列化为带注释的 YAML。
 //
 // 通过 desc tag 自动生成注释，适用于生成 config.example.yaml。
+// 可通过 [WithExampleEnvPrefix] 在注释中标注环境变量名，
+// 通过 [WithExampleEmptyStyle] 调整空切片和空 map 的渲染方式，
+// 通过 [WithExampleSortKeys] 按字母序输出字段。
+// 标记为必填（validate:"required" 或 required:"true"）的字段在注释后追加 (required)，
+// 带 validate:"oneof=..." 规则的字段追加 (allowed: dev, staging, prod) 列出可选值。
+// sensitive:"true" 的字符串字段输出占位值 "<your-secret-here>"，其他类型输出零值，不写入真实的默认密钥。
+// 生成时不进行模板展开，默认值和注释中的 {{ }} 原样输出。
+// 包含换行的字符串（如 PEM 证书）渲染为 | 块标量，加载后与原值一致。
+// 输出与 [MarshalJSON] 相同，以且仅以一个换行结尾。
 //
 // 使用示例：
 //
 //	yaml := cfgm.ExampleYAML(DefaultConfig())
 //	os.WriteFile("config/config.example.yaml", yaml, 0644)
-func ExampleYAML[T any](cfg T) []byte {
-	node := structToNode(reflect.ValueOf(cfg), reflect.TypeOf(cfg))
+func ExampleYAML[T any](cfg T, opts ...ExampleOption) []byte {
+	node := exampleNode(cfg, opts)
 	node.HeadComment = "配置示例文件, 复制此文件为 config.yaml 并根据需要修改"
 
+	return encodeYAMLNode(node)
+}
+
+// ExampleYAMLSection 仅将指定的顶级配置节序列化为带注释的 YAML。
+//
+// section 为顶级字段的 koanf key（如 "server"），输出保留该节的 key 及其注释，
+// 适用于只为单个模块编写文档的场景。section 不存在时返回 error。
+//
+// 使用示例：
+//
+//	yaml, err := cfgm.ExampleYAMLSection(DefaultConfig(), "server")
+func ExampleYAMLSection[T any](cfg T, section string, opts ...ExampleOption) ([]byte, error) {
+	node := exampleNode(cfg, opts)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], node.Content[i+1]
+		if keyNode.Value != section {
+			continue
+		}
+
+		// 独立输出时去掉用于分隔字段的前导空行
+		keyNode.HeadComment = strings.TrimPrefix(keyNode.HeadComment, "\n")
+		sectionNode := &yamlv3.Node{Kind: yamlv3.MappingNode, Content: []*yamlv3.Node{keyNode, valNode}}
+
+		return encodeYAMLNode(sectionNode), nil
+	}
+
+	return nil, fmt.Errorf("section %q not found", section)
+}
+
+// exampleNode 构建带注释的示例节点并应用示例选项。
+func exampleNode[T any](cfg T, opts []ExampleOption) *yamlv3.Node {
+	var o exampleOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	node := structToNode(reflect.ValueOf(cfg), reflect.TypeOf(cfg))
+	if o.emptyStyle != EmptyFlow {
+		applyEmptyStyle(node, o.emptyStyle)
+	}
+	if o.sortKeys {
+		sortMappingKeys(node)
+	}
+	if o.envPrefix != "" {
+		envByKey := make(map[string]string)
+		for envKey, key := range generateEnvBindings(o.envPrefix, collectKoanfKeys(cfg)) {
+			envByKey[key] = envKey
+		}
+		annotateEnvComments(node, "", envByKey)
+	}
+	for i := 1; i < len(node.Content); i += 2 {
+		trimNestedLeadingBlank(node.Content[i])
+	}
+
+	return node
+}
+
+// trimNestedLeadingBlank 去掉嵌套映射首个 key 注释前用于分隔字段的空行，递归处理嵌套的映射和序列。
+//
+// 嵌套配置节紧跟在父 key 之后，前导空行只会在父 key 下留下一个空行。
+func trimNestedLeadingBlank(node *yamlv3.Node) {
+	switch node.Kind {
+	case yamlv3.SequenceNode:
+		for _, elem := range node.Content {
+			trimNestedLeadingBlank(elem)
+		}
+	case yamlv3.MappingNode:
+		if len(node.Content) > 0 {
+			node.Content[0].HeadComment = strings.TrimPrefix(node.Content[0].HeadComment, "\n")
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			trimNestedLeadingBlank(node.Content[i])
+		}
+	}
+}
+
+// applyEmptyStyle 按 style 重写映射中值为空切片或空 map 的字段，递归处理嵌套的映射和序列。
+func applyEmptyStyle(node *yamlv3.Node, style EmptyStyle) {
+	switch node.Kind {
+	case yamlv3.SequenceNode:
+		for _, elem := range node.Content {
+			applyEmptyStyle(elem, style)
+		}
+	case yamlv3.MappingNode:
+		content := make([]*yamlv3.Node, 0, len(node.Content))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valNode := node.Content[i], node.Content[i+1]
+			switch {
+			case !isEmptyCollectionNode(valNode):
+				applyEmptyStyle(valNode, style)
+			case style == EmptyOmit:
+				continue
+			case style == EmptyBlock:
+				valNode = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", LineComment: valNode.LineComment}
+			}
+			content = append(content, keyNode, valNode)
+		}
+		node.Content = content
+	}
+}
+
+// sortMappingKeys 将映射节点的键值对按 key 字母序排列，递归处理嵌套的映射和序列。
+func sortMappingKeys(node *yamlv3.Node) {
+	switch node.Kind {
+	case yamlv3.SequenceNode:
+		for _, elem := range node.Content {
+			sortMappingKeys(elem)
+		}
+	case yamlv3.MappingNode:
+		pairs := make([][2]*yamlv3.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			sortMappingKeys(node.Content[i+1])
+			pairs = append(pairs, [2]*yamlv3.Node{node.Content[i], node.Content[i+1]})
+		}
+		slices.SortStableFunc(pairs, func(a, b [2]*yamlv3.Node) int {
+			return cmp.Compare(a[0].Value, b[0].Value)
+		})
+
+		node.Content = node.Content[:0]
+		for _, pair := range pairs {
+			node.Content = append(node.Content, pair[0], pair[1])
+		}
+	}
+}
+
+// isEmptyCollectionNode 判断节点是否为 valueToNode 生成的空切片或空 map（[] 或 {}）。
+func isEmptyCollectionNode(node *yamlv3.Node) bool {
+	return (node.Kind == yamlv3.SequenceNode || node.Kind == yamlv3.MappingNode) &&
+		len(node.Content) == 0 && node.Style&yamlv3.FlowStyle != 0
+}
+
+// annotateEnvComments 在叶子配置项的注释后追加 (env: NAME)。
+//
+// 标量值追加到行尾注释，切片、map 等复杂值追加到 key 上方的注释。
+func annotateEnvComments(node *yamlv3.Node, prefix string, envByKey map[string]string) {
+	if node.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		envKey, ok := envByKey[key]
+		if !ok {
+			annotateEnvComments(valNode, key, envByKey)
+
+			continue
+		}
+
+		note := "(env: " + envKey + ")"
+		switch {
+		case valNode.Kind == yamlv3.ScalarNode:
+			valNode.LineComment = strings.TrimSpace(valNode.LineComment + " " + note)
+		case strings.TrimSpace(keyNode.HeadComment) != "":
+			keyNode.HeadComment += " " + note
+		default:
+			keyNode.HeadComment += note
+		}
+	}
+}
+
+// encodeYAMLNode 使用统一的缩进将 yamlv3.Node 编码为字节。
+func encodeYAMLNode(node *yamlv3.Node) []byte {
 	var buf bytes.Buffer
 	enc := yamlv3.NewEncoder(&buf)
 	enc.SetIndent(2)
 	_ = enc.Encode(node)
 	_ = enc.Close()
 
-	return buf.Bytes()
+	// 编码器为嵌套配置节前的空行输出缩进空格，去掉以免产生行尾空白；
+	// 块标量不会包含仅由空格组成的行，因此不影响值的内容
+	lines := bytes.Split(buf.Bytes(), []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimLeft(line, " ")) == 0 {
+			lines[i] = nil
+		}
+	}
+
+	return singleTrailingNewline(bytes.Join(lines, []byte("\n")))
+}
+
+// singleTrailingNewline 使输出以且仅以一个换行结尾，保证不同生成方式写出的文件一致。
+func singleTrailingNewline(data []byte) []byte {
+	return append(bytes.TrimRight(data, "\n"), '\n')
 }
 
 // MarshalYAML 将配置结构体序列化为 YAML（无注释）。
@@ -60,17 +303,200 @@ func MarshalYAML[T any](cfg T) []byte {
 
 // MarshalJSON 将配置结构体序列化为 JSON。
 //
+// 字段名优先使用 json tag，未设置时使用 koanf tag，与配置文件期望的 key 一致，
+// 因此配置结构体无需额外声明 json tag；两者都未设置时使用 Go 字段名（与 encoding/json 相同）。
+// 字段按声明顺序输出，json tag 的 "-" 和 omitempty 选项仍然生效。
+// map 字段的 key 按字符串形式排序（数字 key 同样按字符串比较），与 [ExampleYAML] 的顺序一致。
+// 输出以且仅以一个换行结尾。
+//
 // 使用示例：
 //
 //	jsonBytes := cfgm.MarshalJSON(cfg)
 //	os.WriteFile("config/config.json", jsonBytes, 0644)
 func MarshalJSON[T any](cfg T) []byte {
+	return marshalJSON(cfg, "  ")
+}
+
+// MarshalJSONCompact 将配置结构体序列化为单行 JSON（无缩进），适用于机器读取。
+//
+// 输出与 [MarshalJSON] 结构相同，仅省略缩进和换行。
+//
+// 使用示例：
+//
+//	fmt.Println(string(cfgm.MarshalJSONCompact(cfg)))
+func MarshalJSONCompact[T any](cfg T) []byte {
+	return bytes.TrimSuffix(marshalJSON(cfg, ""), []byte("\n"))
+}
+
+// marshalJSON 使用指定的缩进序列化 JSON，indent 为空时输出单行。
+func marshalJSON(cfg any, indent string) []byte {
 	var buf bytes.Buffer
 	enc := json.NewEncoder(&buf)
-	enc.SetIndent("", "  ")
-	_ = enc.Encode(cfg) //nolint:errchkjson // T is a config struct, safe to encode
+	enc.SetIndent("", indent)
+	_ = enc.Encode(jsonValue(reflect.ValueOf(cfg))) //nolint:errchkjson // cfg is a config struct, safe to encode
+
+	return singleTrailingNewline(buf.Bytes())
+}
+
+// jsonObject 按字段顺序编码的 JSON 对象。
+type jsonObject []jsonField
+
+// jsonField jsonObject 的一个字段。
+type jsonField struct {
+	key   string
+	value any
+}
+
+// MarshalJSON 按字段顺序编码为 JSON 对象。
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// jsonValue 将值转换为可由 encoding/json 编码的形式，结构体字段名按 [MarshalJSON] 的规则确定。
+//
+// 实现了 json.Marshaler 或 encoding.TextMarshaler 的值（如 time.Time）原样交给 encoding/json。
+func jsonValue(val reflect.Value) any {
+	if !val.IsValid() {
+		return nil
+	}
+	if val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		if val.Kind() == reflect.Interface {
+			return jsonValue(val.Elem())
+		}
+	}
+	if val.CanInterface() {
+		switch val.Interface().(type) {
+		case json.Marshaler, encoding.TextMarshaler:
+			return val.Interface()
+		}
+	}
+
+	switch val.Kind() {
+	case reflect.Pointer:
+		return jsonValue(val.Elem())
+	case reflect.Struct:
+		obj := jsonObject{}
+		appendJSONFields(&obj, val)
+
+		return obj
+	case reflect.Slice:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return val.Interface() // []byte 按 encoding/json 编码为 base64
+		}
+
+		fallthrough
+	case reflect.Array:
+		items := make([]any, 0, val.Len())
+		for i := range val.Len() {
+			items = append(items, jsonValue(val.Index(i)))
+		}
+
+		return items
+	case reflect.Map:
+		if val.IsNil() {
+			return nil
+		}
+		// 与 valueToNode 相同，按 key 的字符串形式排序，保证 JSON 与 YAML 示例的顺序一致
+		obj := make(jsonObject, 0, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			obj = append(obj, jsonField{key: fmt.Sprint(iter.Key().Interface()), value: jsonValue(iter.Value())})
+		}
+		slices.SortFunc(obj, func(a, b jsonField) int { return cmp.Compare(a.key, b.key) })
+
+		return obj
+	default:
+		return val.Interface()
+	}
+}
+
+// appendJSONFields 将结构体的导出字段追加到 obj，未设置 tag 的嵌入结构体字段展开到同一层级。
+func appendJSONFields(obj *jsonObject, val reflect.Value) {
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+
+		jsonTag, hasJSONTag := field.Tag.Lookup("json")
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		koanfKey := field.Tag.Get("koanf")
+
+		if field.Anonymous && !hasJSONTag && koanfKey == "" {
+			embedded := fieldVal
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				appendJSONFields(obj, embedded)
+
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		switch {
+		case name != "":
+		case koanfKey != "":
+			name = koanfKey
+		default:
+			name = field.Name
+		}
+		if slices.Contains(strings.Split(opts, ","), "omitempty") && isEmptyJSONValue(fieldVal) {
+			continue
+		}
+
+		*obj = append(*obj, jsonField{key: name, value: jsonValue(fieldVal)})
+	}
+}
 
-	return buf.Bytes()
+// isEmptyJSONValue 判断值是否满足 encoding/json 的 omitempty 条件。
+func isEmptyJSONValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return val.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return val.IsZero()
+	default:
+		return false
+	}
 }
 
 // structToNode 将结构体转换为带注释的 yamlv3.Node。
@@ -94,7 +520,7 @@ func structToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 		if key == "" {
 			continue
 		}
-		comment := field.Tag.Get("desc")
+		comment := exampleComment(field)
 
 		// Key node
 		keyNode := &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: key}
@@ -103,20 +529,38 @@ func structToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 		var valNode *yamlv3.Node
 
 		// 判断是否为复杂类型（结构体或数组）
-		isStruct := field.Type.Kind() == reflect.Struct &&
-			field.Type != reflect.TypeFor[time.Duration]() &&
-			field.Type != reflect.TypeFor[time.Time]()
+		isStruct := isNestedStruct(field.Type)
 		isSlice := field.Type.Kind() == reflect.Slice
 
 		switch {
 		case isStruct:
 			valNode = structToNode(fieldVal, field.Type)
 			keyNode.HeadComment = "\n" + comment // 复杂类型注释放在 key 上方，前面加空行
+		case field.Tag.Get("sensitive") == "true":
+			valNode = sensitiveExampleNode(field.Type)
+			setSimpleFieldComment(keyNode, valNode, comment)
 		case isSlice:
 			valNode = valueToNode(fieldVal, field.Type)
 			keyNode.HeadComment = "\n" + comment // 复杂类型注释放在 key 上方，前面加空行
+		case field.Type.Kind() == reflect.Map:
+			valNode = valueToNode(fieldVal, field.Type)
+			// 非空 map 渲染为块，注释放在 key 上方；行尾注释会被 yaml 编码器移到其他位置
+			if len(valNode.Content) > 0 {
+				keyNode.HeadComment = "\n" + comment
+			} else {
+				setSimpleFieldComment(keyNode, valNode, comment)
+			}
+		case field.Type.Kind() == reflect.Interface:
+			// any 等接口字段按实际值渲染，注释位置取决于渲染结果是否为块
+			valNode = valueToNode(fieldVal, field.Type)
+			if valNode.Kind != yamlv3.ScalarNode && len(valNode.Content) > 0 {
+				keyNode.HeadComment = "\n" + comment
+			} else {
+				setSimpleFieldComment(keyNode, valNode, comment)
+			}
 		default:
 			valNode = valueToNode(fieldVal, field.Type)
+			applyFieldFormat(valNode, field)
 			// 多行注释放在 key 上方（HeadComment），单行注释放在行尾（LineComment）
 			setSimpleFieldComment(keyNode, valNode, comment)
 		}
@@ -127,6 +571,96 @@ func structToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 	return node
 }
 
+// sensitivePlaceholder 示例中 sensitive:"true" 字符串字段的占位值。
+const sensitivePlaceholder = "<your-secret-here>"
+
+// sensitiveExampleNode 返回 sensitive 字段在示例中的值节点，避免将真实的默认密钥写入示例文件。
+//
+// 字符串字段渲染为 [sensitivePlaceholder]，其他类型渲染为零值，与 [Redacted] 一致。
+func sensitiveExampleNode(typ reflect.Type) *yamlv3.Node {
+	elem := typ
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.String {
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: sensitivePlaceholder, Style: yamlv3.DoubleQuotedStyle}
+	}
+
+	return valueToNode(reflect.Zero(elem), elem)
+}
+
+// exampleComment 返回字段在示例中的注释（desc 标签），必填字段在首行末尾追加 (required) 标记，
+// 带 oneof 校验规则的字段追加 (allowed: ...) 列出可选值。
+func exampleComment(field reflect.StructField) string {
+	comment := field.Tag.Get("desc")
+	var notes []string
+	if isRequiredField(field) {
+		notes = append(notes, "(required)")
+	}
+	if allowed := oneofValues(field); len(allowed) > 0 {
+		notes = append(notes, "(allowed: "+strings.Join(allowed, ", ")+")")
+	}
+	if len(notes) == 0 {
+		return comment
+	}
+
+	first, rest, multiline := strings.Cut(comment, "\n")
+	first = strings.TrimSpace(first + " " + strings.Join(notes, " "))
+	if multiline {
+		return first + "\n" + rest
+	}
+
+	return first
+}
+
+// oneofValues 返回字段 validate 标签中 oneof 规则列出的可选值，没有 oneof 规则时返回 nil。
+func oneofValues(field reflect.StructField) []string {
+	for rule := range strings.SplitSeq(field.Tag.Get("validate"), ",") {
+		if param, ok := strings.CutPrefix(strings.TrimSpace(rule), "oneof="); ok {
+			return strings.Fields(param)
+		}
+	}
+
+	return nil
+}
+
+// applyFieldFormat 按字段的 format 标签调整值的渲染方式。
+//
+// 支持的格式：
+//   - yesno: bool 字段渲染为 yes/no（加载时由 bool 解码规则识别）
+//   - seconds: time.Duration 字段以秒为单位渲染（如 90s，而非 1m30s）
+//   - minutes: time.Duration 字段以分钟为单位渲染（如 1.5m）
+//
+// 渲染结果均为 time.ParseDuration 可解析的格式，加载时无需额外处理。
+func applyFieldFormat(valNode *yamlv3.Node, field reflect.StructField) {
+	if valNode.Kind != yamlv3.ScalarNode {
+		return
+	}
+
+	switch format := field.Tag.Get("format"); format {
+	case "yesno":
+		switch valNode.Value {
+		case "true":
+			valNode.Value = "yes"
+		case "false":
+			valNode.Value = "no"
+		}
+	case "seconds", "minutes":
+		if d, err := time.ParseDuration(valNode.Value); err == nil {
+			valNode.Value = formatDurationUnit(d, format)
+		}
+	}
+}
+
+// formatDurationUnit 将时长格式化为指定单位（seconds 或 minutes）的单一单位表示。
+func formatDurationUnit(d time.Duration, format string) string {
+	if format == "minutes" {
+		return strconv.FormatFloat(d.Minutes(), 'f', -1, 64) + "m"
+	}
+
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
 // setSimpleFieldComment 设置简单字段的注释。
 // 多行注释放在 key 上方（HeadComment），单行注释放在行尾（LineComment）。
 func setSimpleFieldComment(keyNode, valNode *yamlv3.Node, comment string) {
@@ -139,6 +673,23 @@ func setSimpleFieldComment(keyNode, valNode *yamlv3.Node, comment string) {
 
 // valueToNode 将值转换为 yamlv3.Node。
 func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
+	// 指针类型：nil 输出 null，非 nil 输出指向的值
+	if typ.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}
+		}
+		if isNestedStruct(typ.Elem()) {
+			return structToNode(val, typ)
+		}
+
+		return valueToNode(val.Elem(), typ.Elem())
+	}
+
+	// 切片元素、map 值中的结构体按字段递归渲染，保证 time.Duration 等字段格式一致
+	if isNestedStruct(typ) {
+		return structToNode(val, typ)
+	}
+
 	// 特殊类型处理
 	switch typ {
 	case reflect.TypeFor[time.Duration]():
@@ -150,6 +701,11 @@ func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 		}
 	case reflect.TypeFor[time.Time]():
 		if t, ok := val.Interface().(time.Time); ok {
+			// 零值渲染为空字符串，避免 0001-01-01T00:00:00Z 干扰示例，加载时空字符串解码为零值
+			if t.IsZero() {
+				return &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: "", Style: yamlv3.DoubleQuotedStyle}
+			}
+
 			return &yamlv3.Node{
 				Kind:  yamlv3.ScalarNode,
 				Value: t.Format(time.RFC3339),
@@ -159,10 +715,17 @@ func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 
 	switch val.Kind() {
 	case reflect.String:
+		// 多行字符串（如 PEM 证书、SQL）渲染为 | 块，比转义的双引号字符串易读；
+		// 以多个换行结尾的字符串需要 |+ 保留空行，位于文件末尾时会产生多余的换行，因此仍使用双引号
+		style := yamlv3.DoubleQuotedStyle
+		if strings.Contains(val.String(), "\n") && !strings.HasSuffix(val.String(), "\n\n") {
+			style = yamlv3.LiteralStyle
+		}
+
 		return &yamlv3.Node{
 			Kind:  yamlv3.ScalarNode,
 			Value: val.String(),
-			Style: yamlv3.DoubleQuotedStyle,
+			Style: style,
 		}
 
 	case reflect.Bool:
@@ -186,9 +749,17 @@ func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 	case reflect.Float32, reflect.Float64:
 		return &yamlv3.Node{
 			Kind:  yamlv3.ScalarNode,
-			Value: fmt.Sprintf("%v", val.Float()),
+			Value: formatFloat(val.Float(), typ.Bits()),
 		}
 
+	case reflect.Interface:
+		// map[string]any 等容器中的元素按实际类型渲染
+		if val.IsNil() {
+			return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}
+		}
+
+		return valueToNode(val.Elem(), val.Elem().Type())
+
 	case reflect.Slice:
 		node := &yamlv3.Node{Kind: yamlv3.SequenceNode}
 		if val.Len() == 0 {
@@ -197,8 +768,10 @@ func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 			for j := range val.Len() {
 				elem := val.Index(j)
 				elemNode := valueToNode(elem, elem.Type())
-				// slice 元素不使用引号样式，保持简洁
-				elemNode.Style = 0
+				// slice 元素不使用引号样式，保持简洁；多行字符串保留 | 块
+				if elemNode.Style != yamlv3.LiteralStyle {
+					elemNode.Style = 0
+				}
 				node.Content = append(node.Content, elemNode)
 			}
 		}
@@ -210,11 +783,20 @@ func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 		if val.Len() == 0 {
 			node.Style = yamlv3.FlowStyle // {} 形式
 		} else {
-			iter := val.MapRange()
-			for iter.Next() {
-				k, v := iter.Key(), iter.Value()
+			// 按 key 排序，保证输出稳定
+			keys := val.MapKeys()
+			keyStrings := make(map[reflect.Value]string, len(keys))
+			for _, k := range keys {
+				keyStrings[k] = fmt.Sprintf("%v", k.Interface())
+			}
+			slices.SortFunc(keys, func(a, b reflect.Value) int {
+				return cmp.Compare(keyStrings[a], keyStrings[b])
+			})
+
+			for _, k := range keys {
+				v := val.MapIndex(k)
 				node.Content = append(node.Content,
-					&yamlv3.Node{Kind: yamlv3.ScalarNode, Value: fmt.Sprintf("%v", k.Interface())},
+					&yamlv3.Node{Kind: yamlv3.ScalarNode, Value: keyStrings[k]},
 					valueToNode(v, v.Type()),
 				)
 			}
@@ -230,6 +812,28 @@ func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 	}
 }
 
+// formatFloat 将浮点数格式化为 YAML 标量，所有位置（字段、切片元素、map 值）使用相同规则。
+//
+// 使用最短的精确表示，整数值保留 ".0" 以明确为浮点数（如 1.0），
+// NaN 和无穷大使用 YAML 的 .nan 和 .inf 表示。
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	}
+
+	s := strconv.FormatFloat(f, 'f', -1, bitSize)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+
+	return s
+}
+
 // ConfigTestHelper 配置测试辅助工具
 //
 // 使用示例：
@@ -241,9 +845,18 @@ func valueToNode(val reflect.Value, typ reflect.Type) *yamlv3.Node {
 //
 //	func TestWriteExample(t *testing.T) { helper.WriteExampleFile(t, DefaultConfig()) }
 //	func TestConfigKeysValid(t *testing.T) { helper.ValidateKeys(t) }
+//
+// CI 中可使用 AssertExampleUpToDate 检查示例文件是否过期（不写入文件）：
+//
+//	func TestExampleUpToDate(t *testing.T) { helper.AssertExampleUpToDate(t, DefaultConfig()) }
+//
+// 使用 AssertAllDescribed 检查所有配置项都有 desc 描述：
+//
+//	func TestConfigDescribed(t *testing.T) { helper.AssertAllDescribed(t, DefaultConfig()) }
 type ConfigTestHelper[T any] struct {
-	ExamplePath string // 示例文件相对路径（相对于 go.mod 所在目录）
-	ConfigPath  string // 配置文件相对路径（相对于 go.mod 所在目录）
+	ExamplePath     string // 示例文件路径（相对路径基于 go.mod 所在目录）
+	ExampleJSONPath string // JSON 示例文件路径，供 GenerateExamples 使用（相对路径基于 go.mod 所在目录）
+	ConfigPath      string // 配置文件路径（相对路径基于 go.mod 所在目录）
 }
 
 // WriteExampleFile 将示例配置写入文件
@@ -255,21 +868,76 @@ func (h *ConfigTestHelper[T]) WriteExampleFile(t *testing.T, defaultConfig T) {
 		t.Fatalf("无法找到项目根目录: %v", err)
 	}
 
-	yamlBytes := ExampleYAML(defaultConfig)
+	writeHelperFile(t, resolveHelperPath(projectRoot, h.ExamplePath), ExampleYAML(defaultConfig))
+}
+
+// GenerateExamples 同时生成 YAML 和 JSON 示例文件，保持两者同步。
+//
+// YAML 写入 ExamplePath（使用 [ExampleYAML]），JSON 写入 ExampleJSONPath（使用 [MarshalJSON]）。
+// 路径为空的格式会被跳过。
+//
+// 使用示例：
+//
+//	var helper = cfgm.ConfigTestHelper[Config]{
+//	    ExamplePath:     "config/config.example.yaml",
+//	    ExampleJSONPath: "config/config.example.json",
+//	}
+//
+//	func TestGenerateExamples(t *testing.T) { helper.GenerateExamples(t, DefaultConfig()) }
+func (h *ConfigTestHelper[T]) GenerateExamples(t *testing.T, defaultConfig T) {
+	t.Helper()
+
+	projectRoot, err := FindProjectRoot(1)
+	if err != nil {
+		t.Fatalf("无法找到项目根目录: %v", err)
+	}
+
+	if h.ExamplePath != "" {
+		writeHelperFile(t, resolveHelperPath(projectRoot, h.ExamplePath), ExampleYAML(defaultConfig))
+	}
+	if h.ExampleJSONPath != "" {
+		writeHelperFile(t, resolveHelperPath(projectRoot, h.ExampleJSONPath), MarshalJSON(defaultConfig))
+	}
+}
+
+// writeHelperFile 写入示例文件，必要时创建目录。
+func writeHelperFile(t *testing.T, outputPath string, data []byte) {
+	t.Helper()
 
-	outputPath := filepath.Join(projectRoot, h.ExamplePath)
 	outputDir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(outputDir, 0750); err != nil {
 		t.Fatalf("创建目录失败: %v", err)
 	}
 
-	if err := os.WriteFile(outputPath, yamlBytes, 0600); err != nil {
+	if err := os.WriteFile(outputPath, data, 0600); err != nil {
 		t.Fatalf("写入配置文件失败: %v", err)
 	}
 
 	t.Logf("✅ 已生成配置示例文件: %s", outputPath)
 }
 
+// AssertExampleUpToDate 校验示例文件与配置结构体生成的内容一致，适用于 CI。
+//
+// 在内存中重新生成示例（不写入文件），与已提交的示例文件逐行比较，
+// 不一致时输出差异并标记测试失败，防止示例文件过期。
+func (h *ConfigTestHelper[T]) AssertExampleUpToDate(t *testing.T, defaultConfig T) {
+	t.Helper()
+
+	projectRoot, err := FindProjectRoot(1)
+	if err != nil {
+		t.Fatalf("无法找到项目根目录: %v", err)
+	}
+
+	diff, err := exampleDiff(resolveHelperPath(projectRoot, h.ExamplePath), ExampleYAML(defaultConfig))
+	if err != nil {
+		t.Fatalf("无法读取 %s: %v", h.ExamplePath, err)
+	}
+
+	if diff != "" {
+		t.Errorf("%s 已过期，请重新生成示例文件:\n%s", h.ExamplePath, diff)
+	}
+}
+
 // ValidateKeys 校验配置文件中的键名是否都在示例文件中定义
 func (h *ConfigTestHelper[T]) ValidateKeys(t *testing.T) {
 	t.Helper()
@@ -279,8 +947,8 @@ func (h *ConfigTestHelper[T]) ValidateKeys(t *testing.T) {
 		t.Fatalf("无法找到项目根目录: %v", err)
 	}
 
-	configPath := filepath.Join(projectRoot, h.ConfigPath)
-	examplePath := filepath.Join(projectRoot, h.ExamplePath)
+	configPath := resolveHelperPath(projectRoot, h.ConfigPath)
+	examplePath := resolveHelperPath(projectRoot, h.ExamplePath)
 
 	if _, statErr := os.Stat(configPath); os.IsNotExist(statErr) {
 		t.Skipf("%s 不存在，跳过验证", h.ConfigPath)
@@ -316,6 +984,133 @@ func (h *ConfigTestHelper[T]) ValidateKeys(t *testing.T) {
 	}
 }
 
+// AssertAllDescribed 校验配置结构体的每个叶子配置项都有非空的 desc 标签，适用于 CI 保证文档完整。
+//
+// 缺少描述时列出所有对应的 koanf key 并标记测试失败。
+//
+//	func TestConfigDescribed(t *testing.T) { helper.AssertAllDescribed(t, DefaultConfig()) }
+func (h *ConfigTestHelper[T]) AssertAllDescribed(t testing.TB, defaultConfig T) {
+	t.Helper()
+
+	if keys := undescribedKeys(defaultConfig); len(keys) > 0 {
+		t.Errorf("以下配置项缺少 desc 标签:\n  - %s", strings.Join(keys, "\n  - "))
+	}
+}
+
+// undescribedKeys 返回没有非空 desc 标签的叶子 koanf key，按字段声明顺序排列。
+func undescribedKeys[T any](defaultConfig T) []string {
+	var keys []string
+	for _, leaf := range koanfTypeInfoFor(reflect.TypeOf(defaultConfig)).leaves {
+		if strings.TrimSpace(leaf.field.Tag.Get("desc")) == "" {
+			keys = append(keys, leaf.key)
+		}
+	}
+
+	return keys
+}
+
+// resolveHelperPath 将相对路径解析为相对于项目根目录的路径，绝对路径保持不变。
+func resolveHelperPath(projectRoot, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(projectRoot, path)
+}
+
+// exampleDiff 比较文件内容与期望内容，返回逐行差异，一致时返回空字符串。
+func exampleDiff(path string, want []byte) (string, error) {
+	got, err := os.ReadFile(path) //nolint:gosec // path is from trusted test config
+	if err != nil {
+		return "", err
+	}
+
+	return diffLines(string(got), string(want)), nil
+}
+
+// diffLines 基于最长公共子序列逐行比较两段文本，返回 unified 风格的差异块
+// （@@ -旧起始行,行数 +新起始行,行数 @@，- 为旧内容，+ 为新内容），相同时返回空字符串。
+//
+// 插入或删除一行只影响对应的差异块，不会使后续所有行都被判定为变化。
+func diffLines(oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	oldLines := strings.Split(oldText, "\n")
+	newLines := strings.Split(newText, "\n")
+	n, m := len(oldLines), len(newLines)
+
+	// lcs[i][j] 为 oldLines[i:] 与 newLines[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	var removed, added []string
+	hunkOld, hunkNew := 0, 0
+	flush := func() {
+		if len(removed) == 0 && len(added) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "  @@ -%s +%s @@\n", hunkRange(hunkOld, len(removed)), hunkRange(hunkNew, len(added)))
+		for _, line := range removed {
+			fmt.Fprintf(&b, "    - %s\n", line)
+		}
+		for _, line := range added {
+			fmt.Fprintf(&b, "    + %s\n", line)
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			flush()
+			i++
+			j++
+
+			continue
+		case j >= m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			if len(removed) == 0 && len(added) == 0 {
+				hunkOld, hunkNew = i, j
+			}
+			removed = append(removed, oldLines[i])
+			i++
+		default:
+			if len(removed) == 0 && len(added) == 0 {
+				hunkOld, hunkNew = i, j
+			}
+			added = append(added, newLines[j])
+			j++
+		}
+	}
+	flush()
+
+	return b.String()
+}
+
+// hunkRange 格式化差异块的行范围，start 为 0 起始的行下标；
+// 与 unified diff 一致，行数为 0 时起始行为其前一行。
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
 // FindProjectRoot 通过查找 go.mod 文件定位项目根目录。
 //
 // skip 指定跳过的调用栈层数，0 表示调用者，1 表示调用者的调用者，以此类推。
@@ -0,0 +1,151 @@
+package cfgm
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// FlattenConfig 将配置展开为 完整 koanf key → 字符串值 的扁平映射，用于写入 Consul KV 等键值存储。
+//
+// 标量值的格式与 [MarshalEnv] 一致，可被加载流程解析回原值：
+// time.Duration 为 "30s" 格式，bool 为 true/false，time.Time 等实现了
+// encoding.TextMarshaler 的类型使用其文本格式。
+// 切片和 map 序列化为 JSON（map 的 key 按字典序排列，结构体元素按 koanf tag 展开），
+// 保证相同配置的输出稳定；nil 指针字段不输出。
+//
+// 示例：
+//
+//	for key, value := range cfgm.FlattenConfig(*cfg) {
+//	    kv.Put(&api.KVPair{Key: "myapp/" + key, Value: []byte(value)}, nil)
+//	}
+//
+//	// server.addr → ":8080"
+//	// server.timeout → "15s"
+//	// tags → `["a","b"]`
+func FlattenConfig[T any](cfg T) map[string]string {
+	flat := make(map[string]string)
+	flattenRecursive(flat, reflect.ValueOf(cfg), "")
+
+	return flat
+}
+
+// DefaultsMap 将配置结构体载入新的 koanf 实例，返回 完整 koanf key → 值 的扁平映射，
+// 用于与外部系统合并默认配置，是 [MarshalJSON] 的编程接口版本。
+//
+// 值保持原始 Go 类型（如 time.Duration、[]string），不做字符串化，需要字符串时使用 [FlattenConfig]。
+//
+// 示例：
+//
+//	defaults := cfgm.DefaultsMap(DefaultConfig())
+//	// defaults["server.addr"] → ":8080"
+//	// defaults["server.timeout"] → 15 * time.Second
+func DefaultsMap[T any](cfg T) map[string]any {
+	return flattenStruct(cfg)
+}
+
+// flattenRecursive 递归将结构体叶子字段写入 flat。
+func flattenRecursive(flat map[string]string, val reflect.Value, keyPrefix string) {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		koanfKey := field.Tag.Get("koanf")
+		if koanfKey == "" || !field.IsExported() {
+			continue
+		}
+
+		fullKey := koanfKey
+		if keyPrefix != "" {
+			fullKey = keyPrefix + "." + koanfKey
+		}
+
+		fieldVal := val.Field(i)
+		if isNestedStruct(field.Type) {
+			flattenRecursive(flat, fieldVal, fullKey)
+
+			continue
+		}
+		if fieldVal.Kind() == reflect.Pointer {
+			if fieldVal.IsNil() {
+				continue
+			}
+			fieldVal = fieldVal.Elem()
+		}
+
+		if value, ok := envValue(fieldVal); ok {
+			flat[fullKey] = value
+
+			continue
+		}
+		data, err := json.Marshal(plainValue(fieldVal))
+		if err != nil {
+			continue
+		}
+		flat[fullKey] = string(data)
+	}
+}
+
+// plainValue 将复合值转换为可稳定编码为 JSON 的普通值。
+//
+// 标量的格式与 [envValue] 一致，结构体按 koanf tag 转换为 map，nil 切片和 map 视为空。
+func plainValue(val reflect.Value) any {
+	if val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	if val.CanInterface() {
+		if m, ok := val.Interface().(encoding.TextMarshaler); ok {
+			if text, err := m.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+	}
+	if val.Type() == reflect.TypeFor[time.Duration]() {
+		return time.Duration(val.Int()).String()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any)
+		for i := range val.NumField() {
+			field := val.Type().Field(i)
+			if key := field.Tag.Get("koanf"); key != "" && field.IsExported() {
+				m[key] = plainValue(val.Field(i))
+			}
+		}
+
+		return m
+	case reflect.Slice, reflect.Array:
+		items := make([]any, 0, val.Len())
+		for i := range val.Len() {
+			items = append(items, plainValue(val.Index(i)))
+		}
+
+		return items
+	case reflect.Map:
+		m := make(map[string]any, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = plainValue(iter.Value())
+		}
+
+		return m
+	default:
+		return val.Interface()
+	}
+}
@@ -0,0 +1,97 @@
+package cfgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// =============================================================================
+// FlattenConfig 测试
+// =============================================================================
+
+func TestFlattenConfig(t *testing.T) {
+	type Backend struct {
+		Host    string        `koanf:"host"`
+		Timeout time.Duration `koanf:"timeout"`
+	}
+	type Config struct {
+		Name   string `koanf:"name"`
+		Server struct {
+			Addr    string        `koanf:"addr"`
+			Timeout time.Duration `koanf:"timeout"`
+			TLS     bool          `koanf:"tls"`
+		} `koanf:"server"`
+		Workers  *int              `koanf:"workers"`
+		Ratio    float64           `koanf:"ratio"`
+		Tags     []string          `koanf:"tags"`
+		Labels   map[string]string `koanf:"labels"`
+		Backends []Backend         `koanf:"backends"`
+		Started  time.Time         `koanf:"started"`
+		Ignored  string
+	}
+
+	cfg := Config{
+		Name:     "app",
+		Ratio:    0.5,
+		Tags:     []string{"b", "a"},
+		Labels:   map[string]string{"zone": "cn", "env": "prod"},
+		Backends: []Backend{{Host: "db1", Timeout: 5 * time.Second}},
+		Started:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	cfg.Server.Addr = ":8080"
+	cfg.Server.Timeout = 15 * time.Second
+
+	assert.Equal(t, map[string]string{
+		"name":           "app",
+		"server.addr":    ":8080",
+		"server.timeout": "15s",
+		"server.tls":     "false",
+		"ratio":          "0.5",
+		"tags":           `["b","a"]`,
+		"labels":         `{"env":"prod","zone":"cn"}`,
+		"backends":       `[{"host":"db1","timeout":"5s"}]`,
+		"started":        "2025-01-02T03:04:05Z",
+	}, FlattenConfig(cfg))
+
+	t.Run("deterministic", func(t *testing.T) {
+		for range 10 {
+			assert.Equal(t, `{"env":"prod","zone":"cn"}`, FlattenConfig(cfg)["labels"])
+		}
+	})
+
+	t.Run("empty collections and pointers", func(t *testing.T) {
+		workers := 4
+		flat := FlattenConfig(&Config{Workers: &workers})
+		assert.Equal(t, "4", flat["workers"])
+		assert.Equal(t, "[]", flat["tags"])
+		assert.Equal(t, "{}", flat["labels"])
+	})
+}
+
+// =============================================================================
+// DefaultsMap 测试
+// =============================================================================
+
+func TestDefaultsMap(t *testing.T) {
+	type Config struct {
+		Name   string `koanf:"name"`
+		Server struct {
+			Addr    string        `koanf:"addr"`
+			Timeout time.Duration `koanf:"timeout"`
+		} `koanf:"server"`
+		Tags []string `koanf:"tags"`
+	}
+
+	cfg := Config{Name: "app", Tags: []string{"a", "b"}}
+	cfg.Server.Addr = ":8080"
+	cfg.Server.Timeout = 15 * time.Second
+
+	assert.Equal(t, map[string]any{
+		"name":           "app",
+		"server.addr":    ":8080",
+		"server.timeout": 15 * time.Second,
+		"tags":           []string{"a", "b"},
+	}, DefaultsMap(cfg))
+}
@@ -0,0 +1,191 @@
+package cfgm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// Hooks 配置加载生命周期回调，所有字段均可选。
+//
+// 回调按以下顺序触发：
+//  1. OnFileLoaded - 配置文件加载完成后（未找到配置文件时不触发）
+//  2. OnBeforeUnmarshal - 所有配置源合并完成、解析到结构体之前
+//  3. OnLoaded - 解析到结构体之后
+//
+// OnBeforeUnmarshal 和 OnLoaded 返回 error 时中止加载，[Load] 返回该错误。
+type Hooks struct {
+	// OnFileLoaded 在配置文件加载后调用，path 为实际加载的文件路径。
+	OnFileLoaded func(path string)
+
+	// OnBeforeUnmarshal 在解析到结构体前调用，k 包含合并后的所有配置值，可读取或修改。
+	OnBeforeUnmarshal func(k *koanf.Koanf) error
+
+	// OnLoaded 在解析完成后调用，cfg 为指向配置结构体的指针（*T）。
+	OnLoaded func(cfg any) error
+}
+
+// WithHooks 设置配置加载生命周期回调，集中观察加载过程。
+//
+// 多次调用会按顺序追加，同一阶段的回调按添加顺序执行。
+//
+// 示例：
+//
+//	cfgm.WithHooks(cfgm.Hooks{
+//	    OnFileLoaded: func(path string) { log.Printf("config file: %s", path) },
+//	    OnLoaded: func(cfg any) error {
+//	        return cfg.(*Config).Validate()
+//	    },
+//	})
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks)
+	}
+}
+
+// WithAfterLoad 设置加载成功后执行的副作用回调，如根据配置初始化连接池。
+//
+// fn 在所有 [Hooks] 的 OnLoaded 回调（通常用于校验）成功之后执行，校验失败时不会调用。
+// fn 返回 error 时 [Load] 返回该错误并丢弃配置。多次调用按添加顺序执行，遇到第一个错误即停止。
+// 与修改配置的回调不同，fn 用于执行副作用；T 必须与 [Load] 的配置类型一致，否则返回错误。
+//
+// 示例：
+//
+//	var pool *sql.DB
+//	cfg, err := cfgm.Load(DefaultConfig(), cfgm.WithAfterLoad(func(cfg *Config) error {
+//	    var err error
+//	    pool, err = sql.Open("postgres", cfg.Database.DSN)
+//	    return err
+//	}))
+func WithAfterLoad[T any](fn func(cfg *T) error) Option {
+	return func(o *options) {
+		o.afterLoad = append(o.afterLoad, func(cfg any) error {
+			typed, ok := cfg.(*T)
+			if !ok {
+				return fmt.Errorf("config type %T does not match %T", cfg, (*T)(nil))
+			}
+
+			return fn(typed)
+		})
+	}
+}
+
+// valueTransformer [WithValueTransformer] 注册的转换函数。
+type valueTransformer struct {
+	path string
+	fn   func(any) (any, error)
+}
+
+// WithValueTransformer 注册 koanf key 对应值的转换函数，用于规范化配置值（如邮箱转小写、URL 补全末尾的 /）。
+//
+// fn 在所有配置源合并完成后、[Hooks] 的 OnBeforeUnmarshal 和解析到结构体之前调用，
+// 接收合并后的值（来自环境变量或 CLI 时可能是字符串），返回值写回该 key。
+// key 不存在时不调用；fn 返回 error 时中止加载。
+// 同一 key 注册多个函数时按注册顺序依次执行。
+//
+// 示例：
+//
+//	cfgm.WithValueTransformer("api.base_url", func(v any) (any, error) {
+//	    s := fmt.Sprint(v)
+//	    if !strings.HasSuffix(s, "/") {
+//	        s += "/"
+//	    }
+//	    return s, nil
+//	})
+func WithValueTransformer(path string, fn func(any) (any, error)) Option {
+	return func(o *options) {
+		o.valueTransformers = append(o.valueTransformers, valueTransformer{path: path, fn: fn})
+	}
+}
+
+// Metrics 接收配置加载的观测数据，由应用对接 Prometheus 等指标系统，本包不依赖任何指标库。
+type Metrics interface {
+	// ObserveLoad 在每次加载结束时调用一次。
+	// duration 为加载耗时，source 为加载的配置文件路径（未找到配置文件时为空字符串），
+	// err 为加载返回的错误，成功时为 nil。
+	ObserveLoad(duration time.Duration, source string, err error)
+}
+
+// WithMetrics 设置加载指标回调，每次 [Load]（包括 [Watch] 等触发的重新加载）结束时调用一次。
+//
+// 多次调用时后设置的生效。
+//
+// 示例：
+//
+//	type promMetrics struct{ hist *prometheus.HistogramVec }
+//
+//	func (m promMetrics) ObserveLoad(d time.Duration, source string, err error) {
+//	    m.hist.WithLabelValues(strconv.FormatBool(err == nil)).Observe(d.Seconds())
+//	}
+//
+//	cfgm.Load(DefaultConfig(), cfgm.WithMetrics(promMetrics{hist}))
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// runFileLoaded 触发 OnFileLoaded 回调。
+func (o *options) runFileLoaded(path string) {
+	for _, h := range o.hooks {
+		if h.OnFileLoaded != nil {
+			h.OnFileLoaded(path)
+		}
+	}
+}
+
+// runBeforeUnmarshal 触发 OnBeforeUnmarshal 回调，遇到第一个错误即返回。
+func (o *options) runBeforeUnmarshal(k *koanf.Koanf) error {
+	for _, h := range o.hooks {
+		if h.OnBeforeUnmarshal == nil {
+			continue
+		}
+		if err := h.OnBeforeUnmarshal(k); err != nil {
+			return fmt.Errorf("before unmarshal hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyValueTransformers 按注册顺序执行 [WithValueTransformer] 的转换函数，遇到第一个错误即返回。
+func (o *options) applyValueTransformers(k *koanf.Koanf) error {
+	for _, t := range o.valueTransformers {
+		if !k.Exists(t.path) {
+			continue
+		}
+		val, err := t.fn(k.Get(t.path))
+		if err != nil {
+			return fmt.Errorf("transform %s: %w", t.path, err)
+		}
+		_ = k.Set(t.path, val)
+	}
+
+	return nil
+}
+
+// runLoaded 触发 OnLoaded 回调，遇到第一个错误即返回。
+func (o *options) runLoaded(cfg any) error {
+	for _, h := range o.hooks {
+		if h.OnLoaded == nil {
+			continue
+		}
+		if err := h.OnLoaded(cfg); err != nil {
+			return fmt.Errorf("loaded hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// runAfterLoad 执行 [WithAfterLoad] 设置的回调，遇到第一个错误即返回。
+func (o *options) runAfterLoad(cfg any) error {
+	for _, fn := range o.afterLoad {
+		if err := fn(cfg); err != nil {
+			return fmt.Errorf("after load: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package cfgm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// 生命周期回调测试
+// =============================================================================
+
+func TestLoadWithHooks(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+	tmpFile := writeTempConfig(t, "name: from-file\n")
+	t.Setenv("HOOK_PORT", "9090")
+
+	t.Run("fires in order", func(t *testing.T) {
+		var calls []string
+		cfg, err := Load(Config{Name: "default", Port: 8080},
+			WithConfigPaths(tmpFile),
+			WithEnvPrefix("HOOK_"),
+			WithHooks(Hooks{
+				OnFileLoaded: func(path string) {
+					calls = append(calls, "file")
+					assert.Equal(t, tmpFile, path)
+				},
+				OnBeforeUnmarshal: func(k *koanf.Koanf) error {
+					calls = append(calls, "before")
+					assert.Equal(t, "from-file", k.String("name"))
+					assert.Equal(t, "9090", k.String("port"), "env already merged")
+
+					return k.Set("name", "from-hook")
+				},
+				OnLoaded: func(cfg any) error {
+					calls = append(calls, "loaded")
+					c, ok := cfg.(*Config)
+					require.True(t, ok)
+					assert.Equal(t, 9090, c.Port)
+
+					return nil
+				},
+			}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"file", "before", "loaded"}, calls)
+		assert.Equal(t, "from-hook", cfg.Name)
+	})
+
+	t.Run("no config file skips OnFileLoaded", func(t *testing.T) {
+		var calls []string
+		_, err := Load(Config{}, WithConfigPaths("missing.yaml"), WithHooks(Hooks{
+			OnFileLoaded: func(string) { calls = append(calls, "file") },
+			OnLoaded:     func(any) error { calls = append(calls, "loaded"); return nil },
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loaded"}, calls)
+	})
+
+	t.Run("errors abort load", func(t *testing.T) {
+		errHook := errors.New("rejected")
+
+		_, err := Load(Config{}, WithHooks(Hooks{
+			OnBeforeUnmarshal: func(*koanf.Koanf) error { return errHook },
+			OnLoaded:          func(any) error { t.Fatal("OnLoaded should not run"); return nil },
+		}))
+		require.ErrorIs(t, err, errHook)
+		assert.Contains(t, err.Error(), "before unmarshal hook")
+
+		_, err = Load(Config{}, WithHooks(Hooks{OnLoaded: func(any) error { return errHook }}))
+		require.ErrorIs(t, err, errHook)
+		assert.Contains(t, err.Error(), "loaded hook")
+	})
+}
+
+// =============================================================================
+// WithAfterLoad 测试
+// =============================================================================
+
+func TestLoadWithAfterLoad(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name" validate:"required"`
+		Port int    `koanf:"port"`
+	}
+	validate := WithHooks(Hooks{OnLoaded: func(cfg any) error { return ValidateStruct(cfg) }})
+
+	t.Run("runs after validation", func(t *testing.T) {
+		var calls []string
+		cfg, err := Load(Config{Name: "app", Port: 8080},
+			WithAfterLoad(func(cfg *Config) error {
+				calls = append(calls, "after:"+cfg.Name)
+
+				return nil
+			}),
+			WithHooks(Hooks{OnLoaded: func(any) error {
+				calls = append(calls, "validate")
+
+				return nil
+			}}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "app", cfg.Name)
+		assert.Equal(t, []string{"validate", "after:app"}, calls)
+	})
+
+	t.Run("skipped when validation fails", func(t *testing.T) {
+		called := false
+		_, err := Load(Config{}, validate, WithAfterLoad(func(*Config) error {
+			called = true
+
+			return nil
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "name: is required")
+		assert.False(t, called)
+	})
+
+	t.Run("error discards config", func(t *testing.T) {
+		var second bool
+		cfg, err := Load(Config{Name: "app"}, validate,
+			WithAfterLoad(func(*Config) error { return errors.New("pool init failed") }),
+			WithAfterLoad(func(*Config) error {
+				second = true
+
+				return nil
+			}),
+		)
+		require.Error(t, err)
+		assert.Nil(t, cfg)
+		assert.Equal(t, "after load: pool init failed", err.Error())
+		assert.False(t, second)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		type Other struct{}
+		_, err := Load(Config{Name: "app"}, WithAfterLoad(func(*Other) error { return nil }))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+	})
+}
+
+// =============================================================================
+// 值转换测试
+// =============================================================================
+
+func TestLoadWithValueTransformer(t *testing.T) {
+	type Config struct {
+		BaseURL string `koanf:"base_url"`
+		Admin   struct {
+			Email string `koanf:"email"`
+		} `koanf:"admin"`
+	}
+	tmpFile := writeTempConfig(t, "base_url: https://example.com/api\nadmin:\n  email: Admin@Example.COM\n")
+
+	trailingSlash := WithValueTransformer("base_url", func(v any) (any, error) {
+		s := fmt.Sprint(v)
+		if !strings.HasSuffix(s, "/") {
+			s += "/"
+		}
+
+		return s, nil
+	})
+	lower := WithValueTransformer("admin.email", func(v any) (any, error) {
+		return strings.ToLower(fmt.Sprint(v)), nil
+	})
+
+	t.Run("normalizes values", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), trailingSlash, lower)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/api/", cfg.BaseURL)
+		assert.Equal(t, "admin@example.com", cfg.Admin.Email)
+	})
+
+	t.Run("applies to env values", func(t *testing.T) {
+		t.Setenv("APP_BASE_URL", "https://env.example.com/")
+
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithEnvPrefix("APP_"), trailingSlash)
+		require.NoError(t, err)
+		assert.Equal(t, "https://env.example.com/", cfg.BaseURL)
+	})
+
+	t.Run("error aborts load", func(t *testing.T) {
+		_, err := Load(Config{}, WithConfigPaths(tmpFile), WithValueTransformer("admin.email", func(any) (any, error) {
+			return nil, errors.New("invalid email")
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "transform admin.email: invalid email")
+	})
+}
+
+// =============================================================================
+// 加载指标测试
+// =============================================================================
+
+// fakeMetrics 记录每次 ObserveLoad 调用。
+type fakeMetrics struct {
+	calls []fakeObservation
+}
+
+type fakeObservation struct {
+	duration time.Duration
+	source   string
+	err      error
+}
+
+func (m *fakeMetrics) ObserveLoad(duration time.Duration, source string, err error) {
+	m.calls = append(m.calls, fakeObservation{duration: duration, source: source, err: err})
+}
+
+func TestLoadWithMetrics(t *testing.T) {
+	type Config struct {
+		Port int `koanf:"port"`
+	}
+
+	t.Run("success", func(t *testing.T) {
+		path := writeTempConfig(t, "port: 9090\n")
+		m := &fakeMetrics{}
+
+		_, err := Load(Config{}, WithConfigPaths(path), WithMetrics(m))
+		require.NoError(t, err)
+		require.Len(t, m.calls, 1)
+		assert.Equal(t, path, m.calls[0].source)
+		require.NoError(t, m.calls[0].err)
+		assert.Positive(t, m.calls[0].duration)
+	})
+
+	t.Run("no config file", func(t *testing.T) {
+		m := &fakeMetrics{}
+
+		_, err := Load(Config{}, WithBaseDir(t.TempDir()), WithConfigPaths("missing.yaml"), WithMetrics(m))
+		require.NoError(t, err)
+		require.Len(t, m.calls, 1)
+		assert.Empty(t, m.calls[0].source)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		path := writeTempConfig(t, "port: not-a-number\n")
+		m := &fakeMetrics{}
+
+		_, err := Load(Config{}, WithConfigPaths(path), WithMetrics(m))
+		require.Error(t, err)
+		require.Len(t, m.calls, 1)
+		assert.Equal(t, path, m.calls[0].source)
+		require.Error(t, m.calls[0].err)
+		assert.Equal(t, err, m.calls[0].err)
+	})
+}
@@ -0,0 +1,122 @@
+package cfgm
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// includeTag YAML 包含指令的标签。
+const includeTag = "!include"
+
+// fileTag YAML 文件引用标签，将文件内容作为标量值。
+const fileTag = "!file"
+
+// resolveIncludes 解析 YAML 内容中的 !include 指令和 !file 标签（见 [WithFileTags]），返回内联后的 YAML。
+func resolveIncludes(o *options, path string, content []byte) ([]byte, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		return content, nil // 空文件
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve include path %s: %w", path, err)
+	}
+	if err := inlineIncludes(o, &doc, absPath, []string{absPath}); err != nil {
+		return nil, err
+	}
+
+	return encodeYAMLNode(&doc), nil
+}
+
+// inlineIncludes 递归将带 !include 标签的节点替换为引用文件的内容，
+// 带 !file 标签的节点替换为引用文件内容的字符串。
+//
+// file 为当前节点所属文件的绝对路径，stack 为包含链，用于检测循环包含。
+func inlineIncludes(o *options, node *yamlv3.Node, file string, stack []string) error {
+	if node.Tag == fileTag && o.fileTags {
+		return inlineFileTag(o, node, file)
+	}
+	if node.Tag != includeTag || !o.includes {
+		for _, child := range node.Content {
+			if err := inlineIncludes(o, child, file, stack); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if node.Kind != yamlv3.ScalarNode || node.Value == "" {
+		return fmt.Errorf("%s:%d: %s requires a file path", file, node.Line, includeTag)
+	}
+
+	target := node.Value
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(file), target)
+	}
+	target = filepath.Clean(target)
+	if slices.Contains(stack, target) {
+		return fmt.Errorf("include cycle: %s", strings.Join(append(slices.Clip(stack), target), " -> "))
+	}
+
+	content, err := readConfigFile(o, target)
+	if err != nil {
+		return fmt.Errorf("%s:%d: include %s: %w", file, node.Line, node.Value, err)
+	}
+	content, err = expandConfigContent(o, target, content)
+	if err != nil {
+		return err
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("parse included file %s: %w", target, err)
+	}
+
+	// 空文件视为 null
+	root := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}
+	if doc.Kind == yamlv3.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	if err := inlineIncludes(o, root, target, append(slices.Clip(stack), target)); err != nil {
+		return err
+	}
+
+	o.logger.Debug("Included config file", "path", target, "from", file)
+	*node = *root
+
+	return nil
+}
+
+// inlineFileTag 将带 !file 标签的节点替换为引用文件的内容（字符串标量）。
+//
+// 与 !include 不同，文件内容不解析、不进行模板展开，原样作为值（如证书、密钥文件）。
+// 相对路径基于当前节点所属文件 file 所在目录。
+func inlineFileTag(o *options, node *yamlv3.Node, file string) error {
+	if node.Kind != yamlv3.ScalarNode || node.Value == "" {
+		return fmt.Errorf("%s:%d: %s requires a file path", file, node.Line, fileTag)
+	}
+
+	target := node.Value
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(file), target)
+	}
+
+	content, err := readRawConfigFile(o, filepath.Clean(target))
+	if err != nil {
+		return fmt.Errorf("%s:%d: %s %s: %w", file, node.Line, fileTag, node.Value, err)
+	}
+
+	o.logger.Debug("Loaded file reference", "path", target, "from", file)
+	*node = yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: string(content)}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package cfgm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// !include 指令测试
+// =============================================================================
+
+type includeTestConfig struct {
+	Name     string `koanf:"name"`
+	Database struct {
+		Host string `koanf:"host"`
+		Port int    `koanf:"port"`
+		Pool struct {
+			Size int `koanf:"size"`
+		} `koanf:"pool"`
+	} `koanf:"database"`
+}
+
+// writeFiles 在临时目录中写入多个文件，返回目录路径。
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	return dir
+}
+
+func TestLoadWithIncludes(t *testing.T) {
+	t.Run("nested include", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml":      "name: app\ndatabase: !include conf.d/db.yaml\n",
+			"conf.d/db.yaml":   "host: db.local\nport: 5432\npool: !include pool.yaml\n",
+			"conf.d/pool.yaml": "size: 20\n",
+		})
+
+		cfg, err := Load(includeTestConfig{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes())
+		require.NoError(t, err)
+
+		a := assert.New(t)
+		a.Equal("app", cfg.Name)
+		a.Equal("db.local", cfg.Database.Host)
+		a.Equal(5432, cfg.Database.Port)
+		a.Equal(20, cfg.Database.Pool.Size, "include path is relative to the including file")
+	})
+
+	t.Run("ConfigDir in included file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml":    "name: '{{.ConfigDir}}'\ndatabase: !include conf.d/db.yaml\n",
+			"conf.d/db.yaml": "host: '{{.ConfigDir}}/db.sock'\n",
+		})
+
+		cfg, err := Load(includeTestConfig{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes())
+		require.NoError(t, err)
+		assert.Equal(t, dir, cfg.Name)
+		assert.Equal(t, filepath.Join(dir, "conf.d", "db.sock"), cfg.Database.Host)
+	})
+
+	t.Run("included file is template expanded", func(t *testing.T) {
+		t.Setenv("INCLUDE_DB_HOST", "from-env")
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "database: !include db.yaml\n",
+			"db.yaml":     "host: '{{env `INCLUDE_DB_HOST`}}'\n",
+		})
+
+		cfg, err := Load(includeTestConfig{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes())
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", cfg.Database.Host)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "database: !include a.yaml\n",
+			"a.yaml":      "pool: !include b.yaml\n",
+			"b.yaml":      "size: !include a.yaml\n",
+		})
+
+		_, err := Load(includeTestConfig{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "include cycle")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "database: !include missing.yaml\n",
+		})
+
+		_, err := Load(includeTestConfig{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "include missing.yaml")
+	})
+}
+
+// =============================================================================
+// !file 标签测试
+// =============================================================================
+
+func TestLoadWithFileTags(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		TLS  struct {
+			Cert string `koanf:"cert"`
+		} `koanf:"tls"`
+		Database struct {
+			Password string `koanf:"password"`
+		} `koanf:"database"`
+	}
+	const pem = "-----BEGIN CERTIFICATE-----\nMIIB{{.NotATemplate}}\n-----END CERTIFICATE-----\n"
+
+	t.Run("scalar from file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml":   "name: app\ntls:\n  cert: !file ./certs/tls.pem\n",
+			"certs/tls.pem": pem,
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithFileTags())
+		require.NoError(t, err)
+		assert.Equal(t, "app", cfg.Name)
+		assert.Equal(t, pem, cfg.TLS.Cert, "content is kept verbatim without template expansion")
+	})
+
+	t.Run("relative to included file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml":                "database: !include conf.d/db.yaml\n",
+			"conf.d/db.yaml":             "password: !file secrets/db_password\n",
+			"conf.d/secrets/db_password": "s3cret",
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithIncludes(), WithFileTags())
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.Database.Password)
+	})
+
+	t.Run("include not resolved without WithIncludes", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "name: !include name.txt\ntls:\n  cert: !file tls.pem\n",
+			"tls.pem":     "cert",
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithFileTags())
+		require.NoError(t, err)
+		assert.Equal(t, "name.txt", cfg.Name)
+		assert.Equal(t, "cert", cfg.TLS.Cert)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "tls:\n  cert: !file tls.pem\n",
+			"tls.pem":     "cert",
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.yaml")))
+		require.NoError(t, err)
+		assert.Equal(t, "tls.pem", cfg.TLS.Cert)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": "name: app\ntls:\n  cert: !file missing.pem\n",
+		})
+
+		_, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.yaml")), WithFileTags())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config.yaml:3: !file missing.pem")
+	})
+}
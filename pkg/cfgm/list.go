@@ -0,0 +1,70 @@
+package cfgm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// LoadList 加载顶级为列表的配置文件，解析为 []T。
+//
+// 适用于配置文件本身是数组的场景（如端点列表）：
+//
+//	# endpoints.yaml
+//	- name: primary
+//	  url: "https://a.example.com"
+//	- name: backup
+//	  url: "{{env `BACKUP_URL`}}"
+//
+// 配置文件的搜索和模板展开规则与 [Load] 相同，元素按 koanf tag 解码。
+// 由于环境变量和 CLI flags 基于配置路径映射，不适用于列表，因此这两层不会生效。
+// 未找到配置文件时返回空列表。
+//
+// 示例：
+//
+//	endpoints, err := cfgm.LoadList[Endpoint](cfgm.WithConfigPaths("endpoints.yaml"))
+func LoadList[T any](opts ...Option) ([]T, error) {
+	options := newOptions(2, opts)
+
+	path, content, err := findConfigFile(options)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		options.logger.Debug("No config file found, using empty list")
+
+		return nil, nil
+	}
+
+	var raw []any
+	if options.isJSONFile(path) {
+		err = json.Unmarshal(content, &raw)
+	} else {
+		err = yamlv3.Unmarshal(content, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	items := make([]T, 0, len(raw))
+	for i, elem := range raw {
+		// 借助 koanf 解码单个元素，保持与 Load 一致的 tag 和类型转换规则
+		k := koanf.New(".")
+		if err := k.Load(confmap.Provider(map[string]any{"item": elem}, ""), nil); err != nil {
+			return nil, fmt.Errorf("load element %d of %s: %w", i, path, err)
+		}
+
+		var item T
+		if err := unmarshalConfig(k, "item", &item, options.decodeHooks...); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal element %d of %s: %w", i, path, err)
+		}
+		items = append(items, item)
+	}
+
+	options.logger.Debug("Loaded config list from file", "path", path, "count", len(items))
+
+	return items, nil
+}
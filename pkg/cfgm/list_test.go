@@ -0,0 +1,65 @@
+package cfgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// LoadList 测试
+// =============================================================================
+
+type listTestEndpoint struct {
+	Name    string        `koanf:"name"`
+	URL     string        `koanf:"url"`
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+func TestLoadList(t *testing.T) {
+	t.Setenv("BACKUP_URL", "https://backup.example.com")
+
+	t.Run("yaml list", func(t *testing.T) {
+		configPath := writeTempConfig(t, `
+- name: primary
+  url: "https://a.example.com"
+  timeout: 5s
+- name: backup
+  url: '{{env "BACKUP_URL"}}'
+  timeout: 10s
+- name: local
+  url: "http://localhost:8080"
+`)
+		endpoints, err := LoadList[listTestEndpoint](WithConfigPaths(configPath))
+		require.NoError(t, err)
+		require.Len(t, endpoints, 3)
+
+		assert.Equal(t, []listTestEndpoint{
+			{Name: "primary", URL: "https://a.example.com", Timeout: 5 * time.Second},
+			{Name: "backup", URL: "https://backup.example.com", Timeout: 10 * time.Second},
+			{Name: "local", URL: "http://localhost:8080"},
+		}, endpoints)
+	})
+
+	t.Run("json list", func(t *testing.T) {
+		configPath := writeTempJSONConfig(t, `[{"name": "a", "url": "http://a"}, {"name": "b", "url": "http://b"}]`)
+		endpoints, err := LoadList[listTestEndpoint](WithConfigPaths(configPath))
+		require.NoError(t, err)
+		require.Len(t, endpoints, 2)
+		assert.Equal(t, "b", endpoints[1].Name)
+	})
+
+	t.Run("no config file", func(t *testing.T) {
+		endpoints, err := LoadList[listTestEndpoint](WithConfigPaths("nonexistent.yaml"))
+		require.NoError(t, err)
+		assert.Empty(t, endpoints)
+	})
+
+	t.Run("top level is not a list", func(t *testing.T) {
+		configPath := writeTempConfig(t, "name: primary\n")
+		_, err := LoadList[listTestEndpoint](WithConfigPaths(configPath))
+		assert.ErrorContains(t, err, "parse config file")
+	})
+}
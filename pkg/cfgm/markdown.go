@@ -0,0 +1,134 @@
+package cfgm
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// MarkdownDocs 将配置结构体的所有配置项生成为 Markdown 表格，用于 README 等文档。
+//
+// 每行对应一个叶子配置项，列出 koanf key、Go 类型、默认值（cfg 中的值）和描述（desc 标签）。
+// 通过 [WithExampleEnvPrefix] 设置前缀后增加 Env Var 列，环境变量名按 [WithEnvPrefix] 的规则生成，
+// 使表格同时说明配置文件和环境变量两种配置方式。
+// 默认值格式与 [FlattenConfig] 一致，sensitive:"true" 字段的默认值已脱敏；
+// 必填字段在描述后追加 (required)。
+//
+// 示例：
+//
+//	docs := cfgm.MarkdownDocs(DefaultConfig(), cfgm.WithExampleEnvPrefix("APP_"))
+//
+//	// | Key | Type | Default | Env Var | Description |
+//	// | --- | --- | --- | --- | --- |
+//	// | `server.addr` | `string` | `:8080` | `APP_SERVER_ADDR` | 监听地址 |
+func MarkdownDocs[T any](cfg T, opts ...ExampleOption) []byte {
+	o := &exampleOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	redacted := Redacted(cfg)
+	defaults := FlattenConfig(redacted)
+
+	header := []string{"Key", "Type", "Default"}
+	if o.envPrefix != "" {
+		header = append(header, "Env Var")
+	}
+	header = append(header, "Description")
+
+	var buf bytes.Buffer
+	writeMarkdownRow(&buf, header)
+	writeMarkdownRow(&buf, slices.Repeat([]string{"---"}, len(header)))
+
+	for _, f := range FieldMetadata(redacted) {
+		row := []string{markdownCode(f.Key), markdownCode(f.GoType), ""}
+		if def, ok := defaults[f.Key]; ok && def != "" {
+			row[2] = markdownCode(def)
+		}
+		if o.envPrefix != "" {
+			row = append(row, markdownCode(envKeyFor(o.envPrefix, f.Key)))
+		}
+		desc := f.Desc
+		if f.Required {
+			desc = strings.TrimSpace(desc + " (required)")
+		}
+		row = append(row, desc)
+		writeMarkdownRow(&buf, row)
+	}
+
+	return buf.Bytes()
+}
+
+// UsageDoc 生成配置项的完整参考文档（Markdown），同时说明配置文件、环境变量和 CLI flag 三种配置方式。
+//
+// 每个叶子配置项一节，依次列出 YAML 路径、环境变量名（按 [WithEnvPrefix] 的规则生成，envPrefix 为空时省略）、
+// CLI flag（kebab-case 和 dot notation 两种形式，见 [WithCommand]）、Go 类型、默认值和描述。
+// 默认值与 [MarkdownDocs] 一致：sensitive:"true" 字段已脱敏，必填字段在描述后追加 (required)。
+//
+// 示例：
+//
+//	os.WriteFile("docs/config.md", cfgm.UsageDoc(DefaultConfig(), "APP_"), 0644)
+//
+//	// ## `server.addr`
+//	//
+//	// 监听地址
+//	//
+//	// - YAML: `server.addr`
+//	// - Env: `APP_SERVER_ADDR`
+//	// - CLI: `--server-addr`, `--server.addr`
+//	// - Type: `string`
+//	// - Default: `:8080`
+func UsageDoc[T any](cfg T, envPrefix string) []byte {
+	redacted := Redacted(cfg)
+	defaults := FlattenConfig(redacted)
+
+	var buf bytes.Buffer
+	for i, f := range FieldMetadata(redacted) {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("## " + markdownCode(f.Key) + "\n\n")
+
+		desc := f.Desc
+		if f.Required {
+			desc = strings.TrimSpace(desc + " (required)")
+		}
+		if desc != "" {
+			buf.WriteString(desc + "\n\n")
+		}
+
+		buf.WriteString("- YAML: " + markdownCode(f.Key) + "\n")
+		if envPrefix != "" {
+			buf.WriteString("- Env: " + markdownCode(envKeyFor(envPrefix, f.Key)) + "\n")
+		}
+		flags := []string{markdownCode("--" + strings.ReplaceAll(f.Key, ".", "-"))}
+		if strings.Contains(f.Key, ".") {
+			flags = append(flags, markdownCode("--"+f.Key))
+		}
+		buf.WriteString("- CLI: " + strings.Join(flags, ", ") + "\n")
+		buf.WriteString("- Type: " + markdownCode(f.GoType) + "\n")
+		if def, ok := defaults[f.Key]; ok && def != "" {
+			buf.WriteString("- Default: " + markdownCode(def) + "\n")
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// writeMarkdownRow 写入一行 Markdown 表格，转义单元格中的 | 并将换行转为 <br>。
+func writeMarkdownRow(buf *bytes.Buffer, cells []string) {
+	escaper := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+	buf.WriteString("|")
+	for _, cell := range cells {
+		buf.WriteString(" ")
+		buf.WriteString(escaper.Replace(cell))
+		buf.WriteString(" |")
+	}
+	buf.WriteString("\n")
+}
+
+// markdownCode 将文本包装为 Markdown 行内代码。
+func markdownCode(s string) string {
+	return "`" + s + "`"
+}
@@ -0,0 +1,98 @@
+package cfgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// =============================================================================
+// MarkdownDocs 测试
+// =============================================================================
+
+func TestMarkdownDocs(t *testing.T) {
+	type Config struct {
+		Name   string `koanf:"name" desc:"应用名称" validate:"required"`
+		Server struct {
+			Addr    string        `koanf:"addr" desc:"监听地址"`
+			Timeout time.Duration `koanf:"timeout" desc:"超时时间\n包含读写"`
+		} `koanf:"server"`
+		Token   string   `koanf:"token" desc:"访问令牌" sensitive:"true"`
+		Tags    []string `koanf:"tags" desc:"a|b"`
+		Workers *int     `koanf:"workers"`
+	}
+	cfg := Config{Name: "app", Token: "secret", Tags: []string{"x"}}
+	cfg.Server.Addr = ":8080"
+	cfg.Server.Timeout = 30 * time.Second
+
+	t.Run("with env prefix", func(t *testing.T) {
+		assert.Equal(t, "| Key | Type | Default | Env Var | Description |\n"+
+			"| --- | --- | --- | --- | --- |\n"+
+			"| `name` | `string` | `app` | `APP_NAME` | 应用名称 (required) |\n"+
+			"| `server.addr` | `string` | `:8080` | `APP_SERVER_ADDR` | 监听地址 |\n"+
+			"| `server.timeout` | `time.Duration` | `30s` | `APP_SERVER_TIMEOUT` | 超时时间<br>包含读写 |\n"+
+			"| `token` | `string` | `******` | `APP_TOKEN` | 访问令牌 |\n"+
+			"| `tags` | `[]string` | `[\"x\"]` | `APP_TAGS` | a\\|b |\n"+
+			"| `workers` | `*int` |  | `APP_WORKERS` |  |\n",
+			string(MarkdownDocs(cfg, WithExampleEnvPrefix("APP_"))))
+	})
+
+	t.Run("without env prefix", func(t *testing.T) {
+		docs := string(MarkdownDocs(cfg))
+		assert.Contains(t, docs, "| Key | Type | Default | Description |\n")
+		assert.Contains(t, docs, "| `server.addr` | `string` | `:8080` | 监听地址 |\n")
+		assert.NotContains(t, docs, "APP_")
+		assert.NotContains(t, docs, "secret")
+	})
+}
+
+// =============================================================================
+// UsageDoc 测试
+// =============================================================================
+
+func TestUsageDoc(t *testing.T) {
+	type Config struct {
+		Name   string `koanf:"name" desc:"应用名称" validate:"required"`
+		Server struct {
+			Addr string `koanf:"addr" desc:"监听地址"`
+		} `koanf:"server"`
+		Token string `koanf:"token" sensitive:"true" required:"true"`
+	}
+	cfg := Config{Name: "app", Token: "secret"}
+	cfg.Server.Addr = ":8080"
+
+	t.Run("with env prefix", func(t *testing.T) {
+		assert.Equal(t, "## `name`\n\n"+
+			"应用名称 (required)\n\n"+
+			"- YAML: `name`\n"+
+			"- Env: `APP_NAME`\n"+
+			"- CLI: `--name`\n"+
+			"- Type: `string`\n"+
+			"- Default: `app`\n"+
+			"\n"+
+			"## `server.addr`\n\n"+
+			"监听地址\n\n"+
+			"- YAML: `server.addr`\n"+
+			"- Env: `APP_SERVER_ADDR`\n"+
+			"- CLI: `--server-addr`, `--server.addr`\n"+
+			"- Type: `string`\n"+
+			"- Default: `:8080`\n"+
+			"\n"+
+			"## `token`\n\n"+
+			"(required)\n\n"+
+			"- YAML: `token`\n"+
+			"- Env: `APP_TOKEN`\n"+
+			"- CLI: `--token`\n"+
+			"- Type: `string`\n"+
+			"- Default: `******`\n",
+			string(UsageDoc(cfg, "APP_")))
+	})
+
+	t.Run("without env prefix", func(t *testing.T) {
+		doc := string(UsageDoc(cfg, ""))
+		assert.Contains(t, doc, "- YAML: `server.addr`\n- CLI: `--server-addr`, `--server.addr`\n")
+		assert.NotContains(t, doc, "Env:")
+		assert.NotContains(t, doc, "secret")
+	})
+}
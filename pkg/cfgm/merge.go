@@ -0,0 +1,145 @@
+package cfgm
+
+import (
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+)
+
+// MergeFunc 自定义合并函数，见 [WithMergeFunc]。
+type MergeFunc func(path string, a, b any) (any, bool)
+
+// WithMergeFunc 设置自定义的配置源合并规则。
+//
+// 默认合并规则为：map 深度合并，其他值由高优先级来源替换。
+// 合并配置源（如配置文件合并到默认值）时，对两侧都存在的每个配置路径调用 fn，
+// a 为已有值（低优先级），b 为新来源的值（高优先级）。
+// 返回 (value, true) 时使用 value 作为该路径的结果，返回 (_, false) 时使用默认规则。
+//
+// 环境变量和 CLI flags 为显式覆盖，不经过合并函数。
+//
+// 示例：workers 取两者较大值
+//
+//	cfgm.WithMergeFunc(func(path string, a, b any) (any, bool) {
+//	    if path != "workers" {
+//	        return nil, false
+//	    }
+//	    x, okA := a.(int)
+//	    y, okB := b.(int)
+//	    if !okA || !okB {
+//	        return nil, false
+//	    }
+//	    return max(x, y), true
+//	})
+func WithMergeFunc(fn MergeFunc) Option {
+	return func(o *options) {
+		o.mergeFunc = fn
+	}
+}
+
+// WithKeyMapper 设置配置源 key 的转换函数，在合并前将外部命名规范的 key 转换为 koanf tag 对应的 key。
+//
+// 作用于配置文件和 [WithProvider] 添加的配置源，不影响默认值、环境变量和 CLI flags。
+// fn 接收每个叶子配置项的完整 key（嵌套 key 以 "." 连接），返回值中的 "." 表示嵌套层级；
+// 返回空字符串时丢弃该配置项。
+//
+// 示例：camelCase 的 serverAddr 转换为 server.addr
+//
+//	cfgm.WithKeyMapper(func(key string) string {
+//	    return strings.ToLower(regexp.MustCompile(`([a-z0-9])([A-Z])`).ReplaceAllString(key, "$1.$2"))
+//	})
+func WithKeyMapper(fn func(key string) string) Option {
+	return func(o *options) {
+		o.keyMapper = fn
+	}
+}
+
+// WithIgnoreKeys 在解析到结构体前从合并后的配置中删除指定的 key，用于剥离 version、envbind 等元数据节点。
+//
+// key 使用点号分隔的完整路径（如 "meta.version"），删除节点时一并删除其子节点，
+// 删除后为空的父节点也会移除。删除作用于合并后的结果（包括默认值），
+// 因此 key 对应结构体字段时该字段解析为零值。[WithEnvBindKey] 的绑定节点在删除前已被读取，不受影响。
+// 多次调用会追加。
+//
+// 示例：
+//
+//	cfgm.Load(defaultConfig, cfgm.WithIgnoreKeys("version", "envbind"))
+func WithIgnoreKeys(keys ...string) Option {
+	return func(o *options) {
+		o.ignoreKeys = append(o.ignoreKeys, keys...)
+	}
+}
+
+// deleteIgnoredKeys 删除 [WithIgnoreKeys] 指定的 key。
+func (o *options) deleteIgnoredKeys(k *koanf.Koanf) {
+	for _, key := range o.ignoreKeys {
+		if k.Exists(key) {
+			k.Delete(key)
+			o.logger.Debug("Ignored config key", "key", key)
+		}
+	}
+}
+
+// loadInto 将配置源加载并合并到 k，设置了 [WithKeyMapper] 时先转换 key。
+func (o *options) loadInto(k *koanf.Koanf, provider koanf.Provider, parser koanf.Parser) error {
+	if o.keyMapper == nil {
+		return k.Load(provider, parser, o.koanfLoadOptions()...)
+	}
+
+	src := koanf.New(".")
+	if err := src.Load(provider, parser); err != nil {
+		return err
+	}
+
+	mapped := make(map[string]any, len(src.Keys()))
+	for key, value := range src.All() {
+		if newKey := o.keyMapper(key); newKey != "" {
+			mapped[newKey] = value
+		}
+	}
+
+	return k.Load(confmap.Provider(mapped, "."), nil, o.koanfLoadOptions()...)
+}
+
+// koanfLoadOptions 返回加载配置源时使用的 koanf 选项。
+func (o *options) koanfLoadOptions() []koanf.Option {
+	if o.mergeFunc == nil {
+		return nil
+	}
+
+	return []koanf.Option{koanf.WithMergeFunc(func(src, dest map[string]any) error {
+		mergeWithFunc(src, dest, "", o.mergeFunc)
+
+		return nil
+	})}
+}
+
+// mergeWithFunc 将 src 合并到 dest，两侧都存在的路径优先交由 fn 处理。
+func mergeWithFunc(src, dest map[string]any, prefix string, fn MergeFunc) {
+	for key, srcVal := range src {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		destVal, exists := dest[key]
+		if !exists {
+			dest[key] = srcVal
+
+			continue
+		}
+		if v, ok := fn(path, destVal, srcVal); ok {
+			dest[key] = v
+
+			continue
+		}
+
+		srcMap, srcIsMap := srcVal.(map[string]any)
+		destMap, destIsMap := destVal.(map[string]any)
+		if srcIsMap && destIsMap {
+			mergeWithFunc(srcMap, destMap, path, fn)
+
+			continue
+		}
+		dest[key] = srcVal
+	}
+}
@@ -0,0 +1,199 @@
+package cfgm
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// 自定义合并规则测试
+// =============================================================================
+
+func TestLoadWithMergeFunc(t *testing.T) {
+	type Pool struct {
+		Workers int `koanf:"workers"`
+		Queue   int `koanf:"queue"`
+	}
+	type Config struct {
+		Name string `koanf:"name"`
+		Pool Pool   `koanf:"pool"`
+	}
+	defaultCfg := Config{Name: "default", Pool: Pool{Workers: 8, Queue: 100}}
+
+	var calls []string
+	maxWorkers := WithMergeFunc(func(path string, a, b any) (any, bool) {
+		calls = append(calls, path)
+		if path != "pool.workers" {
+			return nil, false
+		}
+		x, okA := a.(int)
+		y, okB := b.(int)
+		if !okA || !okB {
+			return nil, false
+		}
+
+		return max(x, y), true
+	})
+
+	tests := []struct {
+		name        string
+		content     string
+		wantWorkers int
+		wantQueue   int
+	}{
+		{"lower file value keeps default", "pool:\n  workers: 4\n  queue: 50\n", 8, 50},
+		{"higher file value wins", "pool:\n  workers: 16\n", 16, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			tmpFile := writeTempConfig(t, tt.content)
+
+			cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile), maxWorkers)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWorkers, cfg.Pool.Workers)
+			assert.Equal(t, tt.wantQueue, cfg.Pool.Queue, "other paths use default merge")
+			assert.Equal(t, "default", cfg.Name)
+			assert.Contains(t, calls, "pool.workers")
+		})
+	}
+
+	t.Run("env overrides bypass merge func", func(t *testing.T) {
+		t.Setenv("MERGE_POOL_WORKERS", "2")
+		tmpFile := writeTempConfig(t, "pool:\n  workers: 4\n")
+
+		cfg, err := Load(defaultCfg, WithConfigPaths(tmpFile), WithEnvPrefix("MERGE_"), maxWorkers)
+		require.NoError(t, err)
+		assert.Equal(t, 2, cfg.Pool.Workers)
+	})
+}
+
+// =============================================================================
+// WithKeyMapper 测试
+// =============================================================================
+
+func TestLoadWithKeyMapper(t *testing.T) {
+	type Config struct {
+		Name   string `koanf:"name"`
+		Server struct {
+			Addr    string        `koanf:"addr"`
+			Timeout time.Duration `koanf:"timeout"`
+		} `koanf:"server"`
+	}
+
+	// camelCase → 点号分隔的小写 key，如 serverAddr → server.addr
+	camelRe := regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	camelToDotted := func(key string) string {
+		return strings.ToLower(camelRe.ReplaceAllString(key, "$1.$2"))
+	}
+
+	t.Run("camelCase json", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.json": `{"name": "app", "serverAddr": ":9090", "serverTimeout": "5s"}`,
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(filepath.Join(dir, "config.json")), WithKeyMapper(camelToDotted))
+		require.NoError(t, err)
+		assert.Equal(t, "app", cfg.Name)
+		assert.Equal(t, ":9090", cfg.Server.Addr)
+		assert.Equal(t, 5*time.Second, cfg.Server.Timeout)
+	})
+
+	t.Run("merges with defaults and providers", func(t *testing.T) {
+		defaultCfg := Config{Name: "default"}
+		defaultCfg.Server.Timeout = time.Second
+
+		cfg, err := Load(defaultCfg,
+			WithConfigPaths("nonexistent.yaml"),
+			WithProvider(confmap.Provider(map[string]any{"serverAddr": ":7070"}, "."), nil),
+			WithKeyMapper(camelToDotted),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "default", cfg.Name)
+		assert.Equal(t, ":7070", cfg.Server.Addr)
+		assert.Equal(t, time.Second, cfg.Server.Timeout)
+	})
+
+	t.Run("empty key drops value", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: app\nlegacy: true\n")
+
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithKeyMapper(func(key string) string {
+			if key == "name" {
+				return ""
+			}
+
+			return key
+		}))
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Name)
+	})
+}
+
+// =============================================================================
+// WithIgnoreKeys 测试
+// =============================================================================
+
+func TestLoadWithIgnoreKeys(t *testing.T) {
+	type Config struct {
+		Version int `koanf:"version"`
+		Redis   struct {
+			URL string `koanf:"url"`
+		} `koanf:"redis"`
+		Meta map[string]any `koanf:"meta"`
+	}
+	path := writeTempConfig(t, `
+version: v2-beta
+envbind:
+  CACHE_URL: redis.url
+meta:
+  owner: ops
+  schema: 3
+redis:
+  url: redis://file:6379
+`)
+
+	t.Run("without ignore fails to decode meta key", func(t *testing.T) {
+		_, err := Load(Config{Meta: map[string]any{}}, WithConfigPaths(path))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "version")
+	})
+
+	t.Run("ignored keys are stripped before unmarshal", func(t *testing.T) {
+		var seen []string
+		cfg, err := Load(Config{Version: 1, Meta: map[string]any{}},
+			WithConfigPaths(path),
+			WithIgnoreKeys("version", "envbind", "meta.schema", "missing.key"),
+			WithHooks(Hooks{OnBeforeUnmarshal: func(k *koanf.Koanf) error {
+				seen = k.Keys()
+
+				return nil
+			}}),
+		)
+		require.NoError(t, err)
+		assert.Zero(t, cfg.Version, "default is removed along with the key")
+		assert.Equal(t, "redis://file:6379", cfg.Redis.URL)
+		assert.Equal(t, map[string]any{"owner": "ops"}, cfg.Meta)
+		assert.Equal(t, []string{"meta.owner", "redis.url"}, seen)
+	})
+
+	t.Run("env bind key still read", func(t *testing.T) {
+		t.Setenv("CACHE_URL", "redis://env:6379")
+
+		cfg, err := Load(Config{Meta: map[string]any{}},
+			WithConfigPaths(path),
+			WithEnvBindKey("envbind"),
+			WithIgnoreKeys("version", "envbind"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "redis://env:6379", cfg.Redis.URL)
+	})
+}
@@ -0,0 +1,92 @@
+package cfgm
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Field 描述单个配置项的元数据，用于生成管理界面等工具。
+type Field struct {
+	Key       string // 完整的 koanf key，如 server.port
+	GoType    string // Go 类型名称，如 int、time.Duration、[]string
+	Default   any    // cfg 中的值，指针字段取指向的值，nil 指针为 nil
+	Desc      string // desc 标签
+	Sensitive bool   // 是否标记 sensitive:"true"
+	Required  bool   // 是否为必填：validate 标签包含 required 规则，或设置了 required:"true"
+}
+
+// FieldMetadata 返回配置结构体所有叶子配置项的元数据，按字段定义顺序排列。
+//
+// 与 [DiffConfig] 相同，嵌套结构体展开到叶子节点，切片和 map 字段作为单个配置项；
+// 未设置 koanf tag 的字段跳过。cfg 中的值作为 Default，通常传入默认配置。
+//
+// 示例：
+//
+//	for _, f := range cfgm.FieldMetadata(DefaultConfig()) {
+//	    fmt.Printf("%s (%s) = %v  %s\n", f.Key, f.GoType, f.Default, f.Desc)
+//	}
+func FieldMetadata[T any](cfg T) []Field {
+	var fields []Field
+	fieldMetadataRecursive(reflect.ValueOf(cfg), "", &fields)
+
+	return fields
+}
+
+// fieldMetadataRecursive 递归收集结构体字段的元数据，结果追加到 fields。
+func fieldMetadataRecursive(val reflect.Value, prefix string, fields *[]Field) {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			val = reflect.Zero(val.Type().Elem())
+		} else {
+			val = val.Elem()
+		}
+	}
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		key := field.Tag.Get("koanf")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		fieldVal := val.Field(i)
+		if isNestedStruct(field.Type) {
+			fieldMetadataRecursive(fieldVal, key, fields)
+
+			continue
+		}
+
+		var def any
+		if fieldVal.Kind() != reflect.Pointer || !fieldVal.IsNil() {
+			def = reflect.Indirect(fieldVal).Interface()
+		}
+
+		*fields = append(*fields, Field{
+			Key:       key,
+			GoType:    field.Type.String(),
+			Default:   def,
+			Desc:      field.Tag.Get("desc"),
+			Sensitive: field.Tag.Get("sensitive") == "true",
+			Required:  isRequiredField(field),
+		})
+	}
+}
+
+// hasValidateRule 判断字段的 validate 标签是否包含指定规则。
+func hasValidateRule(field reflect.StructField, name string) bool {
+	rules := strings.Split(field.Tag.Get("validate"), ",")
+
+	return slices.ContainsFunc(rules, func(rule string) bool {
+		ruleName, _, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		return ruleName == name
+	})
+}
@@ -0,0 +1,56 @@
+package cfgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// =============================================================================
+// FieldMetadata 测试
+// =============================================================================
+
+func TestFieldMetadata(t *testing.T) {
+	type Database struct {
+		Host     string        `koanf:"host" desc:"数据库地址" validate:"required"`
+		Password string        `koanf:"password" desc:"数据库密码" sensitive:"true"`
+		Timeout  time.Duration `koanf:"timeout" validate:"min=1s, required"`
+		Token    string        `koanf:"token" required:"true"`
+	}
+	type Config struct {
+		Name     string   `koanf:"name" desc:"应用名称"`
+		Workers  *int     `koanf:"workers"`
+		Tags     []string `koanf:"tags"`
+		Database Database `koanf:"database"`
+		Ignored  string
+	}
+
+	workers := 4
+	cfg := Config{
+		Name:    "app",
+		Workers: &workers,
+		Tags:    []string{"a"},
+		Database: Database{
+			Host:     "localhost",
+			Password: "secret",
+			Timeout:  5 * time.Second,
+		},
+	}
+
+	assert.Equal(t, []Field{
+		{Key: "name", GoType: "string", Default: "app", Desc: "应用名称"},
+		{Key: "workers", GoType: "*int", Default: 4},
+		{Key: "tags", GoType: "[]string", Default: []string{"a"}},
+		{Key: "database.host", GoType: "string", Default: "localhost", Desc: "数据库地址", Required: true},
+		{Key: "database.password", GoType: "string", Default: "secret", Desc: "数据库密码", Sensitive: true},
+		{Key: "database.timeout", GoType: "time.Duration", Default: 5 * time.Second, Required: true},
+		{Key: "database.token", GoType: "string", Default: "", Required: true},
+	}, FieldMetadata(cfg))
+
+	t.Run("nil pointer default", func(t *testing.T) {
+		fields := FieldMetadata(&Config{})
+		assert.Equal(t, "workers", fields[1].Key)
+		assert.Nil(t, fields[1].Default)
+	})
+}
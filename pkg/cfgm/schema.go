@@ -0,0 +1,426 @@
+package cfgm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// jsonSchemaDraft 生成的 JSON Schema 使用的规范版本。
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern 匹配 time.ParseDuration 可解析的时长字符串（如 30s、1h30m、-1.5h）。
+const durationPattern = `^[-+]?([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$|^[-+]?0$`
+
+// JSONSchema 根据配置结构体生成 JSON Schema (draft 2020-12)。
+//
+// 属性名使用 koanf tag，desc tag 作为 description，cfg 中的标量值作为 default。
+// 类型映射：
+//   - time.Duration → string，带时长格式的 pattern
+//   - time.Time → string，format 为 date-time
+//   - 切片 → array，map → object（additionalProperties 描述值类型）
+//   - 指针 → 指向的类型
+//
+// 生成的 Schema 可提供给编辑器（如 VS Code 的 yaml.schemas）实现配置补全和校验。
+//
+// 使用示例：
+//
+//	schema, err := cfgm.JSONSchema(DefaultConfig())
+//	os.WriteFile("config/config.schema.json", schema, 0644)
+func JSONSchema[T any](cfg T) ([]byte, error) {
+	return marshalSchema(buildJSONSchema(cfg), "json")
+}
+
+// SchemaCommand 返回输出配置 JSON Schema 的子命令，可直接加入应用的命令列表。
+//
+// 命令名为 schema，通过 --format 选择输出格式（json 或 yaml，默认 json），
+// 输出写入根命令的 Writer（默认 os.Stdout）。
+//
+// 使用示例：
+//
+//	app := &cli.Command{
+//	    Name:     "myapp",
+//	    Commands: []*cli.Command{cfgm.SchemaCommand(DefaultConfig())},
+//	}
+//
+//	// myapp schema --format yaml
+func SchemaCommand[T any](defaultConfig T) *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "输出配置的 JSON Schema",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "json",
+				Usage: "输出格式 (json/yaml)",
+			},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			out, err := marshalSchema(buildJSONSchema(defaultConfig), cmd.String("format"))
+			if err != nil {
+				return err
+			}
+			_, err = cmd.Root().Writer.Write(out)
+
+			return err
+		},
+	}
+}
+
+// marshalSchema 将 Schema 序列化为指定格式。
+func marshalSchema(schema map[string]any, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal schema: %w", err)
+		}
+
+		return append(data, '\n'), nil
+	case "yaml", "yml":
+		data, err := yamlv3.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("marshal schema: %w", err)
+		}
+
+		return data, nil
+	default:
+		return nil, fmt.Errorf("marshal schema: unsupported format %q", format)
+	}
+}
+
+// buildJSONSchema 构建配置结构体的根 Schema。
+func buildJSONSchema[T any](cfg T) map[string]any {
+	schema := schemaForType(reflect.ValueOf(cfg), reflect.TypeOf(cfg))
+	schema["$schema"] = jsonSchemaDraft
+
+	return schema
+}
+
+// schemaForType 返回类型对应的 Schema。
+//
+// val 为该位置的值，用于生成 default；无可用值时（切片元素、map 值、nil 指针）传入零 reflect.Value。
+func schemaForType(val reflect.Value, typ reflect.Type) map[string]any {
+	if typ.Kind() == reflect.Pointer {
+		if val.IsValid() && !val.IsNil() {
+			val = val.Elem()
+		} else {
+			val = reflect.Value{}
+		}
+
+		return schemaForType(val, typ.Elem())
+	}
+
+	schema := make(map[string]any)
+	switch {
+	case typ == reflect.TypeFor[time.Duration]():
+		schema["type"] = "string"
+		schema["pattern"] = durationPattern
+	case typ == reflect.TypeFor[time.Time]():
+		schema["type"] = "string"
+		schema["format"] = "date-time"
+	case isNestedStruct(typ):
+		schema["type"] = "object"
+		schema["properties"] = structSchemaProperties(val, typ)
+
+		return schema
+	default:
+		switch typ.Kind() {
+		case reflect.String:
+			schema["type"] = "string"
+		case reflect.Bool:
+			schema["type"] = "boolean"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			schema["type"] = "integer"
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema["type"] = "integer"
+			schema["minimum"] = 0
+		case reflect.Float32, reflect.Float64:
+			schema["type"] = "number"
+		case reflect.Slice, reflect.Array:
+			schema["type"] = "array"
+			schema["items"] = schemaForType(reflect.Value{}, typ.Elem())
+		case reflect.Map:
+			schema["type"] = "object"
+			schema["additionalProperties"] = schemaForType(reflect.Value{}, typ.Elem())
+		}
+	}
+
+	if def, ok := schemaDefault(val); ok {
+		schema["default"] = def
+	}
+
+	return schema
+}
+
+// structSchemaProperties 返回结构体各 koanf 字段的属性 Schema，未设置 koanf tag 的字段跳过。
+func structSchemaProperties(val reflect.Value, typ reflect.Type) map[string]any {
+	props := make(map[string]any)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		key := field.Tag.Get("koanf")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+
+		var fieldVal reflect.Value
+		if val.IsValid() {
+			fieldVal = val.Field(i)
+		}
+		prop := schemaForType(fieldVal, field.Type)
+		if desc := field.Tag.Get("desc"); desc != "" {
+			prop["description"] = desc
+		}
+		props[key] = prop
+	}
+
+	return props
+}
+
+// schemaDefault 返回值在 Schema 中的 default 表示，仅支持标量和标量切片。
+//
+// time.Duration 使用 "30s" 格式，time.Time 使用 RFC3339，零值 time.Time 和空切片不输出。
+func schemaDefault(val reflect.Value) (any, bool) {
+	if !val.IsValid() {
+		return nil, false
+	}
+
+	switch val.Type() {
+	case reflect.TypeFor[time.Duration]():
+		return time.Duration(val.Int()).String(), true
+	case reflect.TypeFor[time.Time]():
+		t, _ := val.Interface().(time.Time)
+		if t.IsZero() {
+			return nil, false
+		}
+
+		return t.Format(time.RFC3339), true
+	}
+
+	switch val.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return val.Interface(), true
+	case reflect.Slice:
+		if val.Len() == 0 {
+			return nil, false
+		}
+		items := make([]any, 0, val.Len())
+		for j := range val.Len() {
+			item, ok := schemaDefault(val.Index(j))
+			if !ok {
+				return nil, false
+			}
+			items = append(items, item)
+		}
+
+		return items, true
+	default:
+		return nil, false
+	}
+}
+
+// WithSchemaValidation 在解析到结构体之前，按配置结构体生成的 JSON Schema（见 [JSONSchema]）
+// 校验合并后的配置，错误信息带完整的配置路径并汇总所有不匹配项，如：
+//
+//	server.port: expected integer, got string
+//
+// 环境变量和 CLI flag 的值均为字符串，可转换为目标类型的字符串（如 "8080" 之于 integer）视为匹配；
+// 数组字段同样接受字符串（逗号分隔的列表）。Schema 中不存在的 key 不校验，
+// 由 [LoadWithResult] 的 [WarningUnknownKey] 报告。错误信息不包含配置值，避免泄露敏感配置。
+//
+// 校验按字段的 Go 类型进行，由 [WithDecodeHook] 从其他表示转换的自定义类型可能无法通过校验。
+func WithSchemaValidation() Option {
+	return func(o *options) {
+		o.schemaValidation = true
+	}
+}
+
+// validateSchema 按 [schemaForType] 生成的 Schema 校验 value，返回汇总所有不匹配项的错误。
+func validateSchema(schema map[string]any, value any, path string) error {
+	var errs []error
+	validateSchemaRecursive(schema, value, path, &errs)
+
+	return errors.Join(errs...)
+}
+
+// validateSchemaRecursive 递归校验 value，错误追加到 errs。
+func validateSchemaRecursive(schema map[string]any, value any, path string, errs *[]error) {
+	want, _ := schema["type"].(string)
+	if val := reflect.ValueOf(value); val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return
+		}
+		value = val.Elem().Interface()
+	}
+	if want == "" || value == nil {
+		return
+	}
+
+	got := schemaTypeOf(value)
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, fmt.Errorf("%s: %s", schemaErrorPath(path), fmt.Sprintf(format, args...)))
+	}
+	mismatch := func() { fail("expected %s, got %s", want, got) }
+
+	switch want {
+	case "string":
+		s, isString := value.(string)
+		switch {
+		case schema["pattern"] == durationPattern:
+			if got == "integer" {
+				return
+			}
+			if !isString {
+				fail("expected duration, got %s", got)
+			} else if _, err := parseDuration(s); err != nil {
+				fail("expected duration, got invalid duration string")
+			}
+		case got == "array" || got == "object":
+			mismatch()
+		}
+	case "boolean":
+		// 与解码一致，接受 yes/no、on/off 等写法
+		if s, ok := value.(string); ok {
+			if _, err := parseBool(s); err != nil {
+				mismatch()
+			}
+		} else if got != "boolean" {
+			mismatch()
+		}
+	case "integer":
+		negative, ok := schemaInteger(value)
+		if !ok {
+			mismatch()
+		} else if schema["minimum"] == 0 && negative {
+			fail("must be >= 0, got negative integer")
+		}
+	case "number":
+		if s, ok := value.(string); ok {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				mismatch()
+			}
+		} else if got != "integer" && got != "number" {
+			mismatch()
+		}
+	case "array":
+		if got == "string" {
+			return
+		}
+		if got != "array" {
+			mismatch()
+
+			return
+		}
+		items, _ := schema["items"].(map[string]any)
+		val := reflect.ValueOf(value)
+		for i := range val.Len() {
+			validateSchemaRecursive(items, val.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case "object":
+		if got != "object" {
+			mismatch()
+
+			return
+		}
+		validateSchemaObject(schema, reflect.ValueOf(value), path, errs)
+	}
+}
+
+// validateSchemaObject 按 properties 或 additionalProperties 校验 map 的各个值，按 key 排序保证错误顺序稳定。
+func validateSchemaObject(schema map[string]any, val reflect.Value, path string, errs *[]error) {
+	props, _ := schema["properties"].(map[string]any)
+	additional, _ := schema["additionalProperties"].(map[string]any)
+
+	entries := make(map[string]any, val.Len())
+	iter := val.MapRange()
+	for iter.Next() {
+		entries[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+	}
+	for _, key := range slices.Sorted(maps.Keys(entries)) {
+		sub := additional
+		if props != nil {
+			sub, _ = props[key].(map[string]any)
+		}
+		if sub == nil {
+			continue
+		}
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		validateSchemaRecursive(sub, entries[key], childPath, errs)
+	}
+}
+
+// schemaTypeOf 返回值对应的 JSON Schema 类型名称。
+func schemaTypeOf(value any) string {
+	val := reflect.ValueOf(value)
+	switch {
+	case val.CanInt() || val.CanUint():
+		return "integer"
+	case val.CanFloat():
+		return "number"
+	}
+
+	switch val.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map:
+		return "object"
+	default:
+		return val.Type().String()
+	}
+}
+
+// schemaInteger 判断值是否为整数、整数值的浮点数或整数字符串，并返回其是否为负数。
+func schemaInteger(value any) (negative, ok bool) {
+	if s, isString := value.(string); isString {
+		if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return false, true
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+
+		return n < 0, err == nil
+	}
+
+	val := reflect.ValueOf(value)
+	switch {
+	case val.CanInt():
+		return val.Int() < 0, true
+	case val.CanUint():
+		return false, true
+	case val.CanFloat():
+		f := val.Float()
+
+		return f < 0, f == math.Trunc(f) && !math.IsInf(f, 0)
+	default:
+		return false, false
+	}
+}
+
+// schemaErrorPath 返回错误信息中的配置路径，根路径显示为 (root)。
+func schemaErrorPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+
+	return path
+}
@@ -0,0 +1,252 @@
+package cfgm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+	yamlv3 "go.yaml.in/yaml/v3"
+)
+
+// =============================================================================
+// JSON Schema 测试
+// =============================================================================
+
+type schemaServer struct {
+	Addr    string        `koanf:"addr" desc:"监听地址"`
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+type schemaConfig struct {
+	Name    string            `koanf:"name" desc:"应用名称"`
+	Debug   bool              `koanf:"debug"`
+	Workers *uint             `koanf:"workers"`
+	Ratio   float64           `koanf:"ratio"`
+	Tags    []string          `koanf:"tags"`
+	Labels  map[string]string `koanf:"labels"`
+	Server  schemaServer      `koanf:"server"`
+	Backups []schemaServer    `koanf:"backups"`
+	Ignored string
+}
+
+func defaultSchemaConfig() schemaConfig {
+	return schemaConfig{
+		Name:   "app",
+		Ratio:  0.5,
+		Tags:   []string{"a", "b"},
+		Server: schemaServer{Addr: ":8080", Timeout: 30 * time.Second},
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema(defaultSchemaConfig())
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	a := assert.New(t)
+	a.Equal(jsonSchemaDraft, schema["$schema"])
+	a.Equal("object", schema["type"])
+
+	props := schema["properties"].(map[string]any)
+	a.NotContains(props, "Ignored")
+	a.Equal(map[string]any{"type": "string", "description": "应用名称", "default": "app"}, props["name"])
+	a.Equal(map[string]any{"type": "boolean", "default": false}, props["debug"])
+	a.Equal(map[string]any{"type": "integer", "minimum": 0.0}, props["workers"])
+	a.Equal(map[string]any{"type": "number", "default": 0.5}, props["ratio"])
+	a.Equal(map[string]any{
+		"type":    "array",
+		"items":   map[string]any{"type": "string"},
+		"default": []any{"a", "b"},
+	}, props["tags"])
+	a.Equal(map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+	}, props["labels"])
+
+	server := props["server"].(map[string]any)
+	serverProps := server["properties"].(map[string]any)
+	a.Equal(map[string]any{"type": "string", "description": "监听地址", "default": ":8080"}, serverProps["addr"])
+	a.Equal(map[string]any{"type": "string", "pattern": durationPattern, "default": "30s"}, serverProps["timeout"])
+
+	backupItems := props["backups"].(map[string]any)["items"].(map[string]any)
+	a.Equal("object", backupItems["type"])
+	a.NotContains(backupItems["properties"].(map[string]any)["addr"], "default")
+}
+
+func TestDurationPattern(t *testing.T) {
+	schema := schemaForType(reflect.Value{}, reflect.TypeFor[time.Duration]())
+	pattern := schema["pattern"].(string)
+
+	for _, s := range []string{"30s", "1h30m", "1.5h", "-5m", "0", "100ms"} {
+		assert.Regexp(t, pattern, s)
+	}
+	for _, s := range []string{"", "30", "5 minutes", "1d"} {
+		assert.NotRegexp(t, pattern, s)
+	}
+}
+
+// =============================================================================
+// SchemaCommand 测试
+// =============================================================================
+
+// runSchemaCommand 以子命令方式运行 SchemaCommand，返回捕获的输出。
+func runSchemaCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	app := &cli.Command{
+		Name:     "app",
+		Writer:   &buf,
+		Commands: []*cli.Command{SchemaCommand(defaultSchemaConfig())},
+	}
+	err := app.Run(context.Background(), append([]string{"app", "schema"}, args...))
+
+	return buf.String(), err
+}
+
+func TestSchemaCommand(t *testing.T) {
+	t.Run("default json", func(t *testing.T) {
+		out, err := runSchemaCommand(t)
+		require.NoError(t, err)
+
+		var schema map[string]any
+		require.NoError(t, json.Unmarshal([]byte(out), &schema))
+		assert.Equal(t, jsonSchemaDraft, schema["$schema"])
+		assert.Contains(t, out, `"description": "应用名称"`)
+		assert.Contains(t, out, `"default": "30s"`)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		out, err := runSchemaCommand(t, "--format", "yaml")
+		require.NoError(t, err)
+
+		var schema map[string]any
+		require.NoError(t, yamlv3.Unmarshal([]byte(out), &schema))
+		assert.Equal(t, "object", schema["type"])
+		assert.Contains(t, out, "description: 应用名称")
+
+		server := schema["properties"].(map[string]any)["server"].(map[string]any)
+		assert.Equal(t, "30s", server["properties"].(map[string]any)["timeout"].(map[string]any)["default"])
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := runSchemaCommand(t, "--format", "toml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported format "toml"`)
+	})
+}
+
+// =============================================================================
+// Schema 校验测试
+// =============================================================================
+
+func TestLoadWithSchemaValidation(t *testing.T) {
+	type Backend struct {
+		Host string `koanf:"host"`
+		Port int    `koanf:"port"`
+	}
+	type Config struct {
+		Server struct {
+			Port    int           `koanf:"port"`
+			Timeout time.Duration `koanf:"timeout"`
+		} `koanf:"server"`
+		Workers  uint              `koanf:"workers"`
+		Debug    bool              `koanf:"debug"`
+		Tags     []string          `koanf:"tags"`
+		Limits   map[string]int    `koanf:"limits"`
+		Backends []Backend         `koanf:"backends"`
+		Labels   map[string]string `koanf:"labels"`
+	}
+	var defaultCfg Config
+	defaultCfg.Server.Port = 8080
+	defaultCfg.Server.Timeout = 30 * time.Second
+
+	load := func(t *testing.T, content string) (*Config, error) {
+		t.Helper()
+
+		return Load(defaultCfg, WithConfigPaths(writeTempConfig(t, content)), WithNoEnv(), WithSchemaValidation())
+	}
+
+	t.Run("valid config", func(t *testing.T) {
+		cfg, err := load(t, `
+server:
+  port: 9090
+  timeout: 1m
+workers: 4
+debug: true
+tags: [a, b]
+limits:
+  cpu: 2
+backends:
+  - host: db1
+    port: 5432
+`)
+		require.NoError(t, err)
+		assert.Equal(t, 9090, cfg.Server.Port)
+		assert.Equal(t, time.Minute, cfg.Server.Timeout)
+	})
+
+	t.Run("type mismatch reports schema path", func(t *testing.T) {
+		_, err := load(t, "server:\n  port: abc\n")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "schema validation failed")
+		assert.Contains(t, err.Error(), "server.port: expected integer, got string")
+	})
+
+	t.Run("reports all mismatches", func(t *testing.T) {
+		_, err := load(t, `
+server:
+  timeout: soon
+workers: -1
+debug: maybe
+tags: {a: b}
+limits:
+  cpu: high
+backends:
+  - host: db1
+    port: [1]
+`)
+		require.Error(t, err)
+		msg := err.Error()
+		assert.Contains(t, msg, "backends[0].port: expected integer, got array")
+		assert.Contains(t, msg, "debug: expected boolean, got string")
+		assert.Contains(t, msg, "limits.cpu: expected integer, got string")
+		assert.Contains(t, msg, "server.timeout: expected duration, got invalid duration string")
+		assert.Contains(t, msg, "tags: expected array, got object")
+		assert.Contains(t, msg, "workers: must be >= 0, got negative integer")
+	})
+
+	t.Run("convertible env strings pass", func(t *testing.T) {
+		t.Setenv("APP_SERVER_PORT", "7070")
+		t.Setenv("APP_DEBUG", "true")
+
+		cfg, err := Load(defaultCfg, WithEnvPrefix("APP_"), WithSchemaValidation())
+		require.NoError(t, err)
+		assert.Equal(t, 7070, cfg.Server.Port)
+		assert.True(t, cfg.Debug)
+	})
+
+	t.Run("bool words accepted like decoder", func(t *testing.T) {
+		for value, want := range map[string]bool{"yes": true, "off": false} {
+			t.Setenv("APP_DEBUG", value)
+
+			cfg, err := Load(defaultCfg, WithEnvPrefix("APP_"), WithSchemaValidation())
+			require.NoError(t, err, value)
+			assert.Equal(t, want, cfg.Debug, value)
+		}
+	})
+
+	t.Run("without option falls back to unmarshal error", func(t *testing.T) {
+		_, err := Load(defaultCfg, WithConfigPaths(writeTempConfig(t, "server:\n  port: abc\n")), WithNoEnv())
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "schema validation failed")
+	})
+}
@@ -0,0 +1,89 @@
+package cfgm
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// defaultDockerSecretsDir Docker secrets 的默认挂载目录，见 [WithDockerSecretsDir]。
+const defaultDockerSecretsDir = "/run/secrets"
+
+// WithDockerSecrets 从 Docker secrets 目录 (默认 /run/secrets) 按约定读取配置。
+//
+// 对每个叶子 koanf key，检查文件 /run/secrets/<prefix><key>，
+// 其中 key 的 "." 和 "-" 转为 "_" 并转为小写。文件存在时读取其内容（去除首尾空白）作为配置值；
+// 文件不存在时跳过，其他读取错误（如权限不足、路径为目录）由 [Load] 返回。
+//
+// 优先级高于配置文件，低于环境变量和 CLI flags。挂载目录可通过 [WithDockerSecretsDir] 修改。
+//
+// 示例 (前缀 "myapp_")：
+//   - database.password → /run/secrets/myapp_database_password
+//   - client.rev-auth-user → /run/secrets/myapp_client_rev_auth_user
+func WithDockerSecrets(prefix string) Option {
+	return func(o *options) {
+		o.dockerSecrets = true
+		o.dockerSecretsPrefix = prefix
+	}
+}
+
+// WithDockerSecretsDir 设置 [WithDockerSecrets] 读取的 secrets 目录，默认为 /run/secrets。
+//
+// 适用于 secrets 挂载到其他位置（如 Kubernetes 的 secret volume）或测试场景，
+// 仅设置目录，仍需通过 [WithDockerSecrets] 启用。
+//
+// 示例：
+//
+//	cfgm.Load(defaultConfig, cfgm.WithDockerSecrets("myapp_"), cfgm.WithDockerSecretsDir("/etc/secrets"))
+func WithDockerSecretsDir(dir string) Option {
+	return func(o *options) {
+		o.dockerSecretsDir = dir
+	}
+}
+
+// dockerSecretName 返回 koanf key 对应的 Docker secret 文件名。
+func dockerSecretName(prefix, koanfKey string) string {
+	return prefix + strings.ToLower(strings.NewReplacer(".", "_", "-", "_").Replace(koanfKey))
+}
+
+// applyDockerSecrets 将存在的 Docker secret 文件内容写入 koanf。
+//
+// 与环境变量相同，数值、bool 和 time.Duration 字段的值会先校验格式。
+func applyDockerSecrets(o *options, k *koanf.Koanf, fieldTypes map[string]reflect.Type) error {
+	dir := cmp.Or(o.dockerSecretsDir, defaultDockerSecretsDir)
+	for _, key := range slices.Sorted(maps.Keys(fieldTypes)) {
+		typ := fieldTypes[key]
+		path := filepath.Join(dir, dockerSecretName(o.dockerSecretsPrefix, key))
+		content, err := os.ReadFile(path) //nolint:gosec // path is derived from config keys
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // secret 不存在
+		}
+		if err != nil {
+			return fmt.Errorf("read docker secret %s: %w", path, err)
+		}
+
+		val := strings.TrimSpace(string(content))
+		if typeName, err := validateEnvValue(val, typ); err != nil && o.prevalidates(typ) {
+			if errors.Is(err, strconv.ErrRange) {
+				return fmt.Errorf("docker secret %s overflows %s for %s", path, typeName, key)
+			}
+
+			return fmt.Errorf("docker secret %s is not a valid %s for %s", path, typeName, key)
+		}
+
+		_ = k.Set(key, val)
+		o.logger.Debug("Loaded docker secret", "path", path, "key", key)
+	}
+
+	return nil
+}
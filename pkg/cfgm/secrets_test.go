@@ -0,0 +1,96 @@
+package cfgm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Docker secrets 测试
+// =============================================================================
+
+// fakeSecretsDir 创建写入了 secrets 的临时目录，返回指向该目录的 [WithDockerSecretsDir] 选项。
+func fakeSecretsDir(t *testing.T, secrets map[string]string) Option {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range secrets {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	return WithDockerSecretsDir(dir)
+}
+
+func TestLoadWithDockerSecrets(t *testing.T) {
+	type Config struct {
+		Database struct {
+			Password string `koanf:"password"`
+			Port     int    `koanf:"port"`
+		} `koanf:"database"`
+		APIKey string `koanf:"api-key"`
+		Name   string `koanf:"name"`
+	}
+	tmpFile := writeTempConfig(t, "name: from-file\napi-key: file-key\n")
+
+	t.Run("secrets override file", func(t *testing.T) {
+		secretsDir := fakeSecretsDir(t, map[string]string{
+			"myapp_database_password": "s3cret\n",
+			"myapp_database_port":     "5433",
+			"myapp_api_key":           "  secret-key  ",
+			"other_name":              "ignored",
+		})
+
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithDockerSecrets("myapp_"), secretsDir)
+		require.NoError(t, err)
+
+		a := assert.New(t)
+		a.Equal("s3cret", cfg.Database.Password, "content is trimmed")
+		a.Equal(5433, cfg.Database.Port)
+		a.Equal("secret-key", cfg.APIKey)
+		a.Equal("from-file", cfg.Name, "keys without secret file keep file value")
+	})
+
+	t.Run("env overrides secrets", func(t *testing.T) {
+		secretsDir := fakeSecretsDir(t, map[string]string{"myapp_api_key": "secret-key"})
+		t.Setenv("MYAPP_API_KEY", "env-key")
+
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithDockerSecrets("myapp_"), secretsDir, WithEnvPrefix("MYAPP_"))
+		require.NoError(t, err)
+		assert.Equal(t, "env-key", cfg.APIKey)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		secretsDir := fakeSecretsDir(t, map[string]string{"api_key": "secret-key"})
+
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), secretsDir)
+		require.NoError(t, err)
+		assert.Equal(t, "file-key", cfg.APIKey)
+	})
+
+	t.Run("invalid typed value", func(t *testing.T) {
+		secretsDir := fakeSecretsDir(t, map[string]string{"myapp_database_port": "abc"})
+
+		_, err := Load(Config{}, WithDockerSecrets("myapp_"), secretsDir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not a valid int for database.port")
+	})
+
+	t.Run("read error is returned", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "myapp_name"), 0750))
+
+		_, err := Load(Config{}, WithDockerSecrets("myapp_"), WithDockerSecretsDir(dir))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "read docker secret "+filepath.Join(dir, "myapp_name"))
+	})
+
+	t.Run("missing dir is skipped", func(t *testing.T) {
+		cfg, err := Load(Config{}, WithConfigPaths(tmpFile), WithDockerSecrets("myapp_"),
+			WithDockerSecretsDir(filepath.Join(t.TempDir(), "missing")))
+		require.NoError(t, err)
+		assert.Equal(t, "file-key", cfg.APIKey)
+	})
+}
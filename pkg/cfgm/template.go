@@ -0,0 +1,44 @@
+package cfgm
+
+import (
+	"fmt"
+)
+
+// ValidateTemplate 检查配置文件的模板能否在当前环境下展开，且展开结果是有效的 YAML/JSON。
+//
+// 与 [Load] 使用相同的读取、模板展开和 !include 规则，但不解码到配置结构体，
+// 适用于在 CI 中预先检查配置模板。返回第一个错误，模板和解析错误中包含文件路径和行号。
+// 相对路径的解析规则与 [WithConfigPaths] 相同；文件不存在时返回错误。
+//
+// 示例：
+//
+//	// config.yaml: password: '{{required "DB_PASSWORD is required" .DB_PASSWORD}}'
+//	if err := cfgm.ValidateTemplate("config/config.yaml"); err != nil {
+//	    log.Fatal(err)
+//	}
+func ValidateTemplate(path string, opts ...Option) error {
+	o := newOptions(2, opts)
+	o.configPaths = []string{path}
+	path = o.resolvedConfigPaths()[0]
+
+	content, err := readConfigFile(o, path)
+	if err != nil {
+		return err
+	}
+	content, err = expandConfigContent(o, path, content)
+	if err != nil {
+		return err
+	}
+	if o.resolvesTags() && !o.isJSONFile(path) {
+		content, err = resolveIncludes(o, path, content)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := o.parserFor(path).Unmarshal(content); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package cfgm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// ValidateTemplate 测试
+// =============================================================================
+
+func TestValidateTemplate(t *testing.T) {
+	content := `name: app
+database:
+  host: '{{env "VT_DB_HOST" "localhost"}}'
+  password: '{{required "VT_DB_PASSWORD is required" .VT_DB_PASSWORD}}'
+`
+	tmpFile := writeTempConfig(t, content)
+
+	t.Run("valid with env set", func(t *testing.T) {
+		t.Setenv("VT_DB_PASSWORD", "secret")
+		assert.NoError(t, ValidateTemplate(tmpFile))
+	})
+
+	t.Run("missing required var", func(t *testing.T) {
+		err := ValidateTemplate(tmpFile, WithNoEnv())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tmpFile)
+		assert.Contains(t, err.Error(), ":4:", "error should point at the template line")
+		assert.Contains(t, err.Error(), "VT_DB_PASSWORD is required")
+	})
+
+	t.Run("template data satisfies required var", func(t *testing.T) {
+		err := ValidateTemplate(tmpFile, WithNoEnv(), WithTemplateData(map[string]string{"VT_DB_PASSWORD": "x"}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("expansion produces invalid yaml", func(t *testing.T) {
+		t.Setenv("VT_BAD", "[unclosed")
+		badFile := writeTempConfig(t, "list: {{.VT_BAD}}\n")
+
+		err := ValidateTemplate(badFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parse config file "+badFile)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		jsonFile := writeTempJSONConfig(t, `{"name": {{env "VT_NAME" "app"}}}`)
+
+		err := ValidateTemplate(jsonFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parse config file")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		assert.Error(t, ValidateTemplate(tmpFile+".missing"))
+	})
+}
@@ -0,0 +1,210 @@
+package cfgm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateStruct 按 validate 标签校验配置结构体，返回汇总所有失败项的错误。
+//
+// 可用于手动构建的配置，也可在 [Hooks] 的 OnLoaded 中对加载结果进行校验。
+// cfg 可以是结构体或指向结构体的指针。嵌套结构体和结构体切片会递归校验，
+// 错误信息使用 koanf key 路径定位字段（如 server.port、servers[0].host）。
+//
+// 支持的规则（多个规则以逗号分隔）：
+//   - required: 值不能为零值，切片和 map 不能为空；required:"true" 标签与此规则等价
+//   - min=N / max=N: 数值比较大小，字符串、切片和 map 比较长度，time.Duration 使用时长（如 min=1s）
+//   - oneof=a b c: 值必须是列出的值之一
+//
+// 未知规则视为错误，避免拼写错误被静默忽略。
+//
+// 示例：
+//
+//	type Config struct {
+//	    Host string `koanf:"host" validate:"required"`
+//	    Port int    `koanf:"port" validate:"min=1,max=65535"`
+//	    Mode string `koanf:"mode" validate:"oneof=dev prod"`
+//	}
+//
+//	if err := cfgm.ValidateStruct(cfg); err != nil { ... }
+func ValidateStruct[T any](cfg T) error {
+	var errs []error
+	validateRecursive(reflect.ValueOf(cfg), "", &errs)
+
+	return errors.Join(errs...)
+}
+
+// validateRecursive 递归校验结构体字段，错误追加到 errs。
+func validateRecursive(val reflect.Value, prefix string, errs *[]error) {
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := field.Tag.Get("koanf")
+		if key == "" {
+			key = field.Name
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		fieldVal := val.Field(i)
+
+		rules := field.Tag.Get("validate")
+		if isRequiredField(field) && !hasValidateRule(field, "required") {
+			rules = "required," + rules // required:"true" 标签
+		}
+		if rules != "" {
+			for rule := range strings.SplitSeq(rules, ",") {
+				if err := checkRule(fieldVal, strings.TrimSpace(rule)); err != nil {
+					*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+				}
+			}
+		}
+
+		elemType := field.Type
+		if elemType.Kind() == reflect.Pointer {
+			elemType = elemType.Elem()
+		}
+		switch {
+		case isNestedStruct(elemType):
+			validateRecursive(fieldVal, key, errs)
+		case elemType.Kind() == reflect.Slice && isNestedStruct(elemType.Elem()):
+			for j := range fieldVal.Len() {
+				validateRecursive(fieldVal.Index(j), fmt.Sprintf("%s[%d]", key, j), errs)
+			}
+		}
+	}
+}
+
+// isRequiredField 判断字段是否为必填：validate 标签包含 required 规则，或设置了 required:"true"。
+func isRequiredField(field reflect.StructField) bool {
+	if required, err := strconv.ParseBool(field.Tag.Get("required")); err == nil && required {
+		return true
+	}
+
+	return hasValidateRule(field, "required")
+}
+
+// checkRule 校验单个规则。
+func checkRule(val reflect.Value, rule string) error {
+	name, param, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case "":
+		return nil
+	case "required":
+		if isEmptyValue(val) {
+			return errors.New("is required")
+		}
+
+		return nil
+	case "min", "max":
+		return checkBound(val, name, param)
+	case "oneof":
+		allowed := strings.Fields(param)
+		if val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				return nil
+			}
+			val = val.Elem()
+		}
+		if got := fmt.Sprint(val.Interface()); !slices.Contains(allowed, got) {
+			return fmt.Errorf("must be one of [%s], got %q", strings.Join(allowed, " "), got)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown validate rule %q", rule)
+	}
+}
+
+// isEmptyValue 判断值是否为空：零值，或长度为 0 的切片和 map。
+func isEmptyValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Slice, reflect.Map:
+		return val.Len() == 0
+	default:
+		return val.IsZero()
+	}
+}
+
+// checkBound 校验 min/max 规则。nil 指针跳过校验（由 required 负责）。
+func checkBound(val reflect.Value, name, param string) error {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	var got float64
+	var unit string
+	switch {
+	case val.Type() == reflect.TypeFor[time.Duration]():
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return fmt.Errorf("invalid %s duration %q", name, param)
+		}
+		if violatesBound(name, float64(val.Int()), float64(d)) {
+			return fmt.Errorf("must be %s %s, got %s", boundWord(name), d, time.Duration(val.Int()))
+		}
+
+		return nil
+	case val.Kind() == reflect.String || val.Kind() == reflect.Slice || val.Kind() == reflect.Map:
+		got, unit = float64(val.Len()), "length "
+	case val.CanInt():
+		got = float64(val.Int())
+	case val.CanUint():
+		got = float64(val.Uint())
+	case val.CanFloat():
+		got = val.Float()
+	default:
+		return fmt.Errorf("%s is not supported for %s", name, val.Type())
+	}
+
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q", name, param)
+	}
+	if violatesBound(name, got, limit) {
+		return fmt.Errorf("%smust be %s %s, got %v", unit, boundWord(name), param, got)
+	}
+
+	return nil
+}
+
+// violatesBound 判断值是否违反 min/max 限制。
+func violatesBound(name string, got, limit float64) bool {
+	if name == "min" {
+		return got < limit
+	}
+
+	return got > limit
+}
+
+// boundWord 返回 min/max 对应的比较符号。
+func boundWord(name string) string {
+	if name == "min" {
+		return ">="
+	}
+
+	return "<="
+}
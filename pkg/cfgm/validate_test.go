@@ -0,0 +1,111 @@
+package cfgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// 结构体校验测试
+// =============================================================================
+
+type validateTestServer struct {
+	Host string `koanf:"host" validate:"required"`
+	Port int    `koanf:"port" validate:"min=1,max=65535"`
+}
+
+type validateTestConfig struct {
+	Name    string               `koanf:"name" validate:"required,min=3"`
+	Mode    string               `koanf:"mode" validate:"oneof=dev prod"`
+	Tags    []string             `koanf:"tags" validate:"max=2"`
+	Timeout time.Duration        `koanf:"timeout" validate:"min=1s"`
+	Workers *int                 `koanf:"workers" validate:"min=1"`
+	Server  validateTestServer   `koanf:"server"`
+	Backups []validateTestServer `koanf:"backups"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	valid := validateTestConfig{
+		Name:    "app",
+		Mode:    "prod",
+		Tags:    []string{"a"},
+		Timeout: time.Second,
+		Server:  validateTestServer{Host: "localhost", Port: 8080},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, ValidateStruct(valid))
+		require.NoError(t, ValidateStruct(&valid), "pointer accepted")
+	})
+
+	t.Run("required and min failures", func(t *testing.T) {
+		zero := 0
+		cfg := valid
+		cfg.Name = ""
+		cfg.Workers = &zero
+		cfg.Server = validateTestServer{Port: 0}
+
+		err := ValidateStruct(cfg)
+		require.Error(t, err)
+
+		msg := err.Error()
+		a := assert.New(t)
+		a.Contains(msg, "name: is required")
+		a.Contains(msg, "name: length must be >= 3, got 0")
+		a.Contains(msg, "workers: must be >= 1, got 0")
+		a.Contains(msg, "server.host: is required")
+		a.Contains(msg, "server.port: must be >= 1, got 0")
+		a.NotContains(msg, "mode")
+	})
+
+	t.Run("other rules", func(t *testing.T) {
+		cfg := valid
+		cfg.Mode = "test"
+		cfg.Tags = []string{"a", "b", "c"}
+		cfg.Timeout = 500 * time.Millisecond
+		cfg.Backups = []validateTestServer{{Host: "b1", Port: 70000}}
+
+		err := ValidateStruct(cfg)
+		require.Error(t, err)
+
+		msg := err.Error()
+		a := assert.New(t)
+		a.Contains(msg, `mode: must be one of [dev prod], got "test"`)
+		a.Contains(msg, "tags: length must be <= 2, got 3")
+		a.Contains(msg, "timeout: must be >= 1s, got 500ms")
+		a.Contains(msg, "backups[0].port: must be <= 65535, got 70000")
+	})
+
+	t.Run("required tag", func(t *testing.T) {
+		type Config struct {
+			Token string `koanf:"token" required:"true"`
+			Port  int    `koanf:"port" required:"true" validate:"max=10"`
+		}
+		err := ValidateStruct(Config{Port: 20})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "token: is required")
+		assert.Contains(t, err.Error(), "port: must be <= 10, got 20")
+		assert.NoError(t, ValidateStruct(Config{Token: "t", Port: 1}))
+	})
+
+	t.Run("unknown rule", func(t *testing.T) {
+		type Config struct {
+			Email string `koanf:"email" validate:"email"`
+		}
+		err := ValidateStruct(Config{Email: "a@b.c"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `email: unknown validate rule "email"`)
+	})
+
+	t.Run("with OnLoaded hook", func(t *testing.T) {
+		tmpFile := writeTempConfig(t, "name: ab\n")
+		_, err := Load(valid, WithConfigPaths(tmpFile), WithHooks(Hooks{
+			OnLoaded: func(cfg any) error { return ValidateStruct(cfg) },
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "name: length must be >= 3, got 2")
+	})
+}
@@ -0,0 +1,106 @@
+package cfgm
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// 警告代码，见 [Warning]。
+const (
+	// WarningUnknownKey 配置源中的 key 不对应配置结构体的任何字段，已被忽略。
+	WarningUnknownKey = "unknown_key"
+	// WarningShadowedEnv 已设置的环境变量因同一配置路径存在更高优先级的绑定而被忽略，
+	// 如 APP_DEBUG（前缀绑定）被绑定到 debug 的代码绑定遮蔽。
+	WarningShadowedEnv = "shadowed_env"
+	// WarningDeprecated 配置使用了已废弃的 key 或字段，见 deprecated 和 alias 标签。
+	WarningDeprecated = "deprecated"
+	// WarningLenientFile [WithLenientFile] 注册的文件读取或解析失败，已被跳过。
+	WarningLenientFile = "lenient_file"
+)
+
+// Warning 加载过程中不影响加载结果的问题，由 [LoadWithResult] 返回。
+type Warning struct {
+	Code    string // 警告代码，如 [WarningUnknownKey]、[WarningDeprecated]
+	Message string // 可读的描述
+	Key     string // 相关的 koanf key，与具体配置项无关时为空
+}
+
+// String 返回 "key: message" 形式的描述，Key 为空时仅返回 Message。
+func (w Warning) String() string {
+	if w.Key == "" {
+		return w.Message
+	}
+
+	return w.Key + ": " + w.Message
+}
+
+// LoadResult [LoadWithResult] 的加载结果。
+type LoadResult[T any] struct {
+	Config   *T        // 加载后的配置
+	Warnings []Warning // 加载过程中产生的警告，按产生顺序排列
+}
+
+// LoadWithResult 与 [Load] 相同，但额外返回加载过程中的警告，便于应用记录非致命问题。
+//
+// 目前产生的警告：
+//   - [WarningUnknownKey]: 合并后的配置中存在配置结构体没有的 key（如拼写错误），该 key 被忽略
+//   - [WarningShadowedEnv]: 已设置的环境变量被同一配置路径上更高优先级的绑定遮蔽（见 [WithEnvBindings]）
+//   - [WarningDeprecated]: 使用了 alias 标签声明的旧 key（值已迁移到新字段），或设置了带 deprecated 标签的字段
+//   - [WarningLenientFile]: [WithLenientFile] 注册的文件读取或解析失败，已被跳过
+//
+// 使用 [Load] 时警告仅以 Debug 级别记录到 logger。
+//
+// 示例：
+//
+//	res, err := cfgm.LoadWithResult(DefaultConfig())
+//	for _, w := range res.Warnings {
+//	    slog.Warn("config warning", "code", w.Code, "key", w.Key, "msg", w.Message)
+//	}
+func LoadWithResult[T any](defaultConfig T, opts ...Option) (*LoadResult[T], error) {
+	cfg, warnings, err := loadWithWarnings(defaultConfig, 1, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoadResult[T]{Config: cfg, Warnings: warnings}, nil
+}
+
+// warn 记录一条警告。
+func (o *options) warn(code, key, message string) {
+	o.warnings = append(o.warnings, Warning{Code: code, Message: message, Key: key})
+	o.logger.Debug("Config warning", "code", code, "key", key, "message", message)
+}
+
+// warnUnknownKeys 为 k 中不对应 defaultConfig 字段的 key 记录 [WarningUnknownKey]。
+//
+// key 本身或其任一父路径是结构体叶子字段（如 map、切片字段的子 key）时视为已知。
+// defaultConfig 不是结构体时不检查。
+func warnUnknownKeys[T any](o *options, k *koanf.Koanf, defaultConfig T) {
+	typ := reflect.TypeOf(defaultConfig)
+	if typ == nil || (typ.Kind() != reflect.Struct && (typ.Kind() != reflect.Pointer || typ.Elem().Kind() != reflect.Struct)) {
+		return
+	}
+
+	fieldTypes := collectKoanfFieldTypes(defaultConfig)
+	for _, key := range k.Keys() {
+		if !isKnownKey(key, fieldTypes) {
+			o.warn(WarningUnknownKey, key, "unknown key ignored")
+		}
+	}
+}
+
+// isKnownKey 判断 key 或其父路径是否为结构体叶子字段。
+func isKnownKey(key string, fieldTypes map[string]reflect.Type) bool {
+	for {
+		if _, ok := fieldTypes[key]; ok {
+			return true
+		}
+		idx := strings.LastIndex(key, ".")
+		if idx < 0 {
+			return false
+		}
+		key = key[:idx]
+	}
+}
@@ -0,0 +1,225 @@
+package cfgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// LoadWithResult 测试
+// =============================================================================
+
+func TestLoadWithResult(t *testing.T) {
+	type Config struct {
+		Workers int `koanf:"workers"`
+		Server  struct {
+			Timeout time.Duration `koanf:"timeout"`
+		} `koanf:"server"`
+		Ratio  float64           `koanf:"ratio"`
+		Labels map[string]string `koanf:"labels"`
+	}
+	defaultCfg := Config{Workers: 4, Ratio: 0.5}
+	defaultCfg.Server.Timeout = 30 * time.Second
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := writeTempConfig(t, `
+workers: 128
+server:
+  timeout: 100ms
+  tiemout: 5s
+labels:
+  team: infra
+`)
+		res, err := LoadWithResult(defaultCfg, WithConfigPaths(path))
+		require.NoError(t, err)
+
+		a := assert.New(t)
+		a.Equal(128, res.Config.Workers)
+		a.Equal(100*time.Millisecond, res.Config.Server.Timeout)
+		a.Equal(map[string]string{"team": "infra"}, res.Config.Labels, "map entries are not unknown keys")
+		a.Equal([]Warning{
+			{Code: WarningUnknownKey, Key: "server.tiemout", Message: "unknown key ignored"},
+		}, res.Warnings)
+		a.Equal("server.tiemout: unknown key ignored", res.Warnings[0].String())
+	})
+
+	t.Run("unknown key from env binding", func(t *testing.T) {
+		t.Setenv("APP_EXTRA", "1")
+
+		res, err := LoadWithResult(defaultCfg, WithEnvBinding("APP_EXTRA", "extra"))
+		require.NoError(t, err)
+		assert.Equal(t, []Warning{{Code: WarningUnknownKey, Key: "extra", Message: "unknown key ignored"}}, res.Warnings)
+	})
+
+	t.Run("no warnings", func(t *testing.T) {
+		res, err := LoadWithResult(defaultCfg)
+		require.NoError(t, err)
+		assert.Empty(t, res.Warnings)
+		assert.Equal(t, 4, res.Config.Workers)
+	})
+}
+
+func TestLoadWithResult_ShadowedEnv(t *testing.T) {
+	type Config struct {
+		Debug bool   `koanf:"debug"`
+		Name  string `koanf:"name"`
+	}
+
+	t.Run("prefix binding shadowed by code binding", func(t *testing.T) {
+		t.Setenv("APP_DEBUG", "false")
+		t.Setenv("MY_DEBUG", "true")
+		t.Setenv("APP_NAME", "from-prefix")
+
+		res, err := LoadWithResult(Config{},
+			WithEnvPrefix("APP_"),
+			WithEnvBinding("MY_DEBUG", "debug"),
+		)
+		require.NoError(t, err)
+		assert.True(t, res.Config.Debug)
+		assert.Equal(t, "from-prefix", res.Config.Name)
+
+		require.Len(t, res.Warnings, 1)
+		w := res.Warnings[0]
+		assert.Equal(t, WarningShadowedEnv, w.Code)
+		assert.Equal(t, "debug", w.Key)
+		assert.Contains(t, w.Message, "APP_DEBUG")
+		assert.Contains(t, w.Message, "MY_DEBUG")
+	})
+
+	t.Run("unset shadowed env is not reported", func(t *testing.T) {
+		t.Setenv("MY_DEBUG", "true")
+
+		res, err := LoadWithResult(Config{},
+			WithEnvPrefix("APP_"),
+			WithEnvBinding("MY_DEBUG", "debug"),
+		)
+		require.NoError(t, err)
+		assert.Empty(t, res.Warnings)
+	})
+
+	t.Run("no env", func(t *testing.T) {
+		t.Setenv("APP_DEBUG", "false")
+
+		res, err := LoadWithResult(Config{},
+			WithEnvPrefix("APP_"),
+			WithEnvBinding("MY_DEBUG", "debug"),
+			WithNoEnv(),
+		)
+		require.NoError(t, err)
+		assert.Empty(t, res.Warnings)
+	})
+}
+
+func TestLoadWithResult_Deprecated(t *testing.T) {
+	type Config struct {
+		Server struct {
+			DocsDir string `koanf:"docs_dir" alias:"docs" deprecated:"use docs_dir instead"`
+			Legacy  bool   `koanf:"legacy" deprecated:"will be removed in v2"`
+		} `koanf:"server"`
+	}
+	defaultCfg := Config{}
+	defaultCfg.Server.DocsDir = "./docs"
+
+	t.Run("deprecated key loads into new field", func(t *testing.T) {
+		path := writeTempConfig(t, "server:\n  docs: /srv/docs\n")
+
+		res, err := LoadWithResult(defaultCfg, WithConfigPaths(path))
+		require.NoError(t, err)
+		assert.Equal(t, "/srv/docs", res.Config.Server.DocsDir)
+		assert.Equal(t, []Warning{{
+			Code:    WarningDeprecated,
+			Message: "deprecated: use docs_dir instead",
+			Key:     "server.docs",
+		}}, res.Warnings)
+	})
+
+	t.Run("new key takes precedence", func(t *testing.T) {
+		path := writeTempConfig(t, "server:\n  docs: /srv/old\n  docs_dir: /srv/new\n")
+
+		res, err := LoadWithResult(defaultCfg, WithConfigPaths(path))
+		require.NoError(t, err)
+		assert.Equal(t, "/srv/new", res.Config.Server.DocsDir)
+		require.Len(t, res.Warnings, 1)
+		assert.Equal(t, WarningDeprecated, res.Warnings[0].Code)
+		assert.Contains(t, res.Warnings[0].Message, "ignored, server.docs_dir is also set")
+	})
+
+	t.Run("deprecated field set", func(t *testing.T) {
+		path := writeTempConfig(t, "server:\n  legacy: true\n")
+
+		res, err := LoadWithResult(defaultCfg, WithConfigPaths(path))
+		require.NoError(t, err)
+		assert.True(t, res.Config.Server.Legacy)
+		assert.Equal(t, []Warning{{
+			Code:    WarningDeprecated,
+			Message: "deprecated: will be removed in v2",
+			Key:     "server.legacy",
+		}}, res.Warnings)
+	})
+
+	t.Run("no warning without deprecated keys", func(t *testing.T) {
+		path := writeTempConfig(t, "server:\n  docs_dir: /srv/new\n")
+
+		res, err := LoadWithResult(defaultCfg, WithConfigPaths(path))
+		require.NoError(t, err)
+		assert.Empty(t, res.Warnings)
+	})
+}
+
+func TestLoadWithResult_LenientFile(t *testing.T) {
+	type Config struct {
+		Name string `koanf:"name"`
+		Port int    `koanf:"port"`
+	}
+	defaultCfg := Config{Name: "default", Port: 8080}
+
+	t.Run("malformed lenient file is skipped", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml":       "name: base\nport: 9090\n",
+			"config.local.yaml": "name: [unclosed\n",
+		})
+
+		res, err := LoadWithResult(defaultCfg,
+			WithBaseDir(dir),
+			WithConfigPaths("config.yaml"),
+			WithLenientFile("config.local.yaml"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "base", Port: 9090}, *res.Config)
+		require.Len(t, res.Warnings, 1)
+		assert.Equal(t, WarningLenientFile, res.Warnings[0].Code)
+		assert.Contains(t, res.Warnings[0].Message, "config.local.yaml")
+	})
+
+	t.Run("valid lenient file overrides base", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml":       "name: base\nport: 9090\n",
+			"config.local.yaml": "port: 9191\n",
+		})
+
+		res, err := LoadWithResult(defaultCfg,
+			WithBaseDir(dir),
+			WithConfigPaths("config.yaml"),
+			WithLenientFile("config.local.yaml"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "base", Port: 9191}, *res.Config)
+		assert.Empty(t, res.Warnings)
+	})
+
+	t.Run("missing lenient file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"config.yaml": "name: base\n"})
+
+		res, err := LoadWithResult(defaultCfg,
+			WithBaseDir(dir),
+			WithConfigPaths("config.yaml"),
+			WithLenientFile("config.local.yaml"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "base", res.Config.Name)
+		assert.Empty(t, res.Warnings)
+	})
+}
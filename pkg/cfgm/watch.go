@@ -0,0 +1,325 @@
+package cfgm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/knadh/koanf/providers/file"
+)
+
+// watchDebounce 文件变化事件的合并窗口。
+const watchDebounce = 100 * time.Millisecond
+
+// ErrImmutableChanged 重新加载改变了标记为 immutable:"true" 的字段，见 [Watch] 和 [OnSignalReload]。
+var ErrImmutableChanged = errors.New("immutable config field changed")
+
+// Watch 加载配置并监听配置文件变化，文件变化时重新加载并回调。
+//
+// 首次加载的结果直接返回；之后每次文件变化都会按相同选项重新执行 [Load]，
+// 并通过 [DiffConfig] 计算与上一次配置的差异：
+//   - 有变化时回调 onChange(newCfg, diffs, nil)，diffs 仅包含变化的配置项
+//   - 无变化时不回调（例如仅重写了相同内容）
+//   - 重新加载失败时回调 onChange(nil, nil, err)，保留上一次的配置
+//   - 标记为 immutable:"true" 的字段（如监听地址等需要重启才能生效的配置）发生变化时，
+//     拒绝整次重新加载，回调包装了 [ErrImmutableChanged] 的错误并保留上一次的配置
+//
+// 监听在 ctx 结束时停止。回调在内部 goroutine 中串行执行。
+// 未找到配置文件时返回 error（没有可监听的文件）。
+//
+// 配置文件为符号链接时（如 Kubernetes ConfigMap 挂载的 config.yaml -> ..data/config.yaml），
+// 监听链接所在目录并在每次事件时重新解析链接，能够识别 ..data 链接被原子替换的更新方式。
+//
+// 示例：
+//
+//	cfg, err := cfgm.Watch(ctx, DefaultConfig(), func(cfg *Config, diffs []cfgm.FieldDiff, err error) {
+//	    if err != nil {
+//	        log.Printf("reload config: %v", err)
+//	        return
+//	    }
+//	    for _, d := range diffs {
+//	        log.Printf("config changed: %s", d.Key)
+//	    }
+//	}, cfgm.WithAppName("myapp"))
+func Watch[T any](
+	ctx context.Context,
+	defaultConfig T,
+	onChange func(cfg *T, diffs []FieldDiff, err error),
+	opts ...Option,
+) (*T, error) {
+	options := newOptions(2, opts)
+
+	// 固定路径基准目录，保证重新加载时解析到同一个文件
+	reloadOpts := append(append([]Option{}, opts...), WithBaseDir(options.baseDir))
+
+	path, _, err := findConfigFile(options)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return nil, errors.New("watch config: no config file found")
+	}
+
+	current, err := load(defaultConfig, 1, reloadOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	immutable := immutableKeys(defaultConfig)
+
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+	reload := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		next, loadErr := load(defaultConfig, 1, reloadOpts...)
+		if loadErr != nil {
+			onChange(nil, nil, loadErr)
+
+			return
+		}
+
+		diffs := DiffConfig(*current, *next)
+		if len(diffs) == 0 {
+			return
+		}
+		if changed := immutableChanges(diffs, immutable); len(changed) > 0 {
+			onChange(nil, nil, fmt.Errorf("%w: %s (restart required)", ErrImmutableChanged, strings.Join(changed, ", ")))
+
+			return
+		}
+
+		current = next
+		onChange(next, diffs, nil)
+	}
+
+	stop, err := watchFile(path, func(watchErr error) {
+		if watchErr != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			onChange(nil, nil, fmt.Errorf("watch config %s: %w", path, watchErr))
+
+			return
+		}
+
+		// 编辑器和 os.WriteFile 会先截断再写入，合并短时间内的多次事件，避免读到不完整的文件
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(watchDebounce, reload)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("watch config %s: %w", path, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	options.logger.Debug("Watching config file", "path", path)
+
+	return current, nil
+}
+
+// immutableKeys 返回标记为 immutable:"true" 的叶子 koanf key。
+func immutableKeys[T any](defaultConfig T) map[string]bool {
+	keys := make(map[string]bool)
+	for _, leaf := range koanfTypeInfoFor(reflect.TypeOf(defaultConfig)).leaves {
+		if leaf.field.Tag.Get("immutable") == "true" {
+			keys[leaf.key] = true
+		}
+	}
+
+	return keys
+}
+
+// immutableChanges 返回 diffs 中属于 immutable 字段的 key。
+func immutableChanges(diffs []FieldDiff, immutable map[string]bool) []string {
+	var changed []string
+	for _, d := range diffs {
+		if immutable[d.Key] {
+			changed = append(changed, d.Key)
+		}
+	}
+
+	return changed
+}
+
+// OnSignalReload 在收到指定信号时按相同选项重新执行 [Load] 并回调，返回停止监听的函数。
+//
+// 适用于传统 Unix 守护进程的 SIGHUP 重载约定，可与基于文件监听的 [Watch] 配合使用。
+// 每次收到信号都会回调 onChange(cfg, err)，不比较配置差异；重新加载失败时 cfg 为 nil。
+// 与 [Watch] 相同，标记为 immutable:"true" 的字段相对上一次的配置（注册时加载的配置或
+// 最近一次成功重新加载的配置）发生变化时，拒绝整次重新加载，回调包装了 [ErrImmutableChanged] 的错误。
+// 回调在内部 goroutine 中串行执行。调用 stop 后不再处理信号，多次调用 stop 是安全的。
+//
+// 示例：
+//
+//	stop := cfgm.OnSignalReload(syscall.SIGHUP, DefaultConfig(), func(cfg *Config, err error) {
+//	    if err != nil {
+//	        log.Printf("reload config: %v", err)
+//	        return
+//	    }
+//	    app.Apply(cfg)
+//	}, cfgm.WithAppName("myapp"))
+//	defer stop()
+func OnSignalReload[T any](sig os.Signal, defaultConfig T, onChange func(*T, error), opts ...Option) (stop func()) {
+	options := newOptions(2, opts)
+
+	// 固定路径基准目录，保证重新加载时解析到同一个文件
+	reloadOpts := append(append([]Option{}, opts...), WithBaseDir(options.baseDir))
+
+	// 注册时的配置作为比较 immutable 字段的基准，加载失败时以第一次成功重新加载的配置为基准
+	current, _ := load(defaultConfig, 1, reloadOpts...)
+	immutable := immutableKeys(defaultConfig)
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, sig)
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				options.logger.Debug("Reloading config on signal", "signal", sig.String())
+				next, err := load(defaultConfig, 1, reloadOpts...)
+				if err != nil {
+					onChange(nil, err)
+
+					continue
+				}
+				if current != nil {
+					if changed := immutableChanges(DiffConfig(*current, *next), immutable); len(changed) > 0 {
+						onChange(nil, fmt.Errorf("%w: %s (restart required)", ErrImmutableChanged, strings.Join(changed, ", ")))
+
+						continue
+					}
+				}
+				current = next
+				onChange(next, nil)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// watchFile 监听配置文件，文件变化时调用 onEvent(nil)，监听出错时调用 onEvent(err)。
+//
+// 返回停止监听的函数。符号链接使用 [watchSymlink]，普通文件使用 koanf file provider。
+func watchFile(path string, onEvent func(err error)) (func() error, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return watchSymlink(path, onEvent)
+	}
+
+	provider := file.Provider(path)
+	if err := provider.Watch(func(_ any, err error) { onEvent(err) }); err != nil {
+		return nil, err
+	}
+
+	return provider.Unwatch, nil
+}
+
+// watchSymlink 监听符号链接形式的配置文件。
+//
+// Kubernetes 更新 ConfigMap 时，先写入新的时间戳目录，再将 ..data 链接原子替换到新目录，
+// 最后删除旧目录；配置文件本身（config.yaml -> ..data/config.yaml）从未被写入。
+// 因此监听链接所在目录和当前目标所在目录，每次事件都重新解析链接：
+// 目标变化或目标文件被写入时触发回调。替换过程中链接暂时无法解析时等待后续事件，不中止监听。
+func watchSymlink(path string, onEvent func(err error)) (func() error, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	linkDir := filepath.Dir(path)
+	if err := w.Add(linkDir); err != nil {
+		_ = w.Close()
+
+		return nil, err
+	}
+	// 目标与链接不在同一目录时，额外监听目标目录以捕获直接写入目标文件的变化
+	watchTargetDir := func(target string) {
+		if dir := filepath.Dir(target); dir != linkDir {
+			_ = w.Add(dir)
+		}
+	}
+	watchTargetDir(resolved)
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+
+				current, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					continue
+				}
+
+				if current != resolved {
+					// 旧目标目录通常已被删除，移除失败可忽略
+					if dir := filepath.Dir(resolved); dir != linkDir {
+						_ = w.Remove(dir)
+					}
+					watchTargetDir(current)
+					resolved = current
+					onEvent(nil)
+
+					continue
+				}
+
+				if filepath.Clean(event.Name) == resolved && event.Has(fsnotify.Create|fsnotify.Write) {
+					onEvent(nil)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				onEvent(err)
+			}
+		}
+	}()
+
+	return w.Close, nil
+}
@@ -0,0 +1,278 @@
+package cfgm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Watch 测试
+// =============================================================================
+
+type watchTestConfig struct {
+	Name   string `koanf:"name"`
+	Server struct {
+		Port int `koanf:"port"`
+	} `koanf:"server"`
+}
+
+// watchEvent 记录一次 Watch 回调。
+type watchEvent struct {
+	cfg   *watchTestConfig
+	diffs []FieldDiff
+	err   error
+}
+
+// startWatch 启动 Watch，返回初始配置和回调事件通道。
+func startWatch(t *testing.T, configPath string) (*watchTestConfig, <-chan watchEvent) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	events := make(chan watchEvent, 10)
+	cfg, err := Watch(ctx, watchTestConfig{Name: "default"}, func(cfg *watchTestConfig, diffs []FieldDiff, err error) {
+		events <- watchEvent{cfg: cfg, diffs: diffs, err: err}
+	}, WithConfigPaths(configPath))
+	require.NoError(t, err)
+
+	return cfg, events
+}
+
+// waitWatchEvent 等待下一次回调，超时则测试失败。
+func waitWatchEvent(t *testing.T, events <-chan watchEvent) watchEvent {
+	t.Helper()
+
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watch callback")
+
+		return watchEvent{}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	const initial = "name: app\nserver:\n  port: 8080\n"
+	configPath := writeTempConfig(t, initial)
+
+	cfg, events := startWatch(t, configPath)
+	assert.Equal(t, 8080, cfg.Server.Port)
+
+	// 重写相同内容不应触发回调
+	require.NoError(t, os.WriteFile(configPath, []byte(initial), 0600))
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected callback for unchanged config: %+v", ev)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(configPath, []byte("name: app\nserver:\n  port: 9090\n"), 0600))
+
+	ev := waitWatchEvent(t, events)
+	require.NoError(t, ev.err)
+	assert.Equal(t, 9090, ev.cfg.Server.Port)
+	assert.Equal(t, []FieldDiff{{Key: "server.port", Old: 8080, New: 9090}}, ev.diffs)
+}
+
+func TestWatch_ImmutableField(t *testing.T) {
+	type Config struct {
+		Addr    string `koanf:"addr" immutable:"true"`
+		Workers int    `koanf:"workers"`
+	}
+	configPath := writeTempConfig(t, "addr: \":8080\"\nworkers: 4\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	type event struct {
+		cfg *Config
+		err error
+	}
+	events := make(chan event, 10)
+	cfg, err := Watch(ctx, Config{}, func(cfg *Config, _ []FieldDiff, err error) {
+		events <- event{cfg: cfg, err: err}
+	}, WithConfigPaths(configPath))
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", cfg.Addr)
+
+	wait := func() event {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for watch callback")
+
+			return event{}
+		}
+	}
+
+	// 修改 immutable 字段：拒绝整次重新加载
+	require.NoError(t, os.WriteFile(configPath, []byte("addr: \":9090\"\nworkers: 8\n"), 0600))
+	ev := wait()
+	require.ErrorIs(t, ev.err, ErrImmutableChanged)
+	assert.ErrorContains(t, ev.err, "addr")
+	assert.Nil(t, ev.cfg)
+
+	// 恢复 immutable 字段后，仅修改可变字段：与保留的旧配置比较，重新加载成功
+	require.NoError(t, os.WriteFile(configPath, []byte("addr: \":8080\"\nworkers: 8\n"), 0600))
+	ev = wait()
+	require.NoError(t, ev.err)
+	assert.Equal(t, Config{Addr: ":8080", Workers: 8}, *ev.cfg)
+}
+
+func TestWatch_NoConfigFile(t *testing.T) {
+	_, err := Watch(context.Background(), watchTestConfig{}, func(*watchTestConfig, []FieldDiff, error) {},
+		WithConfigPaths("nonexistent.yaml"))
+	assert.ErrorContains(t, err, "no config file found")
+}
+
+func TestWatch_SymlinkSwap(t *testing.T) {
+	// 模拟 Kubernetes ConfigMap 挂载：config.yaml -> ..data/config.yaml，..data -> ..2024_01
+	mountDir := t.TempDir()
+	writeVersion := func(name, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(mountDir, name), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(mountDir, name, "config.yaml"), []byte(content), 0600))
+	}
+	writeVersion("..2024_01", "name: app\nserver:\n  port: 8080\n")
+	require.NoError(t, os.Symlink("..2024_01", filepath.Join(mountDir, "..data")))
+	configPath := filepath.Join(mountDir, "config.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "config.yaml"), configPath))
+
+	cfg, events := startWatch(t, configPath)
+	assert.Equal(t, 8080, cfg.Server.Port)
+
+	// 原子替换 ..data 链接，然后删除旧版本目录
+	writeVersion("..2024_02", "name: app\nserver:\n  port: 9090\n")
+	require.NoError(t, os.Symlink("..2024_02", filepath.Join(mountDir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(mountDir, "..data_tmp"), filepath.Join(mountDir, "..data")))
+	require.NoError(t, os.RemoveAll(filepath.Join(mountDir, "..2024_01")))
+
+	ev := waitWatchEvent(t, events)
+	require.NoError(t, ev.err)
+	assert.Equal(t, 9090, ev.cfg.Server.Port)
+
+	// 再次替换，确认监听在目标切换后仍然有效
+	writeVersion("..2024_03", "name: app\nserver:\n  port: 7070\n")
+	require.NoError(t, os.Symlink("..2024_03", filepath.Join(mountDir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(mountDir, "..data_tmp"), filepath.Join(mountDir, "..data")))
+	require.NoError(t, os.RemoveAll(filepath.Join(mountDir, "..2024_02")))
+
+	ev = waitWatchEvent(t, events)
+	require.NoError(t, ev.err)
+	assert.Equal(t, 7070, ev.cfg.Server.Port)
+}
+
+// =============================================================================
+// OnSignalReload 测试
+// =============================================================================
+
+func TestOnSignalReload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals to the current process is not supported on windows")
+	}
+
+	configPath := writeTempConfig(t, "name: app\nserver:\n  port: 8080\n")
+
+	type reloadEvent struct {
+		cfg *watchTestConfig
+		err error
+	}
+	events := make(chan reloadEvent, 10)
+	stop := OnSignalReload(syscall.SIGHUP, watchTestConfig{Name: "default"}, func(cfg *watchTestConfig, err error) {
+		events <- reloadEvent{cfg: cfg, err: err}
+	}, WithConfigPaths(configPath))
+	t.Cleanup(stop)
+
+	self, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	waitReload := func() reloadEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for reload callback")
+
+			return reloadEvent{}
+		}
+	}
+
+	require.NoError(t, os.WriteFile(configPath, []byte("name: app\nserver:\n  port: 9090\n"), 0600))
+	require.NoError(t, self.Signal(syscall.SIGHUP))
+
+	ev := waitReload()
+	require.NoError(t, ev.err)
+	assert.Equal(t, 9090, ev.cfg.Server.Port)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("server: [invalid\n"), 0600))
+	require.NoError(t, self.Signal(syscall.SIGHUP))
+
+	ev = waitReload()
+	require.Error(t, ev.err)
+	assert.Nil(t, ev.cfg)
+
+	stop()
+	stop()
+}
+
+func TestOnSignalReload_ImmutableField(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals to the current process is not supported on windows")
+	}
+
+	type Config struct {
+		Addr    string `koanf:"addr" immutable:"true"`
+		Workers int    `koanf:"workers"`
+	}
+	configPath := writeTempConfig(t, "addr: \":8080\"\nworkers: 4\n")
+
+	type reloadEvent struct {
+		cfg *Config
+		err error
+	}
+	events := make(chan reloadEvent, 10)
+	stop := OnSignalReload(syscall.SIGHUP, Config{}, func(cfg *Config, err error) {
+		events <- reloadEvent{cfg: cfg, err: err}
+	}, WithConfigPaths(configPath))
+	t.Cleanup(stop)
+
+	self, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	reload := func(content string) reloadEvent {
+		t.Helper()
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0600))
+		require.NoError(t, self.Signal(syscall.SIGHUP))
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for reload callback")
+
+			return reloadEvent{}
+		}
+	}
+
+	// 修改 immutable 字段：拒绝整次重新加载
+	ev := reload("addr: \":9090\"\nworkers: 8\n")
+	require.ErrorIs(t, ev.err, ErrImmutableChanged)
+	assert.ErrorContains(t, ev.err, "addr")
+	assert.Nil(t, ev.cfg)
+
+	// 仅修改可变字段：与注册时的配置比较，重新加载成功
+	ev = reload("addr: \":8080\"\nworkers: 8\n")
+	require.NoError(t, ev.err)
+	assert.Equal(t, Config{Addr: ":8080", Workers: 8}, *ev.cfg)
+}
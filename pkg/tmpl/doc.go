@@ -18,8 +18,20 @@
 // # 支持的函数
 //
 //   - env: 获取环境变量 {{env "VAR"}} 或 {{env "VAR" "default"}}
+//   - hasEnv: 环境变量是否已设置且非空，用于按条件保留整段配置 {{- if hasEnv "ENABLE_TLS"}} ... {{- end}}
 //   - default: 管道默认值 {{.VAR | default "fallback"}}
 //   - coalesce: 返回第一个非空值 {{coalesce .VAR1 .VAR2 "default"}}
+//   - coalesceAny: 返回第一个非零值，0、false、空切片和空 map 也视为空 {{coalesceAny (mul .COUNT 1) 5}}
+//   - required: 值为空时展开失败 {{required "VAR is required" .VAR}}
+//   - mustMatch: 值不匹配正则表达式时展开失败，否则原样返回 {{env "EMAIL" | mustMatch "^[^@]+@[^@]+$"}}
+//   - add/sub/mul/div: 数值运算 {{mul (env "WORKERS") 4}}，支持数字字符串
+//   - fromJson: 解析 JSON 字符串 {{fromJson .FLAGS}}
+//   - toInt: 将数字字符串转换为整数 {{env "PORT" | toInt}}
+//   - toJson: 将值序列化为 JSON，在 JSON 配置中保留数字类型 "port": {{env "PORT" | toInt | toJson}}
+//   - readYaml/readJson: 读取 YAML/JSON 文件并访问其字段 {{(readYaml "shared.yaml").region}}，文件不存在时展开失败
+//   - get: 安全读取 map 的 key，缺失时返回空 {{get (fromJson .FLAGS) "beta" | default "off"}}
+//   - randAlphaNum/randHex: 生成指定长度的随机字符串 {{env "SESSION_KEY" | default (randAlphaNum 32)}}，
+//     使用 crypto/rand，每次展开结果不同，不适用于 golden 测试等需要稳定输出的场景
 //
 // # 快速开始
 //
@@ -33,5 +45,27 @@
 //	content := `model: "{{.LLM_MODEL | default "gpt-4"}}"`
 //	expanded, err := tmpl.ExpandTemplate(content)
 //
+// 使用 [WithData] 提供额外数据，使用 [WithoutEnv] 隔离进程环境变量：
+//
+//	expanded, err := tmpl.ExpandTemplate(content, tmpl.WithoutEnv(), tmpl.WithData(vars))
+//
+// 在 JSON 配置中，写在引号内的 "{{env "PORT"}}" 展开后仍是字符串；需要数字时去掉引号并使用 toInt 和 toJson：
+//
+//	content := `{"port": {{env "PORT" | toInt | toJson}}}` // {"port": 8080}
+//
+// 文本本身包含 {{ }} 时，使用 [WithDelims] 更换分隔符：
+//
+//	expanded, err := tmpl.ExpandTemplate(`[[env "X"]] {{keep}}`, tmpl.WithDelims("[[", "]]"))
+//
+// 缺失的 {{.VAR}} 默认展开为 "<no value>"，使用 [WithMissingKeyZero] 或 [ExpandTemplateZero] 可展开为空字符串：
+//
+//	expanded, err := tmpl.ExpandTemplateZero(`host: "{{.MISSING}}"`) // host: ""
+//
+// readYaml/readJson 默认使用 os.ReadFile 读取文件，使用 [WithFileReader] 可自定义相对路径的解析和文件大小限制。
+//
+// 排查模板结果时可使用 [ExpandTemplateTraced] 查看每次函数调用的参数和返回值。
+//
+// 模板来自代码常量、展开失败属于程序缺陷时，可使用 [MustExpandTemplate]，失败时 panic。
+//
 // 详见 [ExpandTemplate] 文档。
 package tmpl
@@ -2,20 +2,142 @@ package tmpl
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
+
+	"go.yaml.in/yaml/v3"
 )
 
+// ═══════════════════════════════════════════════════════════════════════════
+// 展开选项
+// ═══════════════════════════════════════════════════════════════════════════
+
+// options 模板展开选项。
+type options struct {
+	env  map[string]string // 环境变量来源，nil 表示使用进程环境变量
+	data map[string]string // 额外模板数据，覆盖同名环境变量
+
+	leftDelim, rightDelim string // 模板分隔符，空字符串表示默认的 {{ 和 }}
+	missingKeyZero        bool   // 缺失的 {{.VAR}} 展开为空字符串而非 <no value>
+
+	readFile func(path string) ([]byte, error) // readJson/readYaml 读取文件的函数，nil 表示 os.ReadFile
+}
+
+// Option 模板展开选项函数。
+type Option func(*options)
+
+// WithData 设置额外的模板数据。
+//
+// 数据与环境变量合并到顶级命名空间，同名时覆盖环境变量，可通过 {{.KEY}} 访问。
+// 多次调用会合并数据。
+func WithData(data map[string]string) Option {
+	return func(o *options) {
+		if o.data == nil {
+			o.data = make(map[string]string)
+		}
+		maps.Copy(o.data, data)
+	}
+}
+
+// WithEnv 使用指定的变量集合代替进程环境变量。
+//
+// 影响 {{.VAR}} 数据和 env 函数，适用于测试或需要隔离环境的场景。
+func WithEnv(env map[string]string) Option {
+	return func(o *options) {
+		o.env = make(map[string]string, len(env))
+		maps.Copy(o.env, env)
+	}
+}
+
+// WithoutEnv 忽略进程环境变量。
+//
+// 等价于传入空集合的 [WithEnv]：{{.VAR}} 仅能访问 [WithData] 提供的数据，env 函数只返回默认值。
+func WithoutEnv() Option {
+	return WithEnv(map[string]string{})
+}
+
+// WithDelims 使用自定义的模板分隔符代替 {{ 和 }}。
+//
+// 适用于文本中本身包含 {{ }}（如供其他系统使用的 Go 模板）的场景，
+// 例如使用 [[ 和 ]] 后，[[env "X"]] 被展开而 {{keep}} 保持原样。
+// 空字符串表示使用对应的默认分隔符。
+func WithDelims(left, right string) Option {
+	return func(o *options) {
+		o.leftDelim = left
+		o.rightDelim = right
+	}
+}
+
+// WithMissingKeyZero 使缺失的 {{.VAR}} 展开为空字符串，而非 text/template 默认输出的 "<no value>"。
+//
+// 对应 text/template 的 missingkey=zero 选项，避免字面量 "<no value>" 写入配置，同时不报错。
+// 需要在变量缺失时报错请使用 required 函数。
+func WithMissingKeyZero() Option {
+	return func(o *options) {
+		o.missingKeyZero = true
+	}
+}
+
+// WithFileReader 使用指定的函数代替 os.ReadFile 读取 readJson 和 readYaml 引用的文件。
+//
+// 适用于需要按调用方规则解析相对路径或限制文件大小的场景，fn 返回的 error 原样包装后由展开返回。
+func WithFileReader(fn func(path string) ([]byte, error)) Option {
+	return func(o *options) {
+		o.readFile = fn
+	}
+}
+
+// getenv 从配置的环境变量来源读取变量。
+func (o *options) getenv(key string) string {
+	if o.env != nil {
+		return o.env[key]
+	}
+
+	return os.Getenv(key)
+}
+
 // ═══════════════════════════════════════════════════════════════════════════
 // 模板函数 (参考: Taskfile 和 Sprig)
 // ═══════════════════════════════════════════════════════════════════════════
 
-// templateFuncs 模板函数映射表
-var templateFuncs = template.FuncMap{
-	"env":      envFunc,
-	"default":  defaultFunc,
-	"coalesce": coalesceFunc,
+// funcMap 返回模板函数映射表。
+//
+// env、readJson 等函数依赖选项中的环境变量来源和文件读取函数，因此每次展开时按选项构建。
+func (o *options) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"env":         o.envFunc,
+		"hasEnv":      o.hasEnvFunc,
+		"default":     defaultFunc,
+		"coalesce":    coalesceFunc,
+		"coalesceAny": coalesceAnyFunc,
+		"required":    requiredFunc,
+		"mustMatch":   mustMatchFunc,
+		"fromJson":    fromJSONFunc,
+		"get":         getFunc,
+		"readJson":    o.readJSONFunc,
+		"readYaml":    o.readYAMLFunc,
+		"add":         addFunc,
+		"sub":         subFunc,
+		"mul":         mulFunc,
+		"div":         divFunc,
+		"toInt":       toIntFunc,
+		"toJson":      toJSONFunc,
+
+		"randAlphaNum": randAlphaNumFunc,
+		"randHex":      randHexFunc,
+	}
 }
 
 // envFunc 获取环境变量，支持可选的默认值。
@@ -24,8 +146,8 @@ var templateFuncs = template.FuncMap{
 //   - {{env "VAR"}}           获取环境变量，未设置时返回空字符串
 //   - {{env "VAR" "default"}} 获取环境变量，未设置时返回默认值
 //   - {{env "VAR" | default "fallback"}} 管道语法
-func envFunc(key string, defaultVal ...string) string {
-	if val := os.Getenv(key); val != "" {
+func (o *options) envFunc(key string, defaultVal ...string) string {
+	if val := o.getenv(key); val != "" {
 		return val
 	}
 	if len(defaultVal) > 0 {
@@ -35,6 +157,20 @@ func envFunc(key string, defaultVal ...string) string {
 	return ""
 }
 
+// hasEnvFunc 判断环境变量是否已设置且非空，与 env 函数对空值的处理一致。
+//
+// 配合 if 按条件保留整段配置，使用 {{- 去除标签前的换行，使条件为假时不留下空行：
+//
+//	name: app
+//	{{- if hasEnv "ENABLE_TLS"}}
+//	tls:
+//	  cert: /etc/tls/tls.crt
+//	{{- end}}
+//	port: 8080
+func (o *options) hasEnvFunc(key string) bool {
+	return o.getenv(key) != ""
+}
+
 // defaultFunc 提供默认值（管道友好）。
 //
 // 参考 Sprig 实现，参数顺序：default(默认值, 实际值)
@@ -73,6 +209,404 @@ func coalesceFunc(values ...any) any {
 	return nil
 }
 
+// coalesceAnyFunc 返回第一个非零值，是 coalesce 的扩展版本。
+//
+// 除 nil 和空字符串外，数值 0、false、空切片和空 map 也视为空；
+// coalesce 仍只跳过 nil 和空字符串，保持原有行为。
+//
+// 使用方式：
+//   - {{coalesceAny (mul .COUNT 1) 5}}              COUNT 为 0 时返回 5
+//   - {{coalesceAny (fromJson .HOSTS) (fromJson "[\"localhost\"]")}}  空数组时使用默认列表
+func coalesceAnyFunc(values ...any) any {
+	for _, v := range values {
+		if !isZeroValue(v) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// isZeroValue 判断值是否为零值，空切片和空 map（包括非 nil 的）同样视为零值。
+func isZeroValue(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return val.Len() == 0
+	default:
+		return val.IsZero()
+	}
+}
+
+// requiredFunc 值为空时返回以 msg 为内容的 error，否则原样返回值（参考 Sprig）。
+//
+// 空值的判断规则同 defaultFunc：nil 或空字符串。
+//
+// 使用方式：
+//   - {{required "DB_PASSWORD is required" .DB_PASSWORD}}
+//   - {{env "API_KEY" | required "API_KEY is required"}}
+func requiredFunc(msg string, value any) (any, error) {
+	if value == nil {
+		return nil, errors.New(msg)
+	}
+	if str, ok := value.(string); ok && str == "" {
+		return nil, errors.New(msg)
+	}
+
+	return value, nil
+}
+
+// mustMatchFunc 值匹配正则表达式 pattern 时原样返回，否则返回 error，用于内联校验环境变量。
+//
+// error 只包含 pattern，不包含值本身，避免密码等敏感值出现在日志中。
+//
+// pattern 使用 Go regexp 语法，未加 ^$ 时只需部分匹配；pattern 无效时同样返回 error。
+//
+// 使用方式：
+//   - {{env "EMAIL" | mustMatch "^[^@]+@[^@]+$"}}
+//   - {{mustMatch "^[0-9]+$" .PORT}}
+func mustMatchFunc(pattern, value string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("mustMatch: invalid pattern %q: %w", pattern, err)
+	}
+	if !re.MatchString(value) {
+		return "", fmt.Errorf("mustMatch: value does not match pattern %q", pattern)
+	}
+
+	return value, nil
+}
+
+// fromJSONFunc 将 JSON 字符串解析为值（对象解析为 map[string]any），便于配合 get 使用。
+//
+// 空字符串返回 nil，无效的 JSON 返回 error。
+//
+// 使用方式：
+//   - {{get (fromJson .FLAGS) "beta"}}
+func fromJSONFunc(s string) (any, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil //nolint:nilnil // 空输入视为缺失值
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, fmt.Errorf("fromJson: %w", err)
+	}
+
+	return v, nil
+}
+
+// getFunc 从 map 中安全地读取 key，key 不存在或 map 为 nil 时返回空字符串（管道友好）。
+//
+// 与内置 index 不同，缺失的 key 不会产生 "<no value>"，可直接接 default 使用。
+// 参数不是以字符串为键的 map 时返回 error。
+//
+// 使用方式：
+//   - {{get (fromJson .FLAGS) "beta" | default "off"}}
+func getFunc(m any, key string) (any, error) {
+	if m == nil {
+		return "", nil
+	}
+
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("get: %T is not a map with string keys", m)
+	}
+	if v.IsNil() {
+		return "", nil
+	}
+
+	val := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+	if !val.IsValid() || (val.Kind() == reflect.Interface && val.IsNil()) {
+		return "", nil
+	}
+
+	return val.Interface(), nil
+}
+
+// readJSONFunc 读取 JSON 文件并解析为 map，可在模板中访问其字段。
+//
+// 文件通过 [WithFileReader] 指定的函数读取，未指定时相对路径相对于当前工作目录；
+// 文件不存在、无法解析或顶层不是对象时返回 error。
+//
+// 使用方式：
+//   - {{(readJson "shared.json").region}}
+//   - {{get (readJson "shared.json") "region" | default "cn"}}
+func (o *options) readJSONFunc(path string) (map[string]any, error) {
+	data, err := o.readTemplateFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("readJson: %w", err)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("readJson %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// readYAMLFunc 读取 YAML 文件并解析为 map，可在模板中访问其字段。
+//
+// 文件通过 [WithFileReader] 指定的函数读取，未指定时相对路径相对于当前工作目录；
+// 文件不存在、无法解析或顶层不是映射时返回 error。
+// 空文件返回空 map。
+//
+// 使用方式：
+//   - {{(readYaml "shared.yaml").region}}
+//   - {{(readYaml "shared.yaml").database.host}}
+func (o *options) readYAMLFunc(path string) (map[string]any, error) {
+	data, err := o.readTemplateFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("readYaml: %w", err)
+	}
+
+	m := map[string]any{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("readYaml %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// readTemplateFile 读取 readJson/readYaml 引用的文件，未设置 [WithFileReader] 时使用 os.ReadFile。
+func (o *options) readTemplateFile(path string) ([]byte, error) {
+	if o.readFile != nil {
+		return o.readFile(path)
+	}
+
+	return os.ReadFile(path) //nolint:gosec // path is from trusted template
+}
+
+// addFunc 返回 a + b。
+//
+// 参数可以是数字或数字字符串（如 env 函数的返回值），两者均为整数时返回整数，否则返回浮点数。
+//
+// 使用方式：
+//   - {{add (env "BASE_PORT") 1}}
+func addFunc(a, b any) (any, error) {
+	return arithmetic("add", a, b,
+		func(x, y int64) (int64, error) { return x + y, nil },
+		func(x, y float64) (float64, error) { return x + y, nil })
+}
+
+// subFunc 返回 a - b，类型规则同 addFunc。
+//
+// 使用方式：
+//   - {{sub (env "TOTAL") 2}}
+func subFunc(a, b any) (any, error) {
+	return arithmetic("sub", a, b,
+		func(x, y int64) (int64, error) { return x - y, nil },
+		func(x, y float64) (float64, error) { return x - y, nil })
+}
+
+// mulFunc 返回 a * b，类型规则同 addFunc。
+//
+// 使用方式：
+//   - {{mul (env "WORKERS") 4}}
+func mulFunc(a, b any) (any, error) {
+	return arithmetic("mul", a, b,
+		func(x, y int64) (int64, error) { return x * y, nil },
+		func(x, y float64) (float64, error) { return x * y, nil })
+}
+
+// divFunc 返回 a / b，两者均为整数时执行整数除法。除数为 0 时返回 error。
+//
+// 使用方式：
+//   - {{div (env "MEMORY_MB") 4}}
+func divFunc(a, b any) (any, error) {
+	return arithmetic("div", a, b,
+		func(x, y int64) (int64, error) {
+			if y == 0 {
+				return 0, errors.New("division by zero")
+			}
+
+			return x / y, nil
+		},
+		func(x, y float64) (float64, error) {
+			if y == 0 {
+				return 0, errors.New("division by zero")
+			}
+
+			return x / y, nil
+		})
+}
+
+// toIntFunc 将数字或数字字符串转换为 int64，值不是整数时返回 error。
+//
+// 常与 toJson 配合，在 JSON 配置中输出不带引号的数字。
+//
+// 使用方式：
+//   - {{env "PORT" | toInt}}
+//   - "port": {{env "PORT" | toInt | toJson}}
+func toIntFunc(v any) (int64, error) {
+	n, err := toNumber(v)
+	if err != nil {
+		return 0, fmt.Errorf("toInt: %w", err)
+	}
+	switch n := n.(type) {
+	case int64:
+		return n, nil
+	case float64:
+		if n == float64(int64(n)) {
+			return int64(n), nil
+		}
+	}
+
+	return 0, fmt.Errorf("toInt: %v is not an integer", v)
+}
+
+// toJSONFunc 将值序列化为 JSON，用于在 JSON 配置中保留值的类型。
+//
+// 字符串输出为带引号并转义的 JSON 字符串，数字和 bool 不带引号，map 和切片输出为 JSON 对象和数组。
+//
+// 使用方式：
+//   - "port": {{env "PORT" | toInt | toJson}}   输出 "port": 8080
+//   - "name": {{env "APP_NAME" | toJson}}       值中的引号和换行被正确转义
+func toJSONFunc(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("toJson: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// alphaNumChars randAlphaNum 使用的字符集。
+const alphaNumChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randAlphaNumFunc 使用 crypto/rand 生成 n 个字母和数字组成的随机字符串。
+//
+// 每次展开结果不同，不适用于需要稳定输出的场景（如 golden 测试）。n 为负数时返回 error。
+//
+// 使用方式：
+//   - {{env "SESSION_KEY" | default (randAlphaNum 32)}}
+func randAlphaNumFunc(n int) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("randAlphaNum: negative length %d", n)
+	}
+
+	buf := make([]byte, n)
+	limit := big.NewInt(int64(len(alphaNumChars)))
+	for i := range buf {
+		idx, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", fmt.Errorf("randAlphaNum: %w", err)
+		}
+		buf[i] = alphaNumChars[idx.Int64()]
+	}
+
+	return string(buf), nil
+}
+
+// randHexFunc 使用 crypto/rand 生成 n 个小写十六进制字符组成的随机字符串。
+//
+// 每次展开结果不同，不适用于需要稳定输出的场景（如 golden 测试）。n 为负数时返回 error。
+//
+// 使用方式：
+//   - {{env "API_TOKEN" | default (randHex 64)}}
+func randHexFunc(n int) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("randHex: negative length %d", n)
+	}
+
+	buf := make([]byte, (n+1)/2)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("randHex: %w", err)
+	}
+
+	return hex.EncodeToString(buf)[:n], nil
+}
+
+// arithmetic 将参数转换为数字后执行运算：均为整数时使用 intOp，否则使用 floatOp。
+func arithmetic(
+	name string, a, b any,
+	intOp func(x, y int64) (int64, error),
+	floatOp func(x, y float64) (float64, error),
+) (any, error) {
+	x, err := toNumber(a)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	y, err := toNumber(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	xi, xIsInt := x.(int64)
+	yi, yIsInt := y.(int64)
+	if xIsInt && yIsInt {
+		result, err := intOp(xi, yi)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		return result, nil
+	}
+
+	result, err := floatOp(toFloat(x), toFloat(y))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return result, nil
+}
+
+// toNumber 将值转换为 int64 或 float64，支持数字类型和数字字符串。
+func toNumber(v any) (any, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return int64(n), nil //nolint:gosec // template arithmetic on config values
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil //nolint:gosec // template arithmetic on config values
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case string:
+		s := strings.TrimSpace(n)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+
+		return nil, fmt.Errorf("%q is not a number", n)
+	default:
+		return nil, fmt.Errorf("%v (%T) is not a number", v, v)
+	}
+}
+
+// toFloat 将 toNumber 的结果转换为 float64。
+func toFloat(n any) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+
+	return n.(float64) //nolint:forcetypeassert // toNumber returns int64 or float64
+}
+
 // ═══════════════════════════════════════════════════════════════════════════
 // 模板数据对象 (与 Taskfile 设计对齐)
 // ═══════════════════════════════════════════════════════════════════════════
@@ -80,15 +614,20 @@ func coalesceFunc(values ...any) any {
 // newTemplateData 创建模板数据对象。
 //
 // 返回 map[string]string，支持 Taskfile 风格的 {{.VAR}} 语法。
-// 所有环境变量自动加载到顶级命名空间。
-func newTemplateData() map[string]string {
+// 所有环境变量自动加载到顶级命名空间，[WithData] 提供的数据覆盖同名环境变量。
+func (o *options) newTemplateData() map[string]string {
 	vars := make(map[string]string)
-	for _, env := range os.Environ() {
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) == 2 {
-			vars[parts[0]] = parts[1]
+	if o.env != nil {
+		maps.Copy(vars, o.env)
+	} else {
+		for _, env := range os.Environ() {
+			parts := strings.SplitN(env, "=", 2)
+			if len(parts) == 2 {
+				vars[parts[0]] = parts[1]
+			}
 		}
 	}
+	maps.Copy(vars, o.data)
 
 	return vars
 }
@@ -107,15 +646,71 @@ func newTemplateData() map[string]string {
 //   - {{env "VAR" "default"}} - 带默认值
 //   - {{.VAR | default "fallback"}} - 管道式默认值
 //   - {{coalesce .VAR1 .VAR2 "default"}} - 多级 fallback
+//   - {{required "VAR is required" .VAR}} - 值为空时展开失败
+//   - {{env "EMAIL" | mustMatch "^[^@]+@[^@]+$"}} - 值不匹配正则时展开失败
+//   - {{get (fromJson .FLAGS) "beta" | default "off"}} - 安全读取 JSON 对象的 key
+//   - {{(readYaml "shared.yaml").region}} - 读取 YAML/JSON 文件的字段
+//   - {{env "SESSION_KEY" | default (randAlphaNum 32)}} - 随机默认值（每次展开结果不同）
+//
+// 可通过 [WithData]、[WithEnv]、[WithoutEnv] 等选项调整模板数据来源，
+// 通过 [WithDelims] 更换分隔符，通过 [WithMissingKeyZero] 使缺失的变量展开为空字符串。
+//
+// 返回展开后的字符串。如果模板语法错误或执行失败，返回 error；
+// 调用未定义的函数时，错误信息中附带可用的函数列表。
+func ExpandTemplate(text string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+
+	return o.expand(text, o.funcMap())
+}
+
+// ExpandTemplateZero 展开模板，缺失的 {{.VAR}} 展开为空字符串。
+//
+// 等价于附加 [WithMissingKeyZero] 选项的 [ExpandTemplate]：
 //
-// 返回展开后的字符串。如果模板语法错误或执行失败，返回 error。
-func ExpandTemplate(text string) (string, error) {
-	tmpl, err := template.New("config").Funcs(templateFuncs).Parse(text)
+//	out, _ := tmpl.ExpandTemplateZero(`host: "{{.MISSING}}"`) // host: ""
+func ExpandTemplateZero(text string, opts ...Option) (string, error) {
+	return ExpandTemplate(text, append(opts, WithMissingKeyZero())...)
+}
+
+// MustExpandTemplate 是 [ExpandTemplate] 的 panic 版本。
+//
+// 模板语法错误或执行失败时 panic，panic 值为包装了原始错误的 error。
+// 适用于模板来自代码常量、展开失败意味着程序缺陷的场景。
+//
+// 示例：
+//
+//	dsn := tmpl.MustExpandTemplate(`postgres://{{env "DB_HOST" "localhost"}}:5432/app`)
+func MustExpandTemplate(text string, opts ...Option) string {
+	out, err := ExpandTemplate(text, opts...)
 	if err != nil {
-		return "", err
+		panic(fmt.Errorf("tmpl: failed to expand template: %w", err))
 	}
 
-	data := newTemplateData()
+	return out
+}
+
+// newOptions 解析展开选项。
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// expand 使用指定的函数表展开模板。
+func (o *options) expand(text string, funcs template.FuncMap) (string, error) {
+	t := template.New("config").Delims(o.leftDelim, o.rightDelim).Funcs(funcs)
+	if o.missingKeyZero {
+		t = t.Option("missingkey=zero")
+	}
+	tmpl, err := t.Parse(text)
+	if err != nil {
+		return "", withAvailableFuncs(err, funcs)
+	}
+
+	data := o.newTemplateData()
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
@@ -124,3 +719,15 @@ func ExpandTemplate(text string) (string, error) {
 
 	return buf.String(), nil
 }
+
+// withAvailableFuncs 在"函数未定义"的解析错误后附加可用的模板函数列表，其他错误原样返回。
+//
+// text/template 的原始错误只包含未定义的函数名，附加列表便于发现拼写错误。
+func withAvailableFuncs(err error, funcs template.FuncMap) error {
+	msg := err.Error()
+	if !strings.Contains(msg, "function \"") || !strings.HasSuffix(msg, "not defined") {
+		return err
+	}
+
+	return fmt.Errorf("%w (available functions: %s)", err, strings.Join(slices.Sorted(maps.Keys(funcs)), ", "))
+}
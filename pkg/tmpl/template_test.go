@@ -1,6 +1,10 @@
 package tmpl_test
 
 import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/tmpl"
@@ -48,6 +52,43 @@ func TestTemplateFunction_env(t *testing.T) {
 	}
 }
 
+func TestTemplateFunction_hasEnv(t *testing.T) {
+	const text = `name: app
+{{- if hasEnv "ENABLE_TLS"}}
+tls:
+  cert: /etc/tls/tls.crt
+  key: /etc/tls/tls.key
+{{- end}}
+port: 8080
+`
+
+	t.Run("block present when env set", func(t *testing.T) {
+		got, err := tmpl.ExpandTemplate(text, tmpl.WithEnv(map[string]string{"ENABLE_TLS": "1"}))
+		require.NoError(t, err)
+		assert.Equal(t, "name: app\ntls:\n  cert: /etc/tls/tls.crt\n  key: /etc/tls/tls.key\nport: 8080\n", got)
+	})
+
+	t.Run("block elided when env unset", func(t *testing.T) {
+		got, err := tmpl.ExpandTemplate(text, tmpl.WithoutEnv())
+		require.NoError(t, err)
+		assert.Equal(t, "name: app\nport: 8080\n", got)
+	})
+
+	t.Run("empty value counts as unset", func(t *testing.T) {
+		got, err := tmpl.ExpandTemplate(text, tmpl.WithEnv(map[string]string{"ENABLE_TLS": ""}))
+		require.NoError(t, err)
+		assert.Equal(t, "name: app\nport: 8080\n", got)
+	})
+
+	t.Run("process env", func(t *testing.T) {
+		t.Setenv("ENABLE_TLS", "true")
+
+		got, err := tmpl.ExpandTemplate(`{{if hasEnv "ENABLE_TLS"}}on{{else}}off{{end}}`)
+		require.NoError(t, err)
+		assert.Equal(t, "on", got)
+	})
+}
+
 func TestTemplateFunction_default(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -126,6 +167,32 @@ func TestTemplateFunction_coalesce(t *testing.T) {
 	}
 }
 
+func TestTemplateFunction_coalesceAny(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"int zero skipped", `{{coalesceAny 0 5}}`, "5"},
+		{"int non-zero kept", `{{coalesceAny 3 5}}`, "3"},
+		{"computed zero", `{{coalesceAny (mul .COUNT 1) 5}}`, "5"},
+		{"bool false skipped", `{{coalesceAny false true}}`, "true"},
+		{"empty slice skipped", `{{coalesceAny (fromJson "[]") (fromJson "[1]")}}`, "[1]"},
+		{"empty map skipped", `{{coalesceAny (fromJson "{}") "fallback"}}`, "fallback"},
+		{"empty string skipped", `{{coalesceAny .MISSING_VAR "x"}}`, "x"},
+		{"all zero", `{{coalesceAny 0 false ""}}`, "<no value>"},
+		{"coalesce keeps zero", `{{coalesce 0 5}}`, "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template, tmpl.WithEnv(map[string]string{"COUNT": "0"}))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestTemplateData_DirectVarAccess(t *testing.T) {
 	t.Setenv("MY_VAR", "my-value")
 
@@ -224,3 +291,433 @@ func TestExpandTemplate_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandTemplate_UndefinedFunction(t *testing.T) {
+	_, err := tmpl.ExpandTemplate(`{{undefined_func "arg"}}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `function "undefined_func" not defined`)
+	assert.Contains(t, err.Error(), "available functions: add, coalesce, coalesceAny, default, div, env, fromJson, get,")
+	assert.Contains(t, err.Error(), "readYaml, required")
+
+	t.Run("traced", func(t *testing.T) {
+		_, _, err := tmpl.ExpandTemplateTraced(`{{undefined_func}}`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "available functions: add, coalesce")
+	})
+
+	t.Run("other parse errors unchanged", func(t *testing.T) {
+		_, err := tmpl.ExpandTemplate(`{{env "VAR"`)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "available functions")
+	})
+}
+
+func TestMustExpandTemplate(t *testing.T) {
+	t.Run("valid template", func(t *testing.T) {
+		got := tmpl.MustExpandTemplate(`{{.NAME | default "app"}}`, tmpl.WithoutEnv())
+		assert.Equal(t, "app", got)
+	})
+
+	t.Run("syntax error panics with wrapped error", func(t *testing.T) {
+		_, wantErr := tmpl.ExpandTemplate(`{{env "VAR"`)
+		require.Error(t, wantErr)
+
+		defer func() {
+			r := recover()
+			err, ok := r.(error)
+			require.True(t, ok, "panic value should be an error, got %T", r)
+			assert.Contains(t, err.Error(), "unclosed action")
+			assert.Equal(t, wantErr.Error(), errors.Unwrap(err).Error())
+		}()
+		tmpl.MustExpandTemplate(`{{env "VAR"`)
+		t.Fatal("MustExpandTemplate should panic")
+	})
+}
+
+// =============================================================================
+// 展开选项测试
+// =============================================================================
+
+func TestExpandTemplate_Options(t *testing.T) {
+	t.Setenv("OPT_VAR", "from-env")
+
+	tests := []struct {
+		name     string
+		template string
+		opts     []tmpl.Option
+		want     string
+	}{
+		{
+			name:     "WithData adds variables",
+			template: `{{.region}}`,
+			opts:     []tmpl.Option{tmpl.WithData(map[string]string{"region": "us-east-1"})},
+			want:     "us-east-1",
+		},
+		{
+			name:     "WithData overrides env",
+			template: `{{.OPT_VAR}}`,
+			opts:     []tmpl.Option{tmpl.WithData(map[string]string{"OPT_VAR": "from-data"})},
+			want:     "from-data",
+		},
+		{
+			name:     "WithoutEnv hides env data",
+			template: `{{.OPT_VAR | default "none"}}`,
+			opts:     []tmpl.Option{tmpl.WithoutEnv()},
+			want:     "none",
+		},
+		{
+			name:     "WithoutEnv hides env function",
+			template: `{{env "OPT_VAR" "fallback"}}`,
+			opts:     []tmpl.Option{tmpl.WithoutEnv()},
+			want:     "fallback",
+		},
+		{
+			name:     "WithoutEnv keeps WithData",
+			template: `{{.region}}`,
+			opts:     []tmpl.Option{tmpl.WithoutEnv(), tmpl.WithData(map[string]string{"region": "eu"})},
+			want:     "eu",
+		},
+		{
+			name:     "WithEnv replaces env",
+			template: `{{env "OPT_VAR"}}-{{.OTHER}}`,
+			opts:     []tmpl.Option{tmpl.WithEnv(map[string]string{"OPT_VAR": "a", "OTHER": "b"})},
+			want:     "a-b",
+		},
+		{
+			name:     "WithDelims leaves default delimiters literal",
+			template: `[[env "OPT_VAR"]] {{keep}} [[.OPT_VAR | default "x"]]`,
+			opts:     []tmpl.Option{tmpl.WithDelims("[[", "]]")},
+			want:     "from-env {{keep}} from-env",
+		},
+		{
+			name:     "WithMissingKeyZero renders empty string",
+			template: `[{{.OPT_MISSING}}] [{{.OPT_MISSING | default "x"}}]`,
+			opts:     []tmpl.Option{tmpl.WithMissingKeyZero()},
+			want:     "[] [x]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template, tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTemplateFunction_arithmetic(t *testing.T) {
+	t.Setenv("WORKERS", "8")
+	t.Setenv("RATIO", "0.5")
+	t.Setenv("NOT_NUMBER", "abc")
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		errMsg   string
+	}{
+		{name: "add ints", template: `{{add 2 3}}`, want: "5"},
+		{name: "add env string", template: `{{add (env "WORKERS") 2}}`, want: "10"},
+		{name: "sub", template: `{{sub (env "WORKERS") 3}}`, want: "5"},
+		{name: "mul env", template: `{{mul (env "WORKERS") 4}}`, want: "32"},
+		{name: "mul float", template: `{{mul (env "WORKERS") (env "RATIO")}}`, want: "4"},
+		{name: "div ints", template: `{{div (env "WORKERS") 3}}`, want: "2"},
+		{name: "div float", template: `{{div 1.0 4}}`, want: "0.25"},
+		{name: "div by zero", template: `{{div (env "WORKERS") 0}}`, errMsg: "division by zero"},
+		{name: "div by zero float", template: `{{div 1.5 0}}`, errMsg: "division by zero"},
+		{name: "non-numeric input", template: `{{add (env "NOT_NUMBER") 1}}`, errMsg: `"abc" is not a number`},
+		{name: "missing env", template: `{{mul (env "MISSING_WORKERS") 4}}`, errMsg: "is not a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTemplateFunction_get(t *testing.T) {
+	t.Setenv("FLAGS", `{"beta": "on", "new_ui": true, "limit": null}`)
+	t.Setenv("BAD_JSON", `{"beta":`)
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		errMsg   string
+	}{
+		{name: "present key", template: `{{get (fromJson .FLAGS) "beta"}}`, want: "on"},
+		{name: "present bool key", template: `{{get (fromJson .FLAGS) "new_ui"}}`, want: "true"},
+		{name: "absent key", template: `{{get (fromJson .FLAGS) "gamma"}}`, want: ""},
+		{name: "absent key with default", template: `{{get (fromJson .FLAGS) "gamma" | default "off"}}`, want: "off"},
+		{name: "null value with default", template: `{{get (fromJson .FLAGS) "limit" | default "10"}}`, want: "10"},
+		{name: "unset json with default", template: `{{get (fromJson (env "MISSING_FLAGS")) "beta" | default "off"}}`, want: "off"},
+		{name: "invalid json", template: `{{get (fromJson .BAD_JSON) "beta"}}`, errMsg: "fromJson"},
+		{name: "not a map", template: `{{get "text" "beta"}}`, errMsg: "is not a map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTemplateFunction_readFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "shared.yaml")
+	jsonPath := filepath.Join(dir, "shared.json")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("region: cn-east\ndatabase:\n  host: db.internal\n  port: 5432\n"), 0600))
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"region": "us-west", "database": {"host": "db.example"}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "list.json"), []byte(`["a"]`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.yaml"), nil, 0600))
+	t.Setenv("SHARED_YAML", yamlPath)
+	t.Setenv("SHARED_JSON", jsonPath)
+	t.Setenv("DATA_DIR", dir)
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		errMsg   string
+	}{
+		{name: "yaml field", template: `{{(readYaml .SHARED_YAML).region}}`, want: "cn-east"},
+		{name: "yaml nested field", template: `{{(readYaml .SHARED_YAML).database.host}}:{{(readYaml .SHARED_YAML).database.port}}`, want: "db.internal:5432"},
+		{name: "json nested field", template: `{{(readJson .SHARED_JSON).database.host}}`, want: "db.example"},
+		{name: "with get and default", template: `{{get (readYaml .SHARED_YAML) "zone" | default "a"}}`, want: "a"},
+		{name: "empty yaml", template: `{{get (readYaml (print .DATA_DIR "/empty.yaml")) "region" | default "none"}}`, want: "none"},
+		{name: "missing yaml", template: `{{(readYaml (print .DATA_DIR "/missing.yaml")).region}}`, errMsg: "readYaml"},
+		{name: "missing json", template: `{{(readJson (print .DATA_DIR "/missing.json")).region}}`, errMsg: "readJson"},
+		{name: "json not an object", template: `{{readJson (print .DATA_DIR "/list.json")}}`, errMsg: "readJson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("missing file wraps fs.ErrNotExist", func(t *testing.T) {
+		_, err := tmpl.ExpandTemplate(`{{readYaml "` + filepath.ToSlash(filepath.Join(dir, "missing.yaml")) + `"}}`)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("custom file reader", func(t *testing.T) {
+		errTooLarge := errors.New("too large")
+		var paths []string
+		reader := tmpl.WithFileReader(func(path string) ([]byte, error) {
+			paths = append(paths, path)
+			if path == "big.yaml" {
+				return nil, errTooLarge
+			}
+
+			return os.ReadFile(filepath.Join(dir, path))
+		})
+
+		got, err := tmpl.ExpandTemplate(`{{(readYaml "shared.yaml").region}} {{(readJson "shared.json").region}}`, reader)
+		require.NoError(t, err)
+		assert.Equal(t, "cn-east us-west", got)
+		assert.Equal(t, []string{"shared.yaml", "shared.json"}, paths)
+
+		_, err = tmpl.ExpandTemplate(`{{readYaml "big.yaml"}}`, reader)
+		require.ErrorIs(t, err, errTooLarge)
+	})
+}
+
+func TestTemplateFunction_random(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		length   int
+		charset  string
+	}{
+		{name: "randAlphaNum", template: `{{randAlphaNum 32}}`, length: 32, charset: `^[a-zA-Z0-9]*$`},
+		{name: "randHex even", template: `{{randHex 64}}`, length: 64, charset: `^[0-9a-f]*$`},
+		{name: "randHex odd", template: `{{randHex 7}}`, length: 7, charset: `^[0-9a-f]*$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := tmpl.ExpandTemplate(tt.template)
+			require.NoError(t, err)
+			second, err := tmpl.ExpandTemplate(tt.template)
+			require.NoError(t, err)
+
+			assert.Len(t, first, tt.length)
+			assert.Regexp(t, tt.charset, first)
+			assert.NotEqual(t, first, second)
+		})
+	}
+
+	t.Run("default fallback", func(t *testing.T) {
+		t.Setenv("SESSION_KEY", "")
+		got, err := tmpl.ExpandTemplate(`{{env "SESSION_KEY" | default (randAlphaNum 16)}}`)
+		require.NoError(t, err)
+		assert.Len(t, got, 16)
+
+		t.Setenv("SESSION_KEY", "fixed")
+		got, err = tmpl.ExpandTemplate(`{{env "SESSION_KEY" | default (randAlphaNum 16)}}`)
+		require.NoError(t, err)
+		assert.Equal(t, "fixed", got)
+	})
+
+	t.Run("zero and negative length", func(t *testing.T) {
+		got, err := tmpl.ExpandTemplate(`{{randHex 0}}`)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+
+		_, err = tmpl.ExpandTemplate(`{{randAlphaNum -1}}`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "negative length")
+	})
+}
+
+func TestTemplateFunction_required(t *testing.T) {
+	t.Setenv("REQ_SET", "value")
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		errMsg   string
+	}{
+		{name: "set var", template: `{{required "REQ_SET is required" .REQ_SET}}`, want: "value"},
+		{name: "pipeline", template: `{{env "REQ_SET" | required "REQ_SET is required"}}`, want: "value"},
+		{name: "unset var", template: `{{required "REQ_UNSET is required" (env "REQ_UNSET")}}`, errMsg: "REQ_UNSET is required"},
+		{name: "missing key", template: `{{required "REQ_MISSING is required" .REQ_MISSING}}`, errMsg: "REQ_MISSING is required"},
+		{name: "nil from get", template: `{{required "beta flag is required" (get (fromJson "{\"beta\": null}") "beta")}}`, errMsg: "beta flag is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTemplateFunction_mustMatch(t *testing.T) {
+	t.Setenv("MATCH_EMAIL", "admin@example.com")
+	t.Setenv("MATCH_BAD_EMAIL", "not-an-email")
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		errMsg   string
+	}{
+		{name: "matching value", template: `{{env "MATCH_EMAIL" | mustMatch "^[^@]+@[^@]+$"}}`, want: "admin@example.com"},
+		{name: "direct call", template: `{{mustMatch "^[0-9]+$" "8080"}}`, want: "8080"},
+		{
+			name:     "non-matching value",
+			template: `{{env "MATCH_BAD_EMAIL" | mustMatch "^[^@]+@[^@]+$"}}`,
+			errMsg:   `value does not match pattern "^[^@]+@[^@]+$"`,
+		},
+		{name: "invalid pattern", template: `{{mustMatch "[" "x"}}`, errMsg: `invalid pattern "["`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("error does not contain value", func(t *testing.T) {
+		t.Setenv("MATCH_SECRET", "s3cret-token")
+
+		_, err := tmpl.ExpandTemplate(`{{env "MATCH_SECRET" | mustMatch "^[0-9]+$"}}`)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "s3cret-token")
+	})
+}
+
+func TestTemplateFunction_toJson(t *testing.T) {
+	t.Setenv("JSON_PORT", "8080")
+	t.Setenv("JSON_NAME", `my "app"`)
+
+	got, err := tmpl.ExpandTemplate(`{"port": {{env "JSON_PORT" | toInt | toJson}}, "name": {{env "JSON_NAME" | toJson}}}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"port": 8080, "name": "my \"app\""}`, got)
+
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(got), &parsed))
+	assert.InDelta(t, 8080, parsed["port"], 0, "port should be a JSON number")
+	assert.Equal(t, `my "app"`, parsed["name"])
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		errMsg   string
+	}{
+		{name: "quoted env stays string", template: `{{env "JSON_PORT" | toJson}}`, want: `"8080"`},
+		{name: "list", template: `{{fromJson "[1,2]" | toJson}}`, want: `[1,2]`},
+		{name: "integral float", template: `{{toInt "3.0"}}`, want: "3"},
+		{name: "not a number", template: `{{toInt "abc"}}`, errMsg: `toInt: "abc" is not a number`},
+		{name: "not an integer", template: `{{toInt "1.5"}}`, errMsg: "toInt: 1.5 is not an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ExpandTemplate(tt.template)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpandTemplateZero(t *testing.T) {
+	got, err := tmpl.ExpandTemplateZero(`host: "{{.ZERO_MISSING}}"`, tmpl.WithoutEnv())
+	require.NoError(t, err)
+	assert.Equal(t, `host: ""`, got)
+
+	got, err = tmpl.ExpandTemplate(`host: "{{.ZERO_MISSING}}"`, tmpl.WithoutEnv())
+	require.NoError(t, err)
+	assert.Equal(t, `host: "<no value>"`, got)
+}
@@ -0,0 +1,76 @@
+package tmpl
+
+import (
+	"reflect"
+	"text/template"
+)
+
+// TraceEntry 记录一次模板函数调用。
+type TraceEntry struct {
+	Func   string // 函数名，如 env、coalesce
+	Args   []any  // 调用参数
+	Result any    // 返回值（函数出错时为 nil）
+	Err    error  // 函数返回的错误
+}
+
+// ExpandTemplateTraced 展开模板并记录每次模板函数调用的参数和返回值。
+//
+// 用于排查复杂模板产生意外值的原因，仅作调试用途，不建议在生产环境使用。
+// 返回的调用记录按执行顺序排列；模板执行出错时仍返回出错前的记录。
+//
+// 示例：
+//
+//	out, trace, err := tmpl.ExpandTemplateTraced(`{{coalesce .A .B "x"}}`)
+//	for _, e := range trace {
+//	    fmt.Printf("%s%v = %v\n", e.Func, e.Args, e.Result)
+//	}
+func ExpandTemplateTraced(text string, opts ...Option) (string, []TraceEntry, error) {
+	o := newOptions(opts)
+
+	var trace []TraceEntry
+	funcs := make(template.FuncMap)
+	for name, fn := range o.funcMap() {
+		funcs[name] = traceFunc(name, fn, &trace)
+	}
+
+	out, err := o.expand(text, funcs)
+
+	return out, trace, err
+}
+
+// traceFunc 包装模板函数，调用时将参数和返回值追加到 trace。
+//
+// 包装函数与原函数签名相同，因此 text/template 的参数检查和错误处理行为不变。
+func traceFunc(name string, fn any, trace *[]TraceEntry) any {
+	fnVal := reflect.ValueOf(fn)
+	fnType := fnVal.Type()
+
+	return reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+		var out []reflect.Value
+		if fnType.IsVariadic() {
+			out = fnVal.CallSlice(in)
+		} else {
+			out = fnVal.Call(in)
+		}
+
+		entry := TraceEntry{Func: name}
+		for i, arg := range in {
+			if fnType.IsVariadic() && i == len(in)-1 {
+				for j := range arg.Len() {
+					entry.Args = append(entry.Args, arg.Index(j).Interface())
+				}
+
+				continue
+			}
+			entry.Args = append(entry.Args, arg.Interface())
+		}
+		if len(out) == 2 && !out[1].IsNil() {
+			entry.Err, _ = out[1].Interface().(error)
+		} else {
+			entry.Result = out[0].Interface()
+		}
+		*trace = append(*trace, entry)
+
+		return out
+	}).Interface()
+}
@@ -0,0 +1,48 @@
+package tmpl_test
+
+import (
+	"testing"
+
+	"github.com/lwmacct/251207-go-pkg-cfgm/pkg/tmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandTemplateTraced(t *testing.T) {
+	env := map[string]string{"HOST": "db.local", "DEV_URL": "http://dev"}
+
+	t.Run("records env and coalesce", func(t *testing.T) {
+		out, trace, err := tmpl.ExpandTemplateTraced(
+			`{{env "HOST"}} {{coalesce .PROD_URL .DEV_URL "http://localhost"}}`,
+			tmpl.WithEnv(env),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "db.local http://dev", out)
+
+		require.Len(t, trace, 2)
+		assert.Equal(t, tmpl.TraceEntry{Func: "env", Args: []any{"HOST"}, Result: "db.local"}, trace[0])
+		assert.Equal(t, "coalesce", trace[1].Func)
+		assert.Equal(t, []any{nil, "http://dev", "http://localhost"}, trace[1].Args, "missing key is passed as nil")
+		assert.Equal(t, "http://dev", trace[1].Result)
+	})
+
+	t.Run("records pipeline order", func(t *testing.T) {
+		_, trace, err := tmpl.ExpandTemplateTraced(`{{env "MISSING" | default "fallback"}}`, tmpl.WithEnv(env))
+		require.NoError(t, err)
+
+		require.Len(t, trace, 2)
+		assert.Equal(t, "env", trace[0].Func)
+		assert.Equal(t, "", trace[0].Result)
+		assert.Equal(t, tmpl.TraceEntry{Func: "default", Args: []any{"fallback", ""}, Result: "fallback"}, trace[1])
+	})
+
+	t.Run("records function errors", func(t *testing.T) {
+		_, trace, err := tmpl.ExpandTemplateTraced(`{{div 1 0}}`)
+		require.Error(t, err)
+
+		require.Len(t, trace, 1)
+		assert.Equal(t, "div", trace[0].Func)
+		require.Error(t, trace[0].Err)
+		assert.Contains(t, trace[0].Err.Error(), "division by zero")
+	})
+}